@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"go-tempo/examples/onboarding"
+	"go-tempo/internal/config"
+	"go-tempo/internal/metrics"
+	"go-tempo/internal/worker"
+	"go-tempo/internal/worker/actions"
+	workersdk "go-tempo/pkg/worker"
+)
+
+// taskRegistry registers the actions run by the worker role: the built-in actions and the
+// onboarding demo. Register your own handlers here.
+func taskRegistry(cfg *config.Config) (worker.TaskRegistry, error) {
+	registry := workersdk.NewRegistry()
+	registry.Use(
+		workersdk.Logging(log.Default()),
+		workersdk.Metrics(metrics.WorkerHandlerOutcomesTotal),
+		workersdk.Recover(),
+	)
+
+	actions.RegisterHTTP(registry, actions.HTTPOptions{
+		DefaultTimeout:   cfg.HTTPAction.DefaultTimeout,
+		MaxTimeout:       cfg.HTTPAction.MaxTimeout,
+		MaxResponseBytes: cfg.HTTPAction.MaxResponseBytes,
+	})
+	if len(cfg.ShellAction.AllowedCommands) > 0 {
+		err := actions.RegisterShell(registry, actions.ShellOptions{
+			AllowedCommands: cfg.ShellAction.AllowedCommands,
+			WorkDir:         cfg.ShellAction.WorkDir,
+			DefaultTimeout:  cfg.ShellAction.DefaultTimeout,
+			MaxTimeout:      cfg.ShellAction.MaxTimeout,
+			MaxOutputBytes:  cfg.ShellAction.MaxOutputBytes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("shell_action: %w", err)
+		}
+	}
+	onboarding.Register(registry)
+
+	return worker.NewTaskRegistry(registry), nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"go-tempo/internal/api/middleware"
+	"go-tempo/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authMiddleware returns the authentication middleware of the /api/v1 group, or none when
+// neither API keys nor a JWKS are configured
+func authMiddleware(cfg *config.Config) ([]gin.HandlerFunc, error) {
+	authenticators, err := apiAuthenticators(cfg)
+	if err != nil || len(authenticators) == 0 {
+		return nil, err
+	}
+	return []gin.HandlerFunc{middleware.AuthMiddleware(authenticators...)}, nil
+}
+
+// apiAuthenticators returns the configured authenticators, shared by the REST and gRPC APIs
+func apiAuthenticators(cfg *config.Config) ([]middleware.Authenticator, error) {
+	var authenticators []middleware.Authenticator
+	if cfg.Auth.APIKeysFile != "" {
+		keys, err := middleware.NewAPIKeyAuthenticator(cfg.Auth.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, keys)
+	}
+	if cfg.Auth.JWKSFile != "" {
+		jwt, err := middleware.NewJWTAuthenticator(middleware.JWTOptions{
+			JWKSFile:    cfg.Auth.JWKSFile,
+			Issuer:      cfg.Auth.JWTIssuer,
+			Audience:    cfg.Auth.JWTAudience,
+			TenantClaim: cfg.Auth.TenantClaim,
+			RolesClaim:  cfg.Auth.RolesClaim,
+		})
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwt)
+	}
+	return authenticators, nil
+}
@@ -2,116 +2,250 @@ package main
 
 import (
 	"context"
-	"go-tempo/internal/api/handler"
-	"go-tempo/internal/api/middleware"
-	"go-tempo/internal/coordinator"
-	"go-tempo/internal/core/postgres/repository"
-	"go-tempo/internal/infrastructure/redis"
+	"database/sql"
+	"errors"
+	"go-tempo/internal/config"
+	"go-tempo/internal/core/postgres/migrate"
+	"go-tempo/internal/health"
 	"go-tempo/internal/metrics"
-	"go-tempo/internal/service"
 	"go-tempo/internal/worker"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"gorm.io/driver/postgres"
+	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
 func main() {
-    // 1. Set up database connection
-    dsn := "host=localhost user=postgres password=postgres dbname=workflow_db port=5432 sslmode=disable"
-    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+    // 0. Load configuration: defaults < YAML file < environment < flags
+    // "server migrate [flags] up|down|version" runs migrations instead of the server
+    args := os.Args[1:]
+    subcommand := ""
+    if len(args) > 0 && args[0] == "migrate" {
+        subcommand, args = args[0], args[1:]
+    }
+    cfg, positional, err := config.Load(args)
     if err != nil {
-        log.Fatal("Failed to connect to database:", err)
+        log.Fatal(err)
     }
+    log.Printf("Effective configuration:\n%s", cfg.Redacted())
+
+    // 1. Set up database connection (Postgres, or SQLite with --store=sqlite:path).
+    // --store=memory keeps all state in the process and has no database
+    var db *gorm.DB
+    var sqlDB *sql.DB
+    if cfg.MemoryStore() {
+        if subcommand == "migrate" {
+            log.Fatal("The memory store has no schema to migrate")
+        }
+    } else {
+        var migrator *migrate.Migrator
+        db, migrator, err = openDatabase(cfg)
+        if err != nil {
+            log.Fatal("Failed to connect to database:", err)
+        }
+        sqlDB, err = db.DB()
+        if err != nil {
+            log.Fatal("Failed to get database instance:", err)
+        }
 
-    // Configure database connection pool
-    sqlDB, err := db.DB()
-    if err != nil {
-        log.Fatal("Failed to get database instance:", err)
-    }
-    sqlDB.SetMaxOpenConns(50)
-    sqlDB.SetMaxIdleConns(10)
-    sqlDB.SetConnMaxLifetime(0) // 0 means connections are reused forever
-
-    // Start DB connection pool metrics collector
-    metrics.StartDBPoolCollector(sqlDB, 10*time.Second)
-
-    // 2. Initialize repository
-    taskRepo := repository.NewTaskRepository(db)
-    workflowRepo := repository.NewWorkflowRepository(db)
-
-    // 3. Init Redis Client
-    rdb := redis.NewRedisClient("localhost:6379")
-
-    // 4. Create the Queues and Bus
-    mainQueue := redis.NewRedisQueue(rdb, "workflow:queue:pending")
-    retryQueue := redis.NewRedisQueue(rdb, "workflow:queue:retry")
-    eventBus := redis.NewRedisEventBus(rdb)
-
-    // Start Redis queue depth metrics collectors for both queues
-    metrics.StartRedisQueueDepthCollector(rdb, "workflow:queue:pending", 10*time.Second)
-    metrics.StartRedisQueueDepthCollector(rdb, "workflow:queue:retry", 10*time.Second)
-
-    // 5. Initialize service with repository and main queue
-    workflowSvc := service.NewWorkflowService(taskRepo, mainQueue)
-
-    // 6. Initialize coordinator and start it
-    coord := coordinator.NewCoordinator(taskRepo, workflowRepo, mainQueue, eventBus)
-    go coord.Start(context.Background())
-
-    // 7. Init Registry and Workers
-    registry := worker.InitRegistry()
-    
-    // 8. Start worker pools with 9:1 ratio (9 main workers, 1 retry worker)
-    // Main queue workers - pull from mainQueue, push retries to retryQueue
-    mainWorker := worker.NewWorker(mainQueue, retryQueue, taskRepo, workflowRepo, eventBus, registry)
-    go mainWorker.StartPool(context.Background(), 9)
-    
-    // Retry queue workers - pull from retryQueue, push retries back to retryQueue
-    retryWorker := worker.NewWorker(retryQueue, retryQueue, taskRepo, workflowRepo, eventBus, registry)
-    go retryWorker.StartPool(context.Background(), 1)
-
-    // 9. Initialize handler with service
-    workflowHandler := handler.NewWorkflowHandler(workflowSvc)
-
-    // 10. Set up routes
-    router := gin.Default()
-    
-    // Add Prometheus middleware
-    router.Use(middleware.PrometheusMiddleware())
-
-    // Health and readiness endpoints
-    router.GET("/health", func(c *gin.Context) {
-        c.JSON(http.StatusOK, gin.H{"status": "healthy"})
-    })
-    router.GET("/readiness", func(c *gin.Context) {
-        // Check if dependencies are ready
-        if err := rdb.Ping(context.Background()).Err(); err != nil {
-            c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": "redis unavailable"})
-            return
+        // Apply the embedded schema migrations, or run the migrate subcommand and exit
+        if subcommand == "migrate" {
+            runMigrateCommand(migrator, positional)
+        }
+        if cfg.Database.MigrateOnStart {
+            applied, err := migrator.Up(context.Background())
+            if err != nil {
+                log.Fatal("Failed to apply migrations:", err)
+            }
+            log.Printf("Schema up to date (%d migration(s) applied)", applied)
+        }
+
+        // Start DB connection pool metrics collector
+        metrics.StartDBPoolCollector(sqlDB, cfg.Metrics.DBPoolInterval)
+    }
+
+    // 2. Create the Queues and Bus: Redis, or in-process for the embedded modes
+    newMessaging := newRedisMessaging
+    if cfg.Embedded() {
+        newMessaging = newMemoryMessaging
+    }
+    msg := newMessaging(cfg, db)
+
+    // 3. Initialize the repositories, task queues and event bus the roles share
+    var s *stores
+    if cfg.MemoryStore() {
+        s = newMemoryStores(cfg, msg)
+    } else if s, err = newStores(cfg, db, msg); err != nil {
+        log.Fatal("Failed to initialize stores:", err)
+    } else if cfg.Embedded() {
+        // The in-process queues lost their task IDs when the server last stopped
+        requeued, err := s.requeueWaitingTasks(context.Background())
+        if err != nil {
+            log.Fatal("Failed to requeue waiting tasks:", err)
+        }
+        log.Printf("Requeued %d waiting task(s)", requeued)
+    }
+    startQueueDepthCollectors(cfg, s.queues)
+
+    // Background loops stop in order on shutdown: workers first, then the coordinator and dispatcher
+    workerCtx, stopWorkers := context.WithCancel(context.Background())
+    defer stopWorkers()
+    backgroundCtx, stopBackground := context.WithCancel(context.Background())
+    defer stopBackground()
+    var workerWG, backgroundWG sync.WaitGroup
+    var workers []*worker.Worker
+
+    // Each role registers its own checks; /readiness aggregates the roles running here
+    healthRegistry := health.NewRegistry()
+    s.registerStoreChecks(healthRegistry, cfg.Server.Roles)
+
+    // 4. Coordinator role: resolves the DAG and delivers webhooks
+    if cfg.HasRole(config.RoleCoordinator) {
+        s.startCoordinator(backgroundCtx, healthRegistry, &backgroundWG)
+    }
+
+    // 5. Worker role: executes tasks from the main and retry queues of the task queues
+    // whose actions this process handles
+    if cfg.HasRole(config.RoleWorker) {
+        registry, err := taskRegistry(cfg)
+        if err != nil {
+            log.Fatal("Failed to register actions: ", err)
         }
-        if err := sqlDB.Ping(); err != nil {
-            c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": "database unavailable"})
-            return
+        registry, err = registry.Subset(cfg.Workers.Actions)
+        if err != nil {
+            log.Fatal("Invalid workers.actions: ", err)
         }
-        c.JSON(http.StatusOK, gin.H{"status": "ready"})
-    })
+        workers = s.startWorkers(workerCtx, backgroundCtx, registry, healthRegistry, &workerWG)
+    }
+
+    // 6. Set up routes. Health and metrics are served by every role
+    router := newRouter(healthRegistry)
 
     // Metrics endpoint
     router.GET("/metrics", gin.WrapH(promhttp.Handler()))
-    
-    api := router.Group("/api/v1")
-    {
-        api.POST("/workflows", workflowHandler.SubmitWorkflow)
+
+    var grpcServer *grpc.Server
+
+    // 7. API role: workflow submission, queries, webhook management and external workers
+    if cfg.HasRole(config.RoleAPI) {
+        grpcServer, err = s.mountAPI(backgroundCtx, router, &backgroundWG)
+        if err != nil {
+            log.Fatal("Failed to set up the API: ", err)
+        }
     }
 
-    // 11. Start server
-    log.Println("Server starting on :8080")
-    if err := router.Run(":8080"); err != nil {
+    // 8. Start server
+    server := &http.Server{
+        Addr:              cfg.HTTP.Addr,
+        Handler:           router,
+        ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+        ReadTimeout:       cfg.HTTP.ReadTimeout,
+        IdleTimeout:       cfg.HTTP.IdleTimeout,
+        // No WriteTimeout: workflow event streams stay open until the workflow finishes
+    }
+    serverErr := make(chan error, 1)
+    go func() {
+        log.Printf("Server starting on %s with roles %v", cfg.HTTP.Addr, cfg.Server.Roles)
+        if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            serverErr <- err
+        }
+    }()
+
+    if grpcServer != nil {
+        listener, err := net.Listen("tcp", cfg.GRPC.Addr)
+        if err != nil {
+            log.Fatal("Failed to listen for gRPC:", err)
+        }
+        go func() {
+            log.Printf("gRPC server starting on %s", cfg.GRPC.Addr)
+            if err := grpcServer.Serve(listener); err != nil {
+                serverErr <- err
+            }
+        }()
+    }
+
+    // 9. Wait for SIGINT/SIGTERM, then shut down gracefully
+    signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stopSignals()
+
+    select {
+    case err := <-serverErr:
         log.Fatal("Failed to start server:", err)
+    case <-signalCtx.Done():
+        log.Println("Shutdown signal received")
+    }
+    stopSignals() // A second signal kills the process immediately
+
+    // Stop accepting HTTP requests; open requests and event streams get http.shutdown_timeout
+    httpCtx, cancelHTTP := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+    if err := server.Shutdown(httpCtx); err != nil {
+        log.Printf("HTTP server did not shut down cleanly, closing open connections: %v", err)
+        server.Close()
+    }
+    cancelHTTP()
+
+    // gRPC calls and event streams get the same time
+    if grpcServer != nil {
+        stopped := make(chan struct{})
+        go func() {
+            grpcServer.GracefulStop()
+            close(stopped)
+        }()
+        select {
+        case <-stopped:
+        case <-time.After(cfg.HTTP.ShutdownTimeout):
+            log.Println("gRPC server did not shut down cleanly, closing open connections")
+            grpcServer.Stop()
+        }
+    }
+
+    // Stop popping tasks and let running handlers finish, then cancel and requeue the rest
+    stopWorkers()
+    if !waitTimeout(&workerWG, cfg.Server.DrainTimeout) {
+        log.Printf("Tasks still running after %s, canceling them", cfg.Server.DrainTimeout)
+        for _, w := range workers {
+            w.CancelInFlight()
+        }
+        workerWG.Wait()
+    }
+    log.Println("Workers drained")
+
+    // The coordinator and dispatcher go last so they handle the events of the drained tasks
+    stopBackground()
+    backgroundWG.Wait()
+
+    if err := msg.close(); err != nil {
+        log.Printf("Failed to close queue and event bus connections: %v", err)
+    }
+    if sqlDB != nil {
+        if err := sqlDB.Close(); err != nil {
+            log.Printf("Failed to close database: %v", err)
+        }
+    }
+    log.Println("Shutdown complete")
+}
+
+// waitTimeout waits for wg, reporting false if the timeout elapsed first
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+    done := make(chan struct{})
+    go func() {
+        wg.Wait()
+        close(done)
+    }()
+
+    select {
+    case <-done:
+        return true
+    case <-time.After(timeout):
+        return false
     }
 }
\ No newline at end of file
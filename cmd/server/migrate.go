@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"go-tempo/internal/core/postgres/migrate"
+	"log"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = "usage: server migrate [up | down [steps] | version]"
+
+// runMigrateCommand handles "server migrate ..." and exits the process
+func runMigrateCommand(migrator *migrate.Migrator, args []string) {
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatal("Migration failed:", err)
+		}
+		log.Printf("Applied %d migration(s)", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal(migrateUsage)
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatal("Rollback failed:", err)
+		}
+		log.Printf("Reverted %d migration(s)", reverted)
+
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			log.Fatal("Failed to read schema version:", err)
+		}
+		log.Printf("Schema version %d (latest embedded: %d)", version, migrator.Latest())
+
+	default:
+		log.Fatal(migrateUsage)
+	}
+
+	os.Exit(0)
+}
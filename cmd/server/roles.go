@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-tempo/internal/api/handler"
+	"go-tempo/internal/api/middleware"
+	"go-tempo/internal/api/rpc"
+	"go-tempo/internal/config"
+	"go-tempo/internal/coordinator"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/core/postgres/repository"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/health"
+	"go-tempo/internal/history"
+	"go-tempo/internal/infrastructure/memory"
+	"go-tempo/internal/routing"
+	"go-tempo/internal/service"
+	"go-tempo/internal/webhook"
+	"go-tempo/internal/worker"
+	"log"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+)
+
+// stores holds the repositories, queues and event bus every role of a process shares
+type stores struct {
+	cfg          *config.Config
+	sqlDB        *sql.DB // Nil for --store=memory
+	taskRepo     ports.TaskRepository
+	workflowRepo ports.WorkflowRepository
+	webhookRepo  ports.WebhookRepository
+	historyRepo  ports.WorkflowEventRepository
+	msg          messaging
+	queues       *routing.Router
+	eventBus     ports.EventBus
+}
+
+// newStores opens the repositories on the Postgres or SQLite database
+func newStores(cfg *config.Config, db *gorm.DB, msg messaging) (*stores, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	s := &stores{
+		cfg:          cfg,
+		sqlDB:        sqlDB,
+		taskRepo:     repository.NewTaskRepository(db),
+		workflowRepo: repository.NewWorkflowRepository(db),
+		webhookRepo:  repository.NewWebhookRepository(db),
+		historyRepo:  repository.NewWorkflowEventRepository(db),
+	}
+	s.connect(msg)
+	return s, nil
+}
+
+// newMemoryStores keeps every repository in process memory for --store=memory
+func newMemoryStores(cfg *config.Config, msg messaging) *stores {
+	store := memory.NewStore()
+	s := &stores{
+		cfg:          cfg,
+		taskRepo:     memory.NewTaskRepository(store),
+		workflowRepo: memory.NewWorkflowRepository(store),
+		webhookRepo:  memory.NewWebhookRepository(store),
+		historyRepo:  memory.NewWorkflowEventRepository(store),
+	}
+	s.connect(msg)
+	return s
+}
+
+// connect sets up the task queues and event bus on the messaging adapters
+func (s *stores) connect(msg messaging) {
+	s.msg = msg
+	// Tasks are pushed to the queues of their task queue, routed by action unless they name one
+	s.queues = routing.NewRouter(s.cfg.Queues.Pending, s.cfg.Queues.Retry, s.cfg.TaskQueueRoutes(), msg.openQueue)
+	// Every lifecycle event is appended to the workflow_events history before it is published
+	s.eventBus = history.NewRecordingEventBus(msg.eventBus, s.historyRepo)
+}
+
+// requeueWaitingTasks pushes every task waiting in the database back onto its queue. In-process
+// queues start empty, so without it the tasks queued when an embedded server stopped never run.
+// Retries go back to the retry queue; a delayed retry runs without waiting out its delay.
+func (s *stores) requeueWaitingTasks(ctx context.Context) (int, error) {
+	tasks, err := s.taskRepo.FindWaitingTasks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		q := s.queues.Main(task.TaskQueue)
+		if task.Status == domain.StatusPending && task.RetryCount > 0 {
+			q = s.queues.Retry(task.TaskQueue)
+		}
+		if err := q.Push(ctx, task); err != nil {
+			return i, err
+		}
+	}
+	return len(tasks), nil
+}
+
+// registerStoreChecks adds the Redis and database checks to every role in roles
+func (s *stores) registerStoreChecks(healthRegistry *health.Registry, roles []string) {
+	for _, role := range roles {
+		if s.msg.ping != nil {
+			healthRegistry.Register(role, "redis", s.msg.ping)
+		}
+		if s.sqlDB == nil {
+			// State in process memory is always reachable; the check keeps the role listed
+			healthRegistry.Register(role, "store", func(ctx context.Context) error { return nil })
+			continue
+		}
+		healthRegistry.Register(role, "database", s.sqlDB.PingContext)
+	}
+}
+
+// startCoordinator runs the coordinator role until ctx is canceled: the coordinator resolving
+// the DAG and the dispatcher delivering webhooks
+func (s *stores) startCoordinator(ctx context.Context, healthRegistry *health.Registry, wg *sync.WaitGroup) {
+	cfg := s.cfg
+	coord := coordinator.NewCoordinator(s.taskRepo, s.workflowRepo, s.queues, s.eventBus)
+	// Every coordinator receives every task event; only the leader handles them
+	coord.UseLeaderLock(s.msg.leaderLock, cfg.Coordinator.LeaderLockTTL)
+
+	// Webhooks are recorded on coordinator transitions and delivered in the background
+	dispatcher := webhook.NewDispatcher(s.webhookRepo, s.workflowRepo, webhook.Options{
+		Secret:         cfg.Webhooks.Secret,
+		MaxAttempts:    cfg.Webhooks.MaxAttempts,
+		BaseBackoff:    cfg.Webhooks.BaseBackoff,
+		MaxBackoff:     cfg.Webhooks.MaxBackoff,
+		PollInterval:   cfg.Webhooks.PollInterval,
+		RequestTimeout: cfg.Webhooks.RequestTimeout,
+	})
+	coord.UseNotifier(dispatcher)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		dispatcher.Start(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		coord.Start(ctx)
+	}()
+
+	healthRegistry.Register(config.RoleCoordinator, "event_loop", func(ctx context.Context) error {
+		if !coord.Running() {
+			return errors.New("coordinator is neither handling task events nor standing by")
+		}
+		return nil
+	})
+}
+
+// startWorkers runs the worker role until ctx is canceled: worker pools executing tasks from the
+// main and retry queues of the task queues whose actions the registry handles. Queue listeners
+// run until listenCtx is canceled.
+func (s *stores) startWorkers(ctx, listenCtx context.Context, registry worker.TaskRegistry, healthRegistry *health.Registry, wg *sync.WaitGroup) []*worker.Worker {
+	cfg := s.cfg
+
+	// Actions with external side effects reuse their cached output on redelivery
+	cachedActions := cfg.ResultCache.Actions
+	// Actions calling rate-limited systems share cluster-wide concurrency and rate limits
+	limited := len(cfg.ActionLimits()) > 0
+
+	var taskQueues []string
+	served := make(map[string]bool)
+	for _, action := range registry.Actions() {
+		if taskQueue := s.queues.Route(action); !served[taskQueue] {
+			served[taskQueue] = true
+			taskQueues = append(taskQueues, taskQueue)
+		}
+	}
+
+	// Start worker pools per task queue (default 9:1 ratio, 9 main workers, 1 retry worker)
+	type pool struct {
+		taskQueue               string
+		mainWorker, retryWorker *worker.Worker
+	}
+	var pools []pool
+	var workers []*worker.Worker
+	for _, taskQueue := range taskQueues {
+		mainQueue, retryQueue := s.queues.Main(taskQueue), s.queues.Retry(taskQueue)
+		for _, q := range []ports.TaskQueue{mainQueue, retryQueue} {
+			if l, ok := q.(listener); ok {
+				go l.Listen(listenCtx)
+			}
+		}
+
+		// Main queue workers - pull from mainQueue, push retries to retryQueue
+		mainWorker := worker.NewWorker(mainQueue, retryQueue, s.taskRepo, s.workflowRepo, s.eventBus, registry)
+		mainWorker.UseResultCache(s.msg.resultCache, cachedActions...)
+		mainWorker.ThrottleProgress(cfg.Workers.ProgressInterval)
+		if limited {
+			mainWorker.UseLimiter(s.msg.limiter, cfg.Limits.LeaseTTL, cfg.Limits.ThrottleDelay)
+		}
+		mainWorker.StartPool(ctx, cfg.TaskQueueConcurrency(taskQueue), wg)
+
+		// Retry queue workers - pull from retryQueue, push retries back to retryQueue
+		retryWorker := worker.NewWorker(retryQueue, retryQueue, s.taskRepo, s.workflowRepo, s.eventBus, registry)
+		retryWorker.UseResultCache(s.msg.resultCache, cachedActions...)
+		retryWorker.ThrottleProgress(cfg.Workers.ProgressInterval)
+		if limited {
+			retryWorker.UseLimiter(s.msg.limiter, cfg.Limits.LeaseTTL, cfg.Limits.ThrottleDelay)
+		}
+		retryWorker.StartPool(ctx, cfg.Workers.RetryConcurrency, wg)
+
+		workers = append(workers, mainWorker, retryWorker)
+		pools = append(pools, pool{taskQueue, mainWorker, retryWorker})
+		log.Printf("Serving task queue %s", taskQueue)
+	}
+
+	healthRegistry.Register(config.RoleWorker, "pool", func(ctx context.Context) error {
+		for _, p := range pools {
+			if p.mainWorker.RunningThreads() == 0 {
+				return fmt.Errorf("no main queue worker threads running for task queue %s", p.taskQueue)
+			}
+			if p.retryWorker.RunningThreads() == 0 {
+				return fmt.Errorf("no retry queue worker threads running for task queue %s", p.taskQueue)
+			}
+		}
+		return nil
+	})
+	return workers
+}
+
+// mountAPI adds the api role's routes to router: workflow submission, queries, webhook management
+// and external workers. It returns the gRPC server, nil if grpc.addr is empty. The reaper of
+// expired external worker leases runs until ctx is canceled.
+func (s *stores) mountAPI(ctx context.Context, router *gin.Engine, wg *sync.WaitGroup) (*grpc.Server, error) {
+	cfg := s.cfg
+	workflowSvc := service.NewWorkflowService(s.taskRepo, s.workflowRepo, s.queues, s.eventBus, s.historyRepo, cfg.TenantQuota(), s.msg.rateLimiter)
+	workflowHandler := handler.NewWorkflowHandler(workflowSvc)
+	webhookHandler := handler.NewWebhookHandler(service.NewWebhookService(s.webhookRepo))
+
+	// External workers poll tasks over HTTP; leases they stop renewing are retried
+	taskSvc := service.NewTaskService(s.taskRepo, s.workflowRepo, s.queues, s.eventBus, cfg.External.LeaseTTL)
+	taskHandler := handler.NewTaskHandler(taskSvc, cfg.External.MaxPollWait)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		taskSvc.Start(ctx, cfg.External.ReapInterval)
+	}()
+
+	// Every API request is authenticated (when configured)
+	authChain, err := authMiddleware(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("setting up API authentication: %w", err)
+	}
+	if !cfg.AuthEnabled() {
+		log.Println("API authentication is disabled; set auth.api_keys_file or auth.jwks_file to enable it")
+	}
+
+	submit := middleware.RequireRole(domain.RoleSubmit)
+	read := middleware.RequireRole(domain.RoleRead)
+	cancel := middleware.RequireRole(domain.RoleCancel)
+	admin := middleware.RequireRole(domain.RoleAdmin)
+
+	api := router.Group("/api/v1", authChain...)
+
+	// Workflow and webhook requests act for one tenant, honoring the tenant the credentials are bound to
+	tenantAPI := api.Group("", middleware.TenantMiddleware())
+	{
+		tenantAPI.POST("/workflows", submit, workflowHandler.SubmitWorkflow)
+		tenantAPI.GET("/workflows", read, workflowHandler.ListWorkflows)
+		tenantAPI.GET("/workflows/stats", read, workflowHandler.GetWorkflowStats)
+		tenantAPI.GET("/workflows/:id", read, workflowHandler.GetWorkflow)
+		tenantAPI.POST("/workflows/:id/cancel", cancel, workflowHandler.CancelWorkflow)
+		tenantAPI.GET("/workflows/:id/events", read, workflowHandler.StreamWorkflowEvents)
+		tenantAPI.GET("/workflows/:id/history", read, workflowHandler.GetWorkflowHistory)
+
+		tenantAPI.POST("/webhooks", admin, webhookHandler.CreateWebhook)
+		tenantAPI.GET("/webhooks", admin, webhookHandler.ListWebhooks)
+		tenantAPI.DELETE("/webhooks/:id", admin, webhookHandler.DeleteWebhook)
+		tenantAPI.GET("/webhooks/deliveries", admin, webhookHandler.ListDeliveries)
+	}
+
+	// External workers take tasks of every tenant
+	tasks := api.Group("/tasks", middleware.RequireRole(domain.RoleWorker), middleware.CrossTenantMiddleware())
+	{
+		tasks.POST("/poll", taskHandler.PollTask)
+		tasks.POST("/:id/heartbeat", taskHandler.Heartbeat)
+		tasks.POST("/:id/complete", taskHandler.CompleteTask)
+		tasks.POST("/:id/fail", taskHandler.FailTask)
+	}
+
+	// The gRPC API offers the workflow routes to internal services, on its own listener
+	if cfg.GRPC.Addr == "" {
+		return nil, nil
+	}
+	authenticators, err := apiAuthenticators(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("setting up gRPC authentication: %w", err)
+	}
+	return rpc.NewServer(workflowSvc, authenticators), nil
+}
+
+// newRouter returns the router with the health, readiness and metrics endpoints every role serves
+func newRouter(healthRegistry *health.Registry) *gin.Engine {
+	router := gin.Default()
+
+	// Add Prometheus middleware
+	router.Use(middleware.PrometheusMiddleware())
+
+	// Health and readiness endpoints
+	healthHandler := handler.NewHealthHandler(healthRegistry)
+	router.GET("/health", healthHandler.Liveness)
+	router.GET("/health/:role", healthHandler.RoleHealth)
+	router.GET("/readiness", healthHandler.Readiness)
+
+	return router
+}
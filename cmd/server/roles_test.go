@@ -0,0 +1,532 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go-tempo/internal/api/dto"
+	"go-tempo/internal/config"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/health"
+	"go-tempo/internal/metrics"
+	"go-tempo/internal/worker"
+	workersdk "go-tempo/pkg/worker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/gorm"
+)
+
+// roleGroup is one role started on its own, as it would run in a separate process
+type roleGroup struct {
+	name   string
+	role   string
+	stop   context.CancelFunc
+	wg     sync.WaitGroup
+	server *httptest.Server
+}
+
+// shutdown stops the group's loops and waits for them
+func (g *roleGroup) shutdown() {
+	g.stop()
+	g.wg.Wait()
+	g.server.Close()
+}
+
+// stepRun records when one task ran
+type stepRun struct {
+	start, end time.Time
+}
+
+// stepRecorder records every run of the "step" action per execution and ref_id
+type stepRecorder struct {
+	mu   sync.Mutex
+	runs map[uuid.UUID]map[string][]stepRun
+}
+
+// step sleeps briefly and succeeds, unless its input asks the first attempt to be retried
+// after a delay, {"retry_after": "500ms"}, or the task to fail with some output, {"fail": "boom"}
+func (r *stepRecorder) step(ctx context.Context, input []byte) ([]byte, error) {
+	info, _ := workersdk.ExecutionInfoFromContext(ctx)
+	var in struct {
+		RetryAfter string `json:"retry_after"`
+		Fail       string `json:"fail"`
+	}
+	json.Unmarshal(input, &in)
+
+	start := time.Now()
+	// c outlasts b, so d running as soon as b completes is caught
+	if info.RefID == "c" {
+		time.Sleep(150 * time.Millisecond)
+	} else {
+		time.Sleep(20 * time.Millisecond)
+	}
+	run := stepRun{start: start, end: time.Now()}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.runs[info.ExecutionID] == nil {
+		r.runs[info.ExecutionID] = make(map[string][]stepRun)
+	}
+	r.runs[info.ExecutionID][info.RefID] = append(r.runs[info.ExecutionID][info.RefID], run)
+
+	if delay, err := time.ParseDuration(in.RetryAfter); err == nil && info.Attempt == 1 {
+		return nil, workersdk.RetryAfter(errors.New("throttled"), delay)
+	}
+	if in.Fail != "" {
+		return []byte(`{"exit_code":3}`), workersdk.NonRetryable(errors.New(in.Fail))
+	}
+	return []byte(`{}`), nil
+}
+
+func (r *stepRecorder) runsOf(executionID uuid.UUID) map[string][]stepRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := make(map[string][]stepRun)
+	for refID, refRuns := range r.runs[executionID] {
+		runs[refID] = append([]stepRun(nil), refRuns...)
+	}
+	return runs
+}
+
+// rolesHarness starts roles as separate groups against one database and one set of
+// queues, event bus and leader lock, standing in for Postgres and Redis
+type rolesHarness struct {
+	t        *testing.T
+	cfg      *config.Config
+	db       *gorm.DB
+	msg      messaging
+	recorder *stepRecorder
+	groups   []*roleGroup
+}
+
+func newRolesHarness(t *testing.T) *rolesHarness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Default()
+	cfg.Server.Store = "sqlite:" + filepath.Join(t.TempDir(), "tempo.db")
+	cfg.Coordinator.LeaderLockTTL = time.Second
+	cfg.Workers.MainConcurrency = 4
+
+	db, migrator, err := openDatabase(cfg)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("applying migrations: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	h := &rolesHarness{
+		t:        t,
+		cfg:      cfg,
+		db:       db,
+		msg:      sharedMessaging(cfg, db),
+		recorder: &stepRecorder{runs: make(map[uuid.UUID]map[string][]stepRun)},
+	}
+	t.Cleanup(func() {
+		// Workers drain before the coordinators that handle their events
+		for i := len(h.groups) - 1; i >= 0; i-- {
+			h.groups[i].shutdown()
+		}
+	})
+	return h
+}
+
+// sharedMessaging creates in-process adapters whose queues, like Redis lists, are shared by
+// every group opening them by name
+func sharedMessaging(cfg *config.Config, db *gorm.DB) messaging {
+	msg := newMemoryMessaging(cfg, db)
+	openQueue := msg.openQueue
+	var mu sync.Mutex
+	opened := make(map[string]ports.TaskQueue)
+	msg.openQueue = func(name string) ports.TaskQueue {
+		mu.Lock()
+		defer mu.Unlock()
+		if opened[name] == nil {
+			opened[name] = openQueue(name)
+		}
+		return opened[name]
+	}
+	return msg
+}
+
+// restart stops every group and replaces the in-process adapters, losing the queued task IDs
+// like an embedded server that exits, then refills the queues from the database like main
+func (h *rolesHarness) restart() {
+	h.t.Helper()
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		h.groups[i].shutdown()
+	}
+	h.groups = nil
+
+	h.msg = sharedMessaging(h.cfg, h.db)
+	s, err := newStores(h.cfg, h.db, h.msg)
+	if err != nil {
+		h.t.Fatalf("restart: %v", err)
+	}
+	if _, err := s.requeueWaitingTasks(context.Background()); err != nil {
+		h.t.Fatalf("requeueing waiting tasks: %v", err)
+	}
+}
+
+// start runs one role in a new group with its own stores, health checks and router
+func (h *rolesHarness) start(name, role string) *roleGroup {
+	h.t.Helper()
+	s, err := newStores(h.cfg, h.db, h.msg)
+	if err != nil {
+		h.t.Fatalf("%s: %v", name, err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	g := &roleGroup{name: name, role: role, stop: stop}
+
+	healthRegistry := health.NewRegistry()
+	s.registerStoreChecks(healthRegistry, []string{role})
+
+	router := newRouter(healthRegistry)
+	switch role {
+	case config.RoleAPI:
+		if _, err := s.mountAPI(ctx, router, &g.wg); err != nil {
+			h.t.Fatalf("%s: %v", name, err)
+		}
+	case config.RoleCoordinator:
+		s.startCoordinator(ctx, healthRegistry, &g.wg)
+	case config.RoleWorker:
+		registry := workersdk.NewRegistry()
+		registry.Handle("step", h.recorder.step)
+		s.startWorkers(ctx, ctx, worker.NewTaskRegistry(registry), healthRegistry, &g.wg)
+	}
+	g.server = httptest.NewServer(router)
+
+	h.groups = append(h.groups, g)
+	return g
+}
+
+// submitDiamond submits the workflow a -> (b, c) -> d through the api group
+func submitDiamond(t *testing.T, api *roleGroup) uuid.UUID {
+	t.Helper()
+	task := func(refID string, deps ...string) dto.TaskDTO {
+		return dto.TaskDTO{RefID: refID, Action: "step", Dependencies: deps, Input: map[string]any{}}
+	}
+	body, _ := json.Marshal(dto.CreateWorkflowRequest{
+		Type:   "diamond",
+		UserID: uuid.New(),
+		Tasks:  []dto.TaskDTO{task("a"), task("b", "a"), task("c", "a"), task("d", "b", "c")},
+	})
+	resp, err := http.Post(api.server.URL+"/api/v1/workflows", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("submitting workflow: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("submitting workflow: status %d", resp.StatusCode)
+	}
+	var created dto.CreateWorkflowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decoding submit response: %v", err)
+	}
+	return created.ID
+}
+
+// getJSON decodes the response of a GET on the group's server into out and returns its status
+func getJSON(t *testing.T, g *roleGroup, path string, out any) int {
+	t.Helper()
+	resp, err := http.Get(g.server.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s on %s: %v", path, g.name, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode
+}
+
+// waitForStatus polls the api group until the workflow has a final status
+func waitForStatus(t *testing.T, api *roleGroup, executionID uuid.UUID) string {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		var workflow dto.WorkflowDetailResponse
+		getJSON(t, api, "/api/v1/workflows/"+executionID.String(), &workflow)
+		if execution := (domain.WorkflowExecution{Status: domain.WorkflowStatus(workflow.Status)}); execution.IsFinished() {
+			return workflow.Status
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("workflow %s did not finish", executionID)
+	return ""
+}
+
+// checkDiamond asserts every task ran once, d after both b and c, and the completion was announced once
+func (h *rolesHarness) checkDiamond(api *roleGroup, executionID uuid.UUID) {
+	t := h.t
+	t.Helper()
+	if status := waitForStatus(t, api, executionID); status != string(domain.WorkflowCompleted) {
+		t.Fatalf("workflow %s finished %s, want COMPLETED", executionID, status)
+	}
+
+	runs := h.recorder.runsOf(executionID)
+	for _, refID := range []string{"a", "b", "c", "d"} {
+		if len(runs[refID]) != 1 {
+			t.Errorf("workflow %s: task %s ran %d times, want 1", executionID, refID, len(runs[refID]))
+		}
+	}
+	if len(runs["a"]) == 1 && len(runs["b"]) == 1 && len(runs["c"]) == 1 && len(runs["d"]) == 1 {
+		a, b, c, d := runs["a"][0], runs["b"][0], runs["c"][0], runs["d"][0]
+		if b.start.Before(a.end) || c.start.Before(a.end) {
+			t.Errorf("workflow %s: b or c started before a ended", executionID)
+		}
+		if d.start.Before(b.end) || d.start.Before(c.end) {
+			t.Errorf("workflow %s: d started before b and c ended", executionID)
+		}
+	}
+
+	var workflowHistory dto.WorkflowHistoryResponse
+	getJSON(t, api, "/api/v1/workflows/"+executionID.String()+"/history", &workflowHistory)
+	completions := 0
+	for _, event := range workflowHistory.Events {
+		if event.Type == string(domain.EventWorkflowCompleted) {
+			completions++
+		}
+	}
+	if completions != 1 {
+		t.Errorf("workflow %s: history has %d %s events, want 1", executionID, completions, domain.EventWorkflowCompleted)
+	}
+}
+
+// waitForLeader waits until a coordinator holds the leader lock
+func waitForLeader(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(metrics.CoordinatorLeader) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("no coordinator acquired the leader lock")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRolesInSeparateGroups(t *testing.T) {
+	h := newRolesHarness(t)
+
+	api := h.start("api", config.RoleAPI)
+	first := h.start("coordinator-1", config.RoleCoordinator)
+	waitForLeader(t)
+	h.start("coordinator-2", config.RoleCoordinator)
+	h.start("worker-1", config.RoleWorker)
+	h.start("worker-2", config.RoleWorker)
+
+	for _, g := range h.groups {
+		if status := getJSON(t, g, "/health/"+g.role, nil); status != http.StatusOK {
+			t.Errorf("%s: /health/%s = %d, want 200", g.name, g.role, status)
+		}
+		if status := getJSON(t, g, "/health/api", nil); g.role != config.RoleAPI && status != http.StatusNotFound {
+			t.Errorf("%s: /health/api = %d, want 404", g.name, status)
+		}
+	}
+
+	// Both coordinators receive every task event; only the leader may resolve the DAG
+	var executions []uuid.UUID
+	for i := 0; i < 5; i++ {
+		executions = append(executions, submitDiamond(t, api))
+	}
+	for _, executionID := range executions {
+		h.checkDiamond(api, executionID)
+	}
+
+	// The standby takes over once the leader stops
+	first.shutdown()
+	h.groups = h.groups[:1+copy(h.groups[1:], h.groups[2:])]
+	waitForLeader(t)
+
+	executions = executions[:0]
+	for i := 0; i < 3; i++ {
+		executions = append(executions, submitDiamond(t, api))
+	}
+	for _, executionID := range executions {
+		h.checkDiamond(api, executionID)
+	}
+
+	if t.Failed() {
+		for _, g := range h.groups {
+			var body map[string]any
+			getJSON(t, g, "/readiness", &body)
+			t.Log(g.name, fmt.Sprint(body))
+		}
+	}
+}
+
+func TestRestartRequeuesWaitingTasks(t *testing.T) {
+	h := newRolesHarness(t)
+
+	// Without workers the root tasks stay in the queues the restart loses
+	api := h.start("api", config.RoleAPI)
+	var executions []uuid.UUID
+	for i := 0; i < 3; i++ {
+		executions = append(executions, submitDiamond(t, api))
+	}
+
+	h.restart()
+	api = h.start("api", config.RoleAPI)
+	h.start("coordinator", config.RoleCoordinator)
+	waitForLeader(t)
+	h.start("worker", config.RoleWorker)
+	for _, executionID := range executions {
+		h.checkDiamond(api, executionID)
+	}
+}
+
+func TestCancelledWorkflowSkipsTasks(t *testing.T) {
+	h := newRolesHarness(t)
+
+	// Cancelled before any worker runs, so no task starts
+	api := h.start("api", config.RoleAPI)
+	executionID := submitDiamond(t, api)
+	resp, err := http.Post(api.server.URL+"/api/v1/workflows/"+executionID.String()+"/cancel", "application/json", nil)
+	if err != nil {
+		t.Fatalf("cancelling workflow: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("cancelling workflow: status %d", resp.StatusCode)
+	}
+
+	h.start("coordinator", config.RoleCoordinator)
+	waitForLeader(t)
+	h.start("worker", config.RoleWorker)
+	if status := waitForStatus(t, api, executionID); status != string(domain.WorkflowCancelled) {
+		t.Fatalf("workflow finished %s, want CANCELLED", status)
+	}
+
+	// The status is final at once; workflow.cancelled follows once the last task is skipped
+	deadline := time.Now().Add(10 * time.Second)
+	for cancelled := false; !cancelled; {
+		if time.Now().After(deadline) {
+			t.Fatalf("workflow %s: %s was not announced", executionID, domain.EventWorkflowCancelled)
+		}
+		time.Sleep(20 * time.Millisecond)
+		var workflowHistory dto.WorkflowHistoryResponse
+		getJSON(t, api, "/api/v1/workflows/"+executionID.String()+"/history", &workflowHistory)
+		for _, event := range workflowHistory.Events {
+			cancelled = cancelled || event.Type == string(domain.EventWorkflowCancelled)
+		}
+	}
+
+	var workflow dto.WorkflowDetailResponse
+	getJSON(t, api, "/api/v1/workflows/"+executionID.String(), &workflow)
+	for _, task := range workflow.Tasks {
+		var output struct{ Reason string }
+		json.Unmarshal(task.Output, &output)
+		if task.Status != string(domain.StatusSkipped) || output.Reason != domain.SkipReasonCancelled {
+			t.Errorf("task %s = %s (%s), want SKIPPED (%s)", task.RefID, task.Status, task.Output, domain.SkipReasonCancelled)
+		}
+	}
+	if runs := h.recorder.runsOf(executionID); len(runs) != 0 {
+		t.Errorf("tasks of the cancelled workflow ran: %v", runs)
+	}
+}
+
+func TestRestartKeepsRetryDelay(t *testing.T) {
+	h := newRolesHarness(t)
+	const delay = 500 * time.Millisecond
+
+	api := h.start("api", config.RoleAPI)
+	h.start("coordinator", config.RoleCoordinator)
+	waitForLeader(t)
+	h.start("worker", config.RoleWorker)
+
+	body, _ := json.Marshal(dto.CreateWorkflowRequest{
+		Type:   "throttled",
+		UserID: uuid.New(),
+		Tasks:  []dto.TaskDTO{{RefID: "a", Action: "step", Input: map[string]any{"retry_after": delay.String()}}},
+	})
+	resp, err := http.Post(api.server.URL+"/api/v1/workflows", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("submitting workflow: %v", err)
+	}
+	var created dto.CreateWorkflowResponse
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+
+	// Restart while the retry waits out its delay
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var workflow dto.WorkflowDetailResponse
+		getJSON(t, api, "/api/v1/workflows/"+created.ID.String(), &workflow)
+		if len(workflow.Tasks) == 1 && workflow.Tasks[0].Attempts == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the first attempt was not retried")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.restart()
+	api = h.start("api", config.RoleAPI)
+	h.start("coordinator", config.RoleCoordinator)
+	waitForLeader(t)
+	h.start("worker", config.RoleWorker)
+
+	if status := waitForStatus(t, api, created.ID); status != string(domain.WorkflowCompleted) {
+		t.Fatalf("workflow finished %s, want COMPLETED", status)
+	}
+	runs := h.recorder.runsOf(created.ID)["a"]
+	if len(runs) != 2 {
+		t.Fatalf("task ran %d times, want 2", len(runs))
+	}
+	if gap := runs[1].start.Sub(runs[0].end); gap < delay {
+		t.Errorf("retry ran %s after the first attempt, want at least %s", gap, delay)
+	}
+}
+
+func TestFailedTaskKeepsOutput(t *testing.T) {
+	h := newRolesHarness(t)
+
+	api := h.start("api", config.RoleAPI)
+	h.start("coordinator", config.RoleCoordinator)
+	waitForLeader(t)
+	h.start("worker", config.RoleWorker)
+
+	body, _ := json.Marshal(dto.CreateWorkflowRequest{
+		Type:   "failing",
+		UserID: uuid.New(),
+		Tasks:  []dto.TaskDTO{{RefID: "a", Action: "step", Input: map[string]any{"fail": "boom"}}},
+	})
+	resp, err := http.Post(api.server.URL+"/api/v1/workflows", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("submitting workflow: %v", err)
+	}
+	var created dto.CreateWorkflowResponse
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+
+	if status := waitForStatus(t, api, created.ID); status != string(domain.WorkflowFailed) {
+		t.Fatalf("workflow finished %s, want FAILED", status)
+	}
+	var workflow dto.WorkflowDetailResponse
+	getJSON(t, api, "/api/v1/workflows/"+created.ID.String(), &workflow)
+	var output struct {
+		Error  string
+		Output struct {
+			ExitCode int `json:"exit_code"`
+		}
+	}
+	if len(workflow.Tasks) != 1 || json.Unmarshal(workflow.Tasks[0].Output, &output) != nil ||
+		output.Error != "boom" || output.Output.ExitCode != 3 {
+		t.Errorf("tasks = %+v, want a to fail with boom and the handler's output", workflow.Tasks)
+	}
+}
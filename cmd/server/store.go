@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"go-tempo/internal/config"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/core/postgres/migrate"
+	"go-tempo/internal/core/postgres/queue"
+	"go-tempo/internal/infrastructure/memory"
+	"go-tempo/internal/infrastructure/redis"
+	"go-tempo/internal/metrics"
+	"go-tempo/internal/routing"
+	"go-tempo/migrations"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openDatabase connects to Postgres, or to the SQLite file of --store=sqlite:path,
+// and returns the migrator for its schema
+func openDatabase(cfg *config.Config) (*gorm.DB, *migrate.Migrator, error) {
+	path, embedded := cfg.SQLitePath()
+	if !embedded {
+		db, err := gorm.Open(postgres.Open(cfg.Database.URL), &gorm.Config{})
+		if err != nil {
+			return nil, nil, err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, nil, err
+		}
+		sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime) // 0 means connections are reused forever
+		sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+		migrator, err := migrate.NewMigrator(sqlDB, migrations.FS)
+		return db, migrator, err
+	}
+
+	// Timestamps are stored as text, so keep them in UTC for range filters and ordering
+	db, err := gorm.Open(sqlite.Open(path+"?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on"), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	// SQLite has a single writer; one connection serialises statements instead of failing with SQLITE_BUSY
+	sqlDB.SetMaxOpenConns(1)
+
+	migrator, err := migrate.NewSQLiteMigrator(sqlDB, migrations.SQLiteFS)
+	return db, migrator, err
+}
+
+// messaging holds the queue, event bus and result cache adapters of the selected store
+type messaging struct {
+	openQueue   func(name string) ports.TaskQueue
+	eventBus    ports.EventBus
+	resultCache ports.ResultCache
+	limiter     ports.ActionLimiter
+	rateLimiter ports.RateLimiter               // Tenant submission rates
+	leaderLock  ports.LeaderLock                // Elects the coordinator handling task events
+	ping        func(ctx context.Context) error // Nil for the in-process adapters
+	close       func() error
+}
+
+// listener is implemented by queues that need a background loop to wake blocked poppers
+type listener interface {
+	Listen(ctx context.Context)
+}
+
+// startQueueDepthCollectors reports the per-priority depth of the main and retry queues
+// of the default and every routed task queue
+func startQueueDepthCollectors(cfg *config.Config, router *routing.Router) {
+	for _, taskQueue := range router.TaskQueues() {
+		mainName, retryName := router.QueueNames(taskQueue)
+		metrics.StartQueueDepthCollector(mainName, router.Main(taskQueue).DepthByPriority, cfg.Metrics.QueueDepthInterval)
+		metrics.StartQueueDepthCollector(retryName, router.Retry(taskQueue).DepthByPriority, cfg.Metrics.QueueDepthInterval)
+	}
+}
+
+// newRedisMessaging connects the Redis event bus and result cache, and the task queues
+// on Redis or, with queues.backend=postgres, on the tasks table
+func newRedisMessaging(cfg *config.Config, db *gorm.DB) messaging {
+	rdb := redis.NewRedisClient(redis.ClientOptions{
+		Addr:         cfg.Redis.Addr,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		PoolSize:     cfg.Redis.PoolSize,
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.ReadTimeout,
+		WriteTimeout: cfg.Redis.WriteTimeout,
+	})
+
+	msg := messaging{
+		eventBus:    redis.NewRedisEventBus(rdb, cfg.Events.HistoryTTL, cfg.Events.HistoryMaxLen),
+		resultCache: redis.NewRedisResultCache(rdb, cfg.ResultCache.TTL),
+		limiter:     redis.NewRedisActionLimiter(rdb, cfg.ActionLimits(), cfg.Limits.LeaseTTL),
+		rateLimiter: redis.NewRedisRateLimiter(rdb),
+		leaderLock:  redis.NewRedisLeaderLock(rdb),
+		ping: func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		},
+		close: rdb.Close,
+	}
+
+	if cfg.Queues.Backend == config.QueueBackendPostgres {
+		msg.openQueue = func(name string) ports.TaskQueue {
+			return queue.NewPostgresQueue(db, cfg.Database.URL, name, cfg.Queues.PollInterval, cfg.Queues.AgingInterval, cfg.TenantWeights())
+		}
+		return msg
+	}
+
+	msg.openQueue = func(name string) ports.TaskQueue {
+		return redis.NewRedisQueue(rdb, name, cfg.Queues.AgingInterval, cfg.TenantWeights())
+	}
+	return msg
+}
+
+// newMemoryMessaging creates in-process adapters for the embedded single-process mode.
+// Queued task IDs are lost on restart; main refills the queues from the database on startup.
+func newMemoryMessaging(cfg *config.Config, _ *gorm.DB) messaging {
+	return messaging{
+		openQueue: func(name string) ports.TaskQueue {
+			return memory.NewQueue(cfg.Queues.AgingInterval, cfg.TenantWeights())
+		},
+		eventBus:    memory.NewEventBus(int(cfg.Events.HistoryMaxLen)),
+		resultCache: memory.NewResultCache(cfg.ResultCache.TTL),
+		limiter:     memory.NewActionLimiter(cfg.ActionLimits(), cfg.Limits.LeaseTTL),
+		rateLimiter: memory.NewRateLimiter(),
+		leaderLock:  memory.NewLeaderLock(),
+		close:       func() error { return nil },
+	}
+}
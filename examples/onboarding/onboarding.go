@@ -0,0 +1,112 @@
+// Package onboarding holds the demo handlers of the employee onboarding workflow, plus actions
+// that fail and run long for testing retries and timeouts.
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go-tempo/pkg/worker"
+)
+
+// Employee is the input of the onboarding actions
+type Employee struct {
+	Name       string `json:"name"`
+	Email      string `json:"email" validate:"omitempty,email"`
+	Department string `json:"department"`
+}
+
+type Profile struct {
+	Status         string `json:"status"`
+	EmployeeID     string `json:"employee_id"`
+	ProfileCreated bool   `json:"profile_created"`
+}
+
+type EmailAccount struct {
+	Status         string `json:"status"`
+	Email          string `json:"email"`
+	MailboxCreated bool   `json:"mailbox_created"`
+}
+
+type Equipment struct {
+	Status    string `json:"status"`
+	LaptopID  string `json:"laptop_id"`
+	MonitorID string `json:"monitor_id"`
+	Assigned  bool   `json:"assigned"`
+}
+
+type Benefits struct {
+	Status       string `json:"status"`
+	HealthPlan   string `json:"health_plan"`
+	Enrolled401k bool   `json:"401k_enrolled"`
+}
+
+type Orientation struct {
+	Status             string `json:"status"`
+	OrientationDate    string `json:"orientation_date"`
+	CalendarInviteSent bool   `json:"calendar_invite_sent"`
+}
+
+type Completion struct {
+	Status    string `json:"status"`
+	Completed bool   `json:"completed"`
+}
+
+// Register registers the onboarding actions
+func Register(r *worker.Registry) {
+	worker.Register(r, "create_employee_profile", func(ctx context.Context, in Employee) (Profile, error) {
+		log.Printf("Creating employee profile for %q", in.Name)
+		time.Sleep(10 * time.Second)
+		return Profile{Status: "success", EmployeeID: "EMP-12345", ProfileCreated: true}, nil
+	})
+
+	worker.Register(r, "setup_email_account", func(ctx context.Context, in Employee) (EmailAccount, error) {
+		log.Printf("Setting up email account for %q", in.Name)
+		time.Sleep(10 * time.Second)
+		return EmailAccount{Status: "success", Email: "john.doe@company.com", MailboxCreated: true}, nil
+	})
+
+	worker.Register(r, "assign_equipment", func(ctx context.Context, in Employee) (Equipment, error) {
+		log.Printf("Assigning equipment to %q", in.Name)
+		time.Sleep(10 * time.Second)
+		return Equipment{Status: "success", LaptopID: "LT-789", MonitorID: "MN-456", Assigned: true}, nil
+	})
+
+	worker.Register(r, "enroll_benefits", func(ctx context.Context, in Employee) (Benefits, error) {
+		log.Printf("Enrolling %q in benefits", in.Name)
+		time.Sleep(10 * time.Second)
+		return Benefits{Status: "success", HealthPlan: "Premium PPO", Enrolled401k: true}, nil
+	})
+
+	worker.Register(r, "schedule_orientation", func(ctx context.Context, in Employee) (Orientation, error) {
+		log.Printf("Scheduling orientation for %q", in.Name)
+		time.Sleep(10 * time.Second)
+		return Orientation{Status: "success", OrientationDate: "2026-03-01", CalendarInviteSent: true}, nil
+	})
+
+	// Test action: always fails (retryably) to simulate task errors
+	worker.Register(r, "failing_task", func(ctx context.Context, in map[string]any) (Completion, error) {
+		return Completion{}, errors.New("simulated task failure")
+	})
+
+	// Test action: panics, which fails the task without retries
+	worker.Register(r, "panicking_task", func(ctx context.Context, in map[string]any) (Completion, error) {
+		panic("simulated handler panic")
+	})
+
+	// Test action: runs for 10 seconds reporting progress, but respects context cancellation
+	// (for timeout testing)
+	worker.Register(r, "timeout_task", func(ctx context.Context, in map[string]any) (Completion, error) {
+		for elapsed := 0; elapsed < 10; elapsed++ {
+			worker.ReportProgress(ctx, elapsed*10, "waiting", map[string]int{"elapsed_seconds": elapsed})
+			select {
+			case <-ctx.Done():
+				return Completion{}, ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+		return Completion{Status: "success", Completed: true}, nil
+	})
+}
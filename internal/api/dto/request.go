@@ -1,16 +1,70 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type TaskDTO struct {
 	RefID string `json:"ref_id" binding:"required"`
 	Action string `json:"action" binding:"required"`
 	Dependencies []string `json:"dependencies"`
 	Input map[string]any `json:"input" binding:"required"`
+	Priority *int `json:"priority" binding:"omitempty,min=0,max=9"` // Defaults to the workflow priority
+	TaskQueue string `json:"task_queue" binding:"omitempty,max=100"` // Defaults to the route of the action
 }
 
 type CreateWorkflowRequest struct {
 	Type string `json:"type" binding:"required"` 
 	UserID uuid.UUID `json:"user_id" binding:"required"`
 	Tasks []TaskDTO `json:"tasks" binding:"required,min=1"`
+	Priority *int `json:"priority" binding:"omitempty,min=0,max=9"` // 0 (lowest) to 9 (highest), default 5
+	CallbackURL string `json:"callback_url" binding:"omitempty,url"`
+	CallbackEvents []string `json:"callback_events"`
+	IdempotencyKey string `json:"-" binding:"omitempty,max=255"` // From the Idempotency-Key header
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required,url"`
+	Events []string `json:"events"` // Empty subscribes to all events
+	Secret string `json:"secret"` // Generated when empty
+}
+
+// PollTaskRequest asks for a task of one of the actions, waiting up to wait_seconds for one
+type PollTaskRequest struct {
+	WorkerID string `json:"worker_id" binding:"required,max=100"`
+	Actions []string `json:"actions" binding:"required,min=1,dive,required"`
+	TaskQueues []string `json:"task_queues" binding:"omitempty,dive,required,max=100"` // Defaults to the routes of the actions
+	WaitSeconds int `json:"wait_seconds" binding:"omitempty,min=0"` // Capped by external_workers.max_poll_wait, which is also the default
+}
+
+type CompleteTaskRequest struct {
+	LeaseToken string `json:"lease_token" binding:"required"`
+	Output json.RawMessage `json:"output"` // Any JSON value, {} when omitted
+}
+
+type FailTaskRequest struct {
+	LeaseToken string `json:"lease_token" binding:"required"`
+	Error string `json:"error" binding:"required"`
+	NonRetryable bool `json:"non_retryable"` // Fail the task without using its remaining retries
+}
+
+type HeartbeatRequest struct {
+	LeaseToken string `json:"lease_token" binding:"required"`
+}
+
+// ListWorkflowsQuery holds the query parameters of GET /workflows and GET /workflows/stats
+type ListWorkflowsQuery struct {
+	UserID string `form:"user_id" binding:"omitempty,uuid"`
+	Type string `form:"type"`
+	Status string `form:"status" binding:"omitempty,oneof=RUNNING COMPLETED FAILED PAUSED CANCELLED"`
+	CreatedAfter time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedAfter time.Time `form:"updated_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedBefore time.Time `form:"updated_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	FailingAction string `form:"failing_action"`
+	Cursor string `form:"cursor"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=200"`
 }
\ No newline at end of file
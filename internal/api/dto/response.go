@@ -1,7 +1,123 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type CreateWorkflowResponse struct {
 	ID uuid.UUID `json:"execution_id"`
-}
\ No newline at end of file
+}
+
+type WebhookResponse struct {
+	ID uuid.UUID `json:"id"`
+	URL string `json:"url"`
+	Events []string `json:"events"`
+	Secret string `json:"secret,omitempty"` // Only returned on creation
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID uuid.UUID `json:"id"`
+	ExecutionID uuid.UUID `json:"execution_id"`
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"`
+	URL string `json:"url"`
+	EventType string `json:"event_type"`
+	Status string `json:"status"`
+	Attempts int `json:"attempts"`
+	ResponseCode int `json:"response_code,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkflowHistoryEntryResponse struct {
+	Type string `json:"type"`
+	TaskID *uuid.UUID `json:"task_id,omitempty"`
+	RefID string `json:"ref_id,omitempty"`
+	Action string `json:"action,omitempty"`
+	Attempt int `json:"attempt,omitempty"`
+	WorkerID string `json:"worker_id,omitempty"`
+	Error string `json:"error,omitempty"`
+	Status string `json:"status,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type WorkflowHistoryResponse struct {
+	ExecutionID uuid.UUID `json:"execution_id"`
+	Events []WorkflowHistoryEntryResponse `json:"events"`
+}
+
+type WorkflowSummaryResponse struct {
+	ID uuid.UUID `json:"execution_id"`
+	UserID uuid.UUID `json:"user_id"`
+	Tenant string `json:"tenant"`
+	Type string `json:"type"`
+	Status string `json:"status"`
+	Priority int `json:"priority"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WorkflowDetailResponse is an execution with the state of each of its tasks
+type WorkflowDetailResponse struct {
+	WorkflowSummaryResponse
+	Tasks []TaskResponse `json:"tasks"`
+}
+
+type TaskResponse struct {
+	ID uuid.UUID `json:"task_id"`
+	RefID string `json:"ref_id"`
+	Action string `json:"action"`
+	Dependencies []string `json:"dependencies"`
+	TaskQueue string `json:"task_queue"`
+	Priority int `json:"priority"`
+	Status string `json:"status"`
+	Attempts int `json:"attempts"` // Failed attempts so far
+	MaxRetries int `json:"max_retries"`
+	WorkerID string `json:"worker_id,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"` // When the running or last attempt was claimed
+	LastError string `json:"last_error,omitempty"`
+	Output json.RawMessage `json:"output,omitempty"`
+	Progress *TaskProgressResponse `json:"progress,omitempty"` // Latest progress reported by the running attempt
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TaskProgressResponse struct {
+	Percent int `json:"percent"`
+	Message string `json:"message,omitempty"`
+	Details json.RawMessage `json:"details,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ListWorkflowsResponse struct {
+	Workflows []WorkflowSummaryResponse `json:"workflows"`
+	NextCursor string `json:"next_cursor,omitempty"` // Pass as ?cursor= to fetch the next page
+}
+
+type WorkflowStatsResponse struct {
+	Counts map[string]int64 `json:"counts"`
+	Total int64 `json:"total"`
+}
+
+// LeasedTaskResponse is a task handed to an external worker. Report it with the lease token
+// before lease_expires_at, or send heartbeats to extend the lease.
+type LeasedTaskResponse struct {
+	TaskID uuid.UUID `json:"task_id"`
+	ExecutionID uuid.UUID `json:"execution_id"`
+	Tenant string `json:"tenant"`
+	RefID string `json:"ref_id"`
+	Action string `json:"action"`
+	Input json.RawMessage `json:"input"`
+	Attempt int `json:"attempt"`
+	MaxRetries int `json:"max_retries"`
+	LeaseToken string `json:"lease_token"`
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+}
+
+type HeartbeatResponse struct {
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+}
@@ -1,13 +1,22 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"go-tempo/internal/api/dto"
+	"go-tempo/internal/domain"
 	"go-tempo/internal/mapper"
 	"go-tempo/internal/metrics"
 	"go-tempo/internal/service"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type WorkflowHandler struct {
@@ -18,11 +27,27 @@ func NewWorkflowHandler(svc service.WorkflowService) *WorkflowHandler {
     return &WorkflowHandler{service: svc}
 }
 
+// IdempotencyKeyHeader makes a submission safe to retry: resubmitting with the same key
+// returns the workflow created by the first submission
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 func (h *WorkflowHandler) SubmitWorkflow(c *gin.Context) {
     var req dto.CreateWorkflowRequest
+    req.IdempotencyKey = c.GetHeader(IdempotencyKeyHeader)
 
     if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error" : err.Error()})
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := service.ValidateEventTypes(req.CallbackEvents); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // The body's user_id must be the caller's own unless the caller is an admin
+    if identity, ok := domain.IdentityFromContext(c.Request.Context()); ok && !identity.CanActFor(req.UserID) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "user_id does not match the authenticated caller"})
         return
     }
 
@@ -30,6 +55,14 @@ func (h *WorkflowHandler) SubmitWorkflow(c *gin.Context) {
     execution, tasks := mapper.ToWorkflowExecution(req)
 
     executionID, err := h.service.SubmitWorkflow(c.Request.Context(), execution, tasks)
+    var quotaErr *service.QuotaExceededError
+    if errors.As(err, &quotaErr) {
+        if quotaErr.RetryAfter > 0 {
+            c.Header("Retry-After", strconv.Itoa(int(math.Ceil(quotaErr.RetryAfter.Seconds()))))
+        }
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "quota": quotaErr.Quota})
+        return
+    }
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -39,4 +72,186 @@ func (h *WorkflowHandler) SubmitWorkflow(c *gin.Context) {
     metrics.WorkflowsSubmittedTotal.WithLabelValues("default").Inc()
 
     c.JSON(http.StatusCreated, dto.CreateWorkflowResponse{ID: executionID})
-}
\ No newline at end of file
+}
+
+// ListWorkflows searches workflows with filters and cursor pagination
+func (h *WorkflowHandler) ListWorkflows(c *gin.Context) {
+    filter, ok := bindWorkflowFilter(c)
+    if !ok {
+        return
+    }
+
+    page, err := h.service.ListWorkflows(c.Request.Context(), filter)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, mapper.ToListWorkflowsResponse(page))
+}
+
+// GetWorkflowStats returns the number of workflows per status matching the filters
+func (h *WorkflowHandler) GetWorkflowStats(c *gin.Context) {
+    filter, ok := bindWorkflowFilter(c)
+    if !ok {
+        return
+    }
+
+    counts, err := h.service.CountWorkflowsByStatus(c.Request.Context(), filter)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, mapper.ToWorkflowStatsResponse(counts))
+}
+
+// bindWorkflowFilter parses list query parameters, writing a 400 response on failure
+func bindWorkflowFilter(c *gin.Context) (domain.WorkflowFilter, bool) {
+    var query dto.ListWorkflowsQuery
+    if err := c.ShouldBindQuery(&query); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return domain.WorkflowFilter{}, false
+    }
+
+    filter, err := mapper.ToWorkflowFilter(query)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return domain.WorkflowFilter{}, false
+    }
+    return filter, true
+}
+
+// GetWorkflow returns a workflow execution with the state of each of its tasks
+func (h *WorkflowHandler) GetWorkflow(c *gin.Context) {
+    executionID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workflow id"})
+        return
+    }
+
+    execution, err := h.service.GetWorkflow(c.Request.Context(), executionID)
+    if errors.Is(err, service.ErrWorkflowNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, mapper.ToWorkflowDetailResponse(*execution))
+}
+
+// CancelWorkflow stops a running workflow. It is accepted at once; the workflow becomes
+// CANCELLED immediately and emits workflow.cancelled once its running tasks finish.
+func (h *WorkflowHandler) CancelWorkflow(c *gin.Context) {
+    executionID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workflow id"})
+        return
+    }
+
+    err = h.service.CancelWorkflow(c.Request.Context(), executionID)
+    if errors.Is(err, service.ErrWorkflowNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if errors.Is(err, service.ErrWorkflowNotRunning) {
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.Status(http.StatusAccepted)
+}
+
+// GetWorkflowHistory returns the audit trail of every transition in a workflow execution
+func (h *WorkflowHandler) GetWorkflowHistory(c *gin.Context) {
+    executionID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workflow id"})
+        return
+    }
+
+    records, err := h.service.GetWorkflowHistory(c.Request.Context(), executionID)
+    if errors.Is(err, service.ErrWorkflowNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, mapper.ToWorkflowHistoryResponse(executionID, records))
+}
+
+// sseKeepAliveInterval is how often a comment line is sent on an idle event stream
+const sseKeepAliveInterval = 15 * time.Second
+
+// StreamWorkflowEvents streams lifecycle events of a workflow as Server-Sent Events.
+// Clients reconnecting with Last-Event-ID resume after the last event they received.
+func (h *WorkflowHandler) StreamWorkflowEvents(c *gin.Context) {
+    executionID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workflow id"})
+        return
+    }
+
+    lastEventID := c.GetHeader("Last-Event-ID")
+
+    events, err := h.service.WatchWorkflow(c.Request.Context(), executionID, lastEventID)
+    if errors.Is(err, service.ErrWorkflowNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.Header("Content-Type", "text/event-stream")
+    c.Header("Cache-Control", "no-cache")
+    c.Header("Connection", "keep-alive")
+    c.Header("X-Accel-Buffering", "no")
+    c.Status(http.StatusOK)
+    c.Writer.Flush()
+
+    keepAlive := time.NewTicker(sseKeepAliveInterval)
+    defer keepAlive.Stop()
+
+    for {
+        select {
+        case <-c.Request.Context().Done():
+            return
+
+        case <-keepAlive.C:
+            // Comment lines keep proxies from closing an idle stream
+            fmt.Fprint(c.Writer, ": keep-alive\n\n")
+            c.Writer.Flush()
+
+        case event, ok := <-events:
+            if !ok {
+                return
+            }
+            if err := writeSSEEvent(c.Writer, event); err != nil {
+                return
+            }
+            c.Writer.Flush()
+        }
+    }
+}
+
+// writeSSEEvent writes one event in text/event-stream framing
+func writeSSEEvent(w io.Writer, event domain.WorkflowEvent) error {
+    payload, err := json.Marshal(event)
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+    return err
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"go-tempo/internal/health"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds how long a readiness probe waits on dependencies
+const healthCheckTimeout = 3 * time.Second
+
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+	return &HealthHandler{registry: registry}
+}
+
+// Liveness reports that the process is up
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+}
+
+// Readiness runs the checks of every role running in this process
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	roles := gin.H{}
+	ready := true
+	for _, role := range h.registry.Roles() {
+		failures := h.registry.Run(ctx, role)
+		if len(failures) > 0 {
+			ready = false
+			roles[role] = gin.H{"status": "not ready", "errors": failures}
+			continue
+		}
+		roles[role] = gin.H{"status": "ready"}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "roles": roles})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "roles": roles})
+}
+
+// RoleHealth runs the checks of a single role, 404 if the role is not running here
+func (h *HealthHandler) RoleHealth(c *gin.Context) {
+	role := c.Param("role")
+	if !h.registry.Has(role) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not running in this process"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	failures := h.registry.Run(ctx, role)
+	if len(failures) > 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"role": role, "status": "unhealthy", "errors": failures})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"role": role, "status": "healthy"})
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"go-tempo/internal/api/dto"
+	"go-tempo/internal/mapper"
+	"go-tempo/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TaskHandler serves the long-poll protocol of external workers
+type TaskHandler struct {
+	service     *service.TaskService
+	maxPollWait time.Duration
+}
+
+func NewTaskHandler(svc *service.TaskService, maxPollWait time.Duration) *TaskHandler {
+	return &TaskHandler{service: svc, maxPollWait: maxPollWait}
+}
+
+// PollTask waits for a task of the worker's actions and leases it to the worker.
+// Responds 204 when none arrived before the wait ended.
+func (h *TaskHandler) PollTask(c *gin.Context) {
+	var req dto.PollTaskRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wait := h.maxPollWait
+	if req.WaitSeconds > 0 && time.Duration(req.WaitSeconds)*time.Second < wait {
+		wait = time.Duration(req.WaitSeconds) * time.Second
+	}
+
+	leased, err := h.service.PollTask(c.Request.Context(), req.WorkerID, req.Actions, req.TaskQueues, wait)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if leased == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, mapper.ToLeasedTaskResponse(leased.Task, leased.LeaseToken, leased.LeaseExpiresAt))
+}
+
+// Heartbeat extends the lease of a task the worker is still executing
+func (h *TaskHandler) Heartbeat(c *gin.Context) {
+	taskID, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.HeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expiresAt, err := h.service.Heartbeat(c.Request.Context(), taskID, req.LeaseToken)
+	if err != nil {
+		respondLeaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.HeartbeatResponse{LeaseExpiresAt: expiresAt})
+}
+
+// CompleteTask records the output of a leased task
+func (h *TaskHandler) CompleteTask(c *gin.Context) {
+	taskID, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CompleteTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	output := []byte(req.Output)
+	if len(output) == 0 {
+		output = []byte("{}")
+	}
+
+	if err := h.service.CompleteTask(c.Request.Context(), taskID, req.LeaseToken, output); err != nil {
+		respondLeaseError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// FailTask records a failed attempt of a leased task, which is retried unless it can't be
+func (h *TaskHandler) FailTask(c *gin.Context) {
+	taskID, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.FailTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.FailTask(c.Request.Context(), taskID, req.LeaseToken, req.Error, !req.NonRetryable); err != nil {
+		respondLeaseError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func parseTaskID(c *gin.Context) (uuid.UUID, bool) {
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return uuid.Nil, false
+	}
+	return taskID, true
+}
+
+// respondLeaseError reports a lost lease as a conflict: the worker must drop the task
+func respondLeaseError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrLeaseLost) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
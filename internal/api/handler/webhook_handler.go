@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"go-tempo/internal/api/dto"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/mapper"
+	"go-tempo/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultDeliveryLimit caps the delivery log listing when no limit is given
+const defaultDeliveryLimit = 100
+
+type WebhookHandler struct {
+	service service.WebhookService
+}
+
+func NewWebhookHandler(svc service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: svc}
+}
+
+// CreateWebhook registers a global webhook subscription
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req dto.CreateWebhookRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.service.RegisterSubscription(c.Request.Context(), req.URL, req.Events, req.Secret)
+	if errors.Is(err, service.ErrInvalidEventType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The secret is only ever shown once, so the caller can verify signatures
+	resp := mapper.ToWebhookResponse(*sub)
+	resp.Secret = sub.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListWebhooks returns all global webhook subscriptions
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subs, err := h.service.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := make([]dto.WebhookResponse, 0, len(subs))
+	for _, sub := range subs {
+		resp = append(resp, mapper.ToWebhookResponse(sub))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteWebhook removes a global webhook subscription
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return
+	}
+
+	err = h.service.DeleteSubscription(c.Request.Context(), id)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries queries the delivery log by execution_id, subscription_id and status
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	filter := domain.WebhookDeliveryFilter{
+		Status: domain.WebhookDeliveryStatus(c.Query("status")),
+		Limit:  defaultDeliveryLimit,
+	}
+
+	if raw := c.Query("execution_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid execution_id"})
+			return
+		}
+		filter.ExecutionID = id
+	}
+	if raw := c.Query("subscription_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription_id"})
+			return
+		}
+		filter.SubscriptionID = id
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := make([]dto.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, mapper.ToWebhookDeliveryResponse(delivery))
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go-tempo/internal/domain"
+
+	"github.com/google/uuid"
+	"go.yaml.in/yaml/v2"
+)
+
+// APIKeyHeader carries a static API key
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator accepts the static API keys of a keys file. Only SHA-256 hashes of the
+// keys are stored, so the file doesn't disclose them:
+//
+//	keys:
+//	  - name: ci-pipeline
+//	    sha256: 5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8
+//	    user_id: 11111111-1111-1111-1111-111111111111 # Optional: the user it submits for
+//	    tenant: acme                                  # Optional: binds the key to a tenant
+//	    roles: [submit, read]
+type APIKeyAuthenticator struct {
+	keys []apiKey
+}
+
+type apiKey struct {
+	hash     []byte
+	identity domain.Identity
+}
+
+type apiKeysFile struct {
+	Keys []struct {
+		Name   string   `yaml:"name"`
+		SHA256 string   `yaml:"sha256"`
+		UserID string   `yaml:"user_id"`
+		Tenant string   `yaml:"tenant"`
+		Roles  []string `yaml:"roles"`
+	} `yaml:"keys"`
+}
+
+// NewAPIKeyAuthenticator loads the keys file at path
+func NewAPIKeyAuthenticator(path string) (*APIKeyAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file apiKeysFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	a := &APIKeyAuthenticator{}
+	for i, entry := range file.Keys {
+		hash, err := hex.DecodeString(entry.SHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("%s: key %d (%s): sha256 must be 64 hex digits", path, i, entry.Name)
+		}
+		identity := domain.Identity{Subject: entry.Name, Tenant: entry.Tenant}
+		if entry.UserID != "" {
+			if identity.UserID, err = uuid.Parse(entry.UserID); err != nil {
+				return nil, fmt.Errorf("%s: key %d (%s): user_id: %w", path, i, entry.Name, err)
+			}
+		}
+		if entry.Tenant != "" && !domain.ValidTenant(entry.Tenant) {
+			return nil, fmt.Errorf("%s: key %d (%s): invalid tenant %q", path, i, entry.Name, entry.Tenant)
+		}
+		for _, role := range entry.Roles {
+			if !domain.ValidRole(domain.Role(role)) {
+				return nil, fmt.Errorf("%s: key %d (%s): unknown role %q", path, i, entry.Name, role)
+			}
+			identity.Roles = append(identity.Roles, domain.Role(role))
+		}
+		a.keys = append(a.keys, apiKey{hash: hash, identity: identity})
+	}
+	return a, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*domain.Identity, error) {
+	key := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], a.keys[i].hash) == 1 {
+			// A copy, roles included, so callers can't change the key
+			identity := a.keys[i].identity
+			identity.Roles = append([]domain.Role(nil), identity.Roles...)
+			return &identity, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
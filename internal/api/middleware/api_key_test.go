@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-tempo/internal/domain"
+)
+
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeKeysFile writes a keys file and returns its path
+func writeKeysFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing keys file: %v", err)
+	}
+	return path
+}
+
+func authenticateKey(a *APIKeyAuthenticator, key string) (*domain.Identity, error) {
+	r := httptest.NewRequest("GET", "/api/v1/workflows", nil)
+	if key != "" {
+		r.Header.Set(APIKeyHeader, key)
+	}
+	return a.Authenticate(r)
+}
+
+func TestAPIKey(t *testing.T) {
+	keys := `
+keys:
+  - name: ci-pipeline
+    sha256: ` + keyHash("ci-secret") + `
+    user_id: 11111111-1111-1111-1111-111111111111
+    tenant: acme
+    roles: [submit, read]
+  - name: ops
+    sha256: ` + keyHash("ops-secret") + `
+    roles: [admin]
+`
+	a, err := NewAPIKeyAuthenticator(writeKeysFile(t, keys))
+	if err != nil {
+		t.Fatalf("NewAPIKeyAuthenticator: %v", err)
+	}
+
+	identity, err := authenticateKey(a, " ci-secret ")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if identity.Subject != "ci-pipeline" || identity.Tenant != "acme" ||
+		identity.UserID.String() != "11111111-1111-1111-1111-111111111111" ||
+		!identity.HasRole(domain.RoleSubmit) || identity.HasRole(domain.RoleCancel) {
+		t.Errorf("identity = %+v, want ci-pipeline of acme with submit and read", identity)
+	}
+
+	// The identity handed out is a copy, so a caller can't change the key's roles
+	identity.Roles[0] = domain.RoleAdmin
+	identity.Roles = append(identity.Roles, domain.RoleAdmin)
+	if again, _ := authenticateKey(a, "ci-secret"); again.HasRole(domain.RoleAdmin) {
+		t.Error("changing an identity changed the key's roles")
+	}
+
+	if _, err := authenticateKey(a, "unknown-secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate(unknown key) = %v, want invalid credentials", err)
+	}
+	if _, err := authenticateKey(a, ""); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Authenticate(no key) = %v, want no credentials", err)
+	}
+
+	// A key revoked by removing it from the file is refused once the file is reloaded
+	revoked, err := NewAPIKeyAuthenticator(writeKeysFile(t, `
+keys:
+  - name: ops
+    sha256: `+keyHash("ops-secret")+`
+    roles: [admin]
+`))
+	if err != nil {
+		t.Fatalf("NewAPIKeyAuthenticator: %v", err)
+	}
+	if _, err := authenticateKey(revoked, "ci-secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate(revoked key) = %v, want invalid credentials", err)
+	}
+	if _, err := authenticateKey(revoked, "ops-secret"); err != nil {
+		t.Errorf("Authenticate(remaining key): %v", err)
+	}
+}
+
+func TestAPIKeyFileInvalid(t *testing.T) {
+	hash := keyHash("secret")
+	for name, tt := range map[string]struct {
+		content string
+		want    string // In the error
+	}{
+		"plain key":      {"keys:\n  - name: a\n    sha256: secret\n", "sha256 must be 64 hex digits"},
+		"unknown role":   {"keys:\n  - name: a\n    sha256: " + hash + "\n    roles: [retry]\n", `unknown role "retry"`},
+		"invalid tenant": {"keys:\n  - name: a\n    sha256: " + hash + "\n    tenant: Not A Tenant\n", "invalid tenant"},
+		"bad user_id":    {"keys:\n  - name: a\n    sha256: " + hash + "\n    user_id: me\n", "user_id"},
+		"unknown field":  {"keys:\n  - name: a\n    key: secret\n", "key"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewAPIKeyAuthenticator(writeKeysFile(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewAPIKeyAuthenticator = %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
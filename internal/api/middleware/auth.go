@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"go-tempo/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request carries none of its
+	// credentials, so the next one is tried
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrInvalidCredentials is returned for credentials that are unknown, expired or badly signed
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator verifies one kind of credential, e.g. API keys or JWTs
+type Authenticator interface {
+	Authenticate(r *http.Request) (*domain.Identity, error)
+}
+
+// AuthMiddleware returns a Gin middleware that identifies the caller with the first
+// authenticator finding credentials in the request, and rejects the request with 401 otherwise
+func AuthMiddleware(authenticators ...Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, authenticator := range authenticators {
+			identity, err := authenticator.Authenticate(c.Request)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.Request = c.Request.WithContext(domain.WithIdentity(c.Request.Context(), identity))
+			c.Next()
+			return
+		}
+
+		c.Header("WWW-Authenticate", `Bearer realm="tempo"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+	}
+}
+
+// RequireRole returns a Gin middleware that rejects callers without the role with 403.
+// Requests pass unchecked when no AuthMiddleware identified the caller (authentication disabled).
+func RequireRole(role domain.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := domain.IdentityFromContext(c.Request.Context())
+		if ok && !identity.HasRole(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "requires the " + string(role) + " role"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go-tempo/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// jwtLeeway tolerates clock skew between the token issuer and this server
+const jwtLeeway = time.Minute
+
+// JWTOptions configures JWT validation
+type JWTOptions struct {
+	JWKSFile    string // JSON Web Key Set with the issuer's public keys
+	Issuer      string // Required "iss", if set
+	Audience    string // Required in "aud", if set
+	TenantClaim string // Claim binding the caller to a tenant
+	RolesClaim  string // Claim listing the caller's roles, as an array or space-separated string
+}
+
+// JWTAuthenticator accepts "Authorization: Bearer <jwt>" tokens signed with RS256, RS384,
+// RS512, ES256, ES384 or ES512 by a key of the configured JWKS file. The subject becomes the
+// caller's user ID when it is a UUID.
+type JWTAuthenticator struct {
+	opts JWTOptions
+	keys map[string]crypto.PublicKey // By key ID; "" when the set has a single key without one
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewJWTAuthenticator loads the JWKS file
+func NewJWTAuthenticator(opts JWTOptions) (*JWTAuthenticator, error) {
+	data, err := os.ReadFile(opts.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%s: %w", opts.JWKSFile, err)
+	}
+
+	a := &JWTAuthenticator{opts: opts, keys: make(map[string]crypto.PublicKey)}
+	for i, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		public, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %d (%s): %w", opts.JWKSFile, i, key.Kid, err)
+		}
+		a.keys[key.Kid] = public
+	}
+	if len(a.keys) == 0 {
+		return nil, fmt.Errorf("%s: no signing keys", opts.JWKSFile)
+	}
+	return a, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("invalid e")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*domain.Identity, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return a.identity(claims)
+}
+
+// verify checks the signature and the registered claims, returning every claim
+func (a *JWTAuthenticator) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	key, ok := a.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing exp")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("token not yet valid")
+	}
+	if a.opts.Issuer != "" && claims["iss"] != a.opts.Issuer {
+		return nil, fmt.Errorf("unexpected issuer")
+	}
+	if a.opts.Audience != "" && !containsString(claims["aud"], a.opts.Audience) {
+		return nil, fmt.Errorf("unexpected audience")
+	}
+	return claims, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	if len(alg) == 5 {
+		switch alg[2:] {
+		case "256":
+			hash = crypto.SHA256
+		case "384":
+			hash = crypto.SHA384
+		case "512":
+			hash = crypto.SHA512
+		}
+	}
+	if hash == 0 {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" {
+			return fmt.Errorf("algorithm %s does not match an RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return fmt.Errorf("bad signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			return fmt.Errorf("algorithm %s does not match an EC key", alg)
+		}
+		// JWS ECDSA signatures are r || s, each padded to the curve size
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("bad signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("bad signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported key")
+}
+
+// identity maps the claims to the caller
+func (a *JWTAuthenticator) identity(claims map[string]interface{}) (*domain.Identity, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidCredentials)
+	}
+	identity := &domain.Identity{Subject: subject}
+	if userID, err := uuid.Parse(subject); err == nil {
+		identity.UserID = userID
+	}
+
+	if tenant, ok := claims[a.opts.TenantClaim].(string); ok && tenant != "" {
+		if !domain.ValidTenant(tenant) {
+			return nil, fmt.Errorf("%w: invalid tenant claim", ErrInvalidCredentials)
+		}
+		identity.Tenant = tenant
+	}
+
+	var roles []string
+	switch value := claims[a.opts.RolesClaim].(type) {
+	case string:
+		roles = strings.Fields(value)
+	case []interface{}:
+		for _, role := range value {
+			if s, ok := role.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+	}
+	for _, role := range roles {
+		if domain.ValidRole(domain.Role(role)) {
+			identity.Roles = append(identity.Roles, domain.Role(role))
+		}
+	}
+	return identity, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// containsString matches a claim that is either a string or an array of strings
+func containsString(claim interface{}, want string) bool {
+	switch value := claim.(type) {
+	case string:
+		return value == want
+	case []interface{}:
+		for _, v := range value {
+			if v == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-tempo/internal/domain"
+)
+
+var (
+	testRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	testECKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// newTestJWTAuthenticator trusts testRSAKey as "rsa" and testECKey as "ec"
+func newTestJWTAuthenticator(t *testing.T) *JWTAuthenticator {
+	t.Helper()
+	ecSize := (testECKey.Curve.Params().BitSize + 7) / 8
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(testRSAKey.N.Bytes()), "e": b64(big.NewInt(int64(testRSAKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(testECKey.X.FillBytes(make([]byte, ecSize))), "y": b64(testECKey.Y.FillBytes(make([]byte, ecSize)))},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatalf("writing JWKS: %v", err)
+	}
+
+	a, err := NewJWTAuthenticator(JWTOptions{
+		JWKSFile:    path,
+		Issuer:      "https://issuer.example",
+		Audience:    "tempo",
+		TenantClaim: "tenant",
+		RolesClaim:  "roles",
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator: %v", err)
+	}
+	return a
+}
+
+// signJWT signs the claims with alg; the key is picked by the alg's family, so a header naming
+// another key's kid tests a mismatch
+func signJWT(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, testRSAKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("signing: %v", err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, testECKey, digest[:])
+		if err != nil {
+			t.Fatalf("signing: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(signature)
+}
+
+// validClaims are accepted by newTestJWTAuthenticator, with changes applied
+func validClaims(changes map[string]any) map[string]any {
+	claims := map[string]any{
+		"sub":    "11111111-1111-1111-1111-111111111111",
+		"iss":    "https://issuer.example",
+		"aud":    []string{"other", "tempo"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"nbf":    time.Now().Add(-time.Minute).Unix(),
+		"tenant": "acme",
+		"roles":  "submit read unknown",
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func authenticateBearer(a *JWTAuthenticator, token string) (*domain.Identity, error) {
+	r := httptest.NewRequest("GET", "/api/v1/workflows", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return a.Authenticate(r)
+}
+
+func TestJWTValid(t *testing.T) {
+	a := newTestJWTAuthenticator(t)
+	for _, tt := range []struct {
+		name, alg, kid string
+		changes        map[string]any
+	}{
+		{name: "RS256", alg: "RS256", kid: "rsa"},
+		{name: "ES256", alg: "ES256", kid: "ec"},
+		{name: "expired within the leeway", alg: "RS256", kid: "rsa", changes: map[string]any{"exp": time.Now().Add(-30 * time.Second).Unix()}},
+		{name: "single audience", alg: "RS256", kid: "rsa", changes: map[string]any{"aud": "tempo"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := authenticateBearer(a, signJWT(t, tt.alg, tt.kid, validClaims(tt.changes)))
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if identity.UserID.String() != "11111111-1111-1111-1111-111111111111" || identity.Tenant != "acme" {
+				t.Errorf("identity = %s of %q, want the subject's user of acme", identity.UserID, identity.Tenant)
+			}
+			// Unknown roles are ignored
+			if len(identity.Roles) != 2 || !identity.HasRole(domain.RoleSubmit) || !identity.HasRole(domain.RoleRead) {
+				t.Errorf("roles = %v, want [submit read]", identity.Roles)
+			}
+		})
+	}
+}
+
+func TestJWTRejected(t *testing.T) {
+	a := newTestJWTAuthenticator(t)
+	valid := signJWT(t, "RS256", "rsa", validClaims(nil))
+	parts := strings.Split(valid, ".")
+	tamperedClaims, _ := json.Marshal(validClaims(map[string]any{"roles": "admin"}))
+	noneHeader, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa"})
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  string // In the error
+	}{
+		{name: "tampered signature", token: parts[0] + "." + parts[1] + "." + b64([]byte("forged")), want: "bad signature"},
+		{name: "tampered claims", token: parts[0] + "." + b64(tamperedClaims) + "." + parts[2], want: "bad signature"},
+		{name: "alg none", token: b64(noneHeader) + "." + parts[1] + ".", want: "unsupported algorithm"},
+		{name: "EC alg with an RSA key", token: signJWT(t, "ES256", "rsa", validClaims(nil)), want: "does not match an RSA key"},
+		{name: "RSA alg with an EC key", token: signJWT(t, "RS256", "ec", validClaims(nil)), want: "does not match an EC key"},
+		{name: "unknown kid", token: signJWT(t, "RS256", "rotated", validClaims(nil)), want: "unknown key"},
+		{name: "expired", token: signJWT(t, "RS256", "rsa", validClaims(map[string]any{"exp": time.Now().Add(-2 * time.Minute).Unix()})), want: "expired"},
+		{name: "missing exp", token: signJWT(t, "RS256", "rsa", validClaims(map[string]any{"exp": nil})), want: "missing exp"},
+		{name: "not yet valid", token: signJWT(t, "RS256", "rsa", validClaims(map[string]any{"nbf": time.Now().Add(2 * time.Minute).Unix()})), want: "not yet valid"},
+		{name: "wrong issuer", token: signJWT(t, "RS256", "rsa", validClaims(map[string]any{"iss": "https://evil.example"})), want: "unexpected issuer"},
+		{name: "wrong audience", token: signJWT(t, "RS256", "rsa", validClaims(map[string]any{"aud": "other"})), want: "unexpected audience"},
+		{name: "missing sub", token: signJWT(t, "RS256", "rsa", validClaims(map[string]any{"sub": nil})), want: "missing sub"},
+		{name: "invalid tenant", token: signJWT(t, "RS256", "rsa", validClaims(map[string]any{"tenant": "Not A Tenant"})), want: "invalid tenant"},
+		{name: "malformed", token: "not-a-jwt", want: "malformed"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := authenticateBearer(a, tt.token)
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("Authenticate = %+v, %v, want invalid credentials", identity, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q doesn't mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTNoCredentials(t *testing.T) {
+	a := newTestJWTAuthenticator(t)
+	r := httptest.NewRequest("GET", "/api/v1/workflows", nil)
+	r.Header.Set(APIKeyHeader, "ci-secret")
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Authenticate without a bearer token = %v, want no credentials", err)
+	}
+}
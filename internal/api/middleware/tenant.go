@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-tempo/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader names the tenant a request acts for
+const TenantHeader = "X-Tenant-ID"
+
+var (
+	// ErrTenantMismatch is returned when credentials bound to a tenant name another one
+	ErrTenantMismatch = errors.New("credentials are bound to tenant")
+
+	// ErrInvalidTenant is returned for a tenant name the store can't scope by
+	ErrInvalidTenant = errors.New("invalid " + TenantHeader + ": use up to 63 lowercase letters, digits, '-' or '_'")
+)
+
+// ResolveTenant returns the tenant a request naming requested acts for: the tenant the caller's
+// credentials are bound to, else requested, else the default tenant
+func ResolveTenant(ctx context.Context, requested string) (string, error) {
+	tenant := requested
+	if identity, ok := domain.IdentityFromContext(ctx); ok && identity.Tenant != "" {
+		if tenant != "" && tenant != identity.Tenant {
+			return "", fmt.Errorf("%w %s", ErrTenantMismatch, identity.Tenant)
+		}
+		tenant = identity.Tenant
+	}
+	if tenant == "" {
+		tenant = domain.DefaultTenant
+	}
+	if !domain.ValidTenant(tenant) {
+		return "", ErrInvalidTenant
+	}
+	return tenant, nil
+}
+
+// TenantMiddleware returns a Gin middleware that scopes the request context to the tenant in
+// the X-Tenant-ID header, or to the default tenant when the header is absent. Callers whose
+// credentials are bound to a tenant act for that tenant and may not name another.
+func TenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant, err := ResolveTenant(c.Request.Context(), c.GetHeader(TenantHeader))
+		if errors.Is(err, ErrTenantMismatch) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Request = c.Request.WithContext(domain.WithTenant(c.Request.Context(), tenant))
+		c.Next()
+	}
+}
+
+// CrossTenantMiddleware returns a Gin middleware for routes that act for every tenant, such as
+// those of external workers. Callers whose credentials are bound to a tenant are refused.
+func CrossTenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity, ok := domain.IdentityFromContext(c.Request.Context()); ok && identity.Tenant != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "credentials bound to tenant " + identity.Tenant + " can't act for every tenant",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"go-tempo/internal/api/middleware"
+	"go-tempo/internal/api/rpc/tempov1"
+	"go-tempo/internal/domain"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodRoles names the role each RPC requires, like the routes of the REST API
+var methodRoles = map[string]domain.Role{
+	tempov1.WorkflowService_SubmitWorkflow_FullMethodName: domain.RoleSubmit,
+	tempov1.WorkflowService_GetWorkflow_FullMethodName:    domain.RoleRead,
+	tempov1.WorkflowService_ListWorkflows_FullMethodName:  domain.RoleRead,
+	tempov1.WorkflowService_CancelWorkflow_FullMethodName: domain.RoleCancel,
+	tempov1.WorkflowService_WatchWorkflow_FullMethodName:  domain.RoleRead,
+}
+
+// authInterceptor authenticates calls from the credentials in their metadata (x-api-key or
+// authorization), checks the role of the method and scopes the call to the tenant in x-tenant-id
+type authInterceptor struct {
+	authenticators []middleware.Authenticator
+}
+
+func (a *authInterceptor) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authInterceptor) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *authInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if len(a.authenticators) > 0 {
+		identity, err := a.authenticate(md)
+		if err != nil {
+			return nil, err
+		}
+		ctx = domain.WithIdentity(ctx, identity)
+
+		if role, ok := methodRoles[method]; ok && !identity.HasRole(role) {
+			return nil, status.Error(codes.PermissionDenied, "requires the "+string(role)+" role")
+		}
+	}
+
+	tenant, err := middleware.ResolveTenant(ctx, firstValue(md, middleware.TenantHeader))
+	if errors.Is(err, middleware.ErrTenantMismatch) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return domain.WithTenant(ctx, tenant), nil
+}
+
+// authenticate hands the credentials of the metadata to the authenticators of the REST API
+// as the headers they read
+func (a *authInterceptor) authenticate(md metadata.MD) (*domain.Identity, error) {
+	r := &http.Request{Header: make(http.Header)}
+	for _, header := range []string{middleware.APIKeyHeader, "Authorization"} {
+		if value := firstValue(md, header); value != "" {
+			r.Header.Set(header, value)
+		}
+	}
+
+	for _, authenticator := range a.authenticators {
+		identity, err := authenticator.Authenticate(r)
+		if errors.Is(err, middleware.ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return identity, nil
+	}
+	return nil, status.Error(codes.Unauthenticated, "authentication required")
+}
+
+// firstValue returns the first value of a metadata key, matched case-insensitively like headers
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// serverStream carries the authorized context to stream handlers
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
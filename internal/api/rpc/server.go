@@ -0,0 +1,181 @@
+// Package rpc serves the workflow API over gRPC, on the same service layer and mappers as the
+// REST handlers
+package rpc
+
+import (
+	"context"
+	"errors"
+	"go-tempo/internal/api/middleware"
+	"go-tempo/internal/api/rpc/tempov1"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/mapper"
+	"go-tempo/internal/metrics"
+	"go-tempo/internal/service"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// NewServer returns a gRPC server offering the WorkflowService. Calls are authenticated by the
+// authenticators, if any, and scoped to a tenant like the tenant routes of the REST API.
+func NewServer(svc service.WorkflowService, authenticators []middleware.Authenticator) *grpc.Server {
+	auth := &authInterceptor{authenticators: authenticators}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.unary),
+		grpc.StreamInterceptor(auth.stream),
+	)
+	tempov1.RegisterWorkflowServiceServer(server, NewWorkflowServer(svc))
+	return server
+}
+
+// WorkflowServer implements tempov1.WorkflowServiceServer
+type WorkflowServer struct {
+	tempov1.UnimplementedWorkflowServiceServer
+	service service.WorkflowService
+}
+
+func NewWorkflowServer(svc service.WorkflowService) *WorkflowServer {
+	return &WorkflowServer{service: svc}
+}
+
+func (s *WorkflowServer) SubmitWorkflow(ctx context.Context, req *tempov1.SubmitWorkflowRequest) (*tempov1.SubmitWorkflowResponse, error) {
+	body, err := mapper.FromSubmitWorkflowProto(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	}
+	// Validate with the binding rules of the REST API
+	if err := binding.Validator.ValidateStruct(&body); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := service.ValidateEventTypes(body.CallbackEvents); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// The user_id must be the caller's own unless the caller is an admin
+	if identity, ok := domain.IdentityFromContext(ctx); ok && !identity.CanActFor(body.UserID) {
+		return nil, status.Error(codes.PermissionDenied, "user_id does not match the authenticated caller")
+	}
+
+	execution, tasks := mapper.ToWorkflowExecution(body)
+
+	executionID, err := s.service.SubmitWorkflow(ctx, execution, tasks)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	metrics.WorkflowsSubmittedTotal.WithLabelValues("default").Inc()
+
+	return &tempov1.SubmitWorkflowResponse{ExecutionId: executionID.String()}, nil
+}
+
+func (s *WorkflowServer) GetWorkflow(ctx context.Context, req *tempov1.GetWorkflowRequest) (*tempov1.Workflow, error) {
+	executionID, err := parseExecutionID(req.GetExecutionId())
+	if err != nil {
+		return nil, err
+	}
+
+	execution, err := s.service.GetWorkflow(ctx, executionID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return mapper.ToWorkflowProto(*execution), nil
+}
+
+func (s *WorkflowServer) ListWorkflows(ctx context.Context, req *tempov1.ListWorkflowsRequest) (*tempov1.ListWorkflowsResponse, error) {
+	query := mapper.FromListWorkflowsProto(req)
+	if err := binding.Validator.ValidateStruct(&query); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	filter, err := mapper.ToWorkflowFilter(query)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	page, err := s.service.ListWorkflows(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return mapper.ToListWorkflowsProto(page), nil
+}
+
+// CancelWorkflow returns once the workflow is CANCELLED; it emits workflow.cancelled when its
+// running tasks finish
+func (s *WorkflowServer) CancelWorkflow(ctx context.Context, req *tempov1.CancelWorkflowRequest) (*tempov1.CancelWorkflowResponse, error) {
+	executionID, err := parseExecutionID(req.GetExecutionId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.service.CancelWorkflow(ctx, executionID); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tempov1.CancelWorkflowResponse{}, nil
+}
+
+// WatchWorkflow streams the lifecycle events of a workflow, ending after its final event.
+// Clients resuming with last_event_id get the events after the last one they received.
+func (s *WorkflowServer) WatchWorkflow(req *tempov1.WatchWorkflowRequest, stream tempov1.WorkflowService_WatchWorkflowServer) error {
+	executionID, err := parseExecutionID(req.GetExecutionId())
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	events, err := s.service.WatchWorkflow(ctx, executionID, req.GetLastEventId())
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(mapper.ToWorkflowEventProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func parseExecutionID(id string) (uuid.UUID, error) {
+	executionID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid workflow id")
+	}
+	return executionID, nil
+}
+
+// toStatus maps service errors to the gRPC codes matching the statuses of the REST API
+func toStatus(err error) error {
+	var quotaErr *service.QuotaExceededError
+	switch {
+	case errors.Is(err, service.ErrWorkflowNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrWorkflowNotRunning):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.As(err, &quotaErr):
+		st := status.New(codes.ResourceExhausted, err.Error())
+		if quotaErr.RetryAfter > 0 {
+			if detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{
+				RetryDelay: durationpb.New(quotaErr.RetryAfter),
+			}); detailErr == nil {
+				st = detailed
+			}
+		}
+		return st.Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
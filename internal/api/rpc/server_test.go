@@ -0,0 +1,269 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-tempo/internal/api/middleware"
+	"go-tempo/internal/api/rpc"
+	"go-tempo/internal/api/rpc/tempov1"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/history"
+	"go-tempo/internal/infrastructure/memory"
+	"go-tempo/internal/routing"
+	"go-tempo/internal/service"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// keyAuthenticator identifies callers by the X-API-Key header alone
+type keyAuthenticator map[string]*domain.Identity
+
+func (a keyAuthenticator) Authenticate(r *http.Request) (*domain.Identity, error) {
+	key := r.Header.Get(middleware.APIKeyHeader)
+	if key == "" {
+		return nil, middleware.ErrNoCredentials
+	}
+	identity, ok := a[key]
+	if !ok {
+		return nil, middleware.ErrInvalidCredentials
+	}
+	return identity, nil
+}
+
+var (
+	acmeUser = uuid.New()
+	keys     = keyAuthenticator{
+		"admin":       {Subject: "admin", Roles: []domain.Role{domain.RoleAdmin}},
+		"acme-admin":  {Subject: "acme-admin", Tenant: "acme", Roles: []domain.Role{domain.RoleAdmin}},
+		"acme-user":   {Subject: "acme-user", Tenant: "acme", UserID: acmeUser, Roles: []domain.Role{domain.RoleSubmit, domain.RoleRead}},
+		"acme-reader": {Subject: "acme-reader", Tenant: "acme", UserID: acmeUser, Roles: []domain.Role{domain.RoleRead}},
+	}
+)
+
+// newClient serves the WorkflowService over an in-memory listener, on the workflow service of
+// the embedded mode
+func newClient(t *testing.T) tempov1.WorkflowServiceClient {
+	t.Helper()
+	store := memory.NewStore()
+	taskRepo := memory.NewTaskRepository(store)
+	historyRepo := memory.NewWorkflowEventRepository(store)
+	queues := routing.NewRouter("pending", "retry", nil, func(name string) ports.TaskQueue {
+		return memory.NewQueue(time.Minute, nil)
+	})
+	eventBus := history.NewRecordingEventBus(memory.NewEventBus(100), historyRepo)
+	svc := service.NewWorkflowService(taskRepo, memory.NewWorkflowRepository(store), queues, eventBus,
+		historyRepo, domain.TenantQuota{}, memory.NewRateLimiter())
+
+	listener := bufconn.Listen(1 << 20)
+	server := rpc.NewServer(svc, []middleware.Authenticator{keys})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dialing bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return tempov1.NewWorkflowServiceClient(conn)
+}
+
+// call returns ctx carrying the API key and tenant as metadata, each left out when empty
+func call(key, tenant string) context.Context {
+	md := metadata.MD{}
+	if key != "" {
+		md.Set(middleware.APIKeyHeader, key)
+	}
+	if tenant != "" {
+		md.Set(middleware.TenantHeader, tenant)
+	}
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+func submitRequest(userID uuid.UUID) *tempov1.SubmitWorkflowRequest {
+	input, _ := structpb.NewStruct(map[string]any{"n": 1})
+	return &tempov1.SubmitWorkflowRequest{
+		Type:   "onboarding",
+		UserId: userID.String(),
+		Tasks:  []*tempov1.TaskSpec{{RefId: "a", Action: "noop", Input: input}},
+	}
+}
+
+// rpcs invokes each RPC of the service, returning its error. WatchWorkflow's error arrives
+// with its first message.
+func rpcs(client tempov1.WorkflowServiceClient, executionID string) map[string]func(ctx context.Context) error {
+	return map[string]func(ctx context.Context) error{
+		"SubmitWorkflow": func(ctx context.Context) error {
+			_, err := client.SubmitWorkflow(ctx, submitRequest(acmeUser))
+			return err
+		},
+		"GetWorkflow": func(ctx context.Context) error {
+			_, err := client.GetWorkflow(ctx, &tempov1.GetWorkflowRequest{ExecutionId: executionID})
+			return err
+		},
+		"ListWorkflows": func(ctx context.Context) error {
+			_, err := client.ListWorkflows(ctx, &tempov1.ListWorkflowsRequest{})
+			return err
+		},
+		"CancelWorkflow": func(ctx context.Context) error {
+			_, err := client.CancelWorkflow(ctx, &tempov1.CancelWorkflowRequest{ExecutionId: executionID})
+			return err
+		},
+		"WatchWorkflow": func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			stream, err := client.WatchWorkflow(ctx, &tempov1.WatchWorkflowRequest{ExecutionId: executionID})
+			if err != nil {
+				return err
+			}
+			_, err = stream.Recv()
+			return err
+		},
+	}
+}
+
+func wantCode(t *testing.T, name string, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Errorf("%s = %v, want %s", name, err, want)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	client := newClient(t)
+	executionID := uuid.NewString()
+
+	for name, rpc := range rpcs(client, executionID) {
+		wantCode(t, name+" without credentials", rpc(call("", "")), codes.Unauthenticated)
+		wantCode(t, name+" with an unknown key", rpc(call("stolen", "")), codes.Unauthenticated)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	client := newClient(t)
+	created, err := client.SubmitWorkflow(call("acme-admin", ""), submitRequest(acmeUser))
+	if err != nil {
+		t.Fatalf("SubmitWorkflow: %v", err)
+	}
+
+	// acme-reader holds only the read role, so acting for its own user takes nothing else
+	calls := rpcs(client, created.ExecutionId)
+	for name, want := range map[string]codes.Code{
+		"SubmitWorkflow": codes.PermissionDenied,
+		"GetWorkflow":    codes.OK,
+		"ListWorkflows":  codes.OK,
+		"CancelWorkflow": codes.PermissionDenied,
+		"WatchWorkflow":  codes.OK,
+	} {
+		wantCode(t, name+" as a reader", calls[name](call("acme-reader", "")), want)
+	}
+
+	// Submitting for another user takes the admin role
+	_, err = client.SubmitWorkflow(call("acme-user", ""), submitRequest(uuid.New()))
+	wantCode(t, "SubmitWorkflow for another user", err, codes.PermissionDenied)
+	_, err = client.SubmitWorkflow(call("acme-user", ""), submitRequest(acmeUser))
+	wantCode(t, "SubmitWorkflow for the caller", err, codes.OK)
+}
+
+func TestTenants(t *testing.T) {
+	client := newClient(t)
+	created, err := client.SubmitWorkflow(call("admin", "acme"), submitRequest(acmeUser))
+	if err != nil {
+		t.Fatalf("SubmitWorkflow: %v", err)
+	}
+	calls := rpcs(client, created.ExecutionId)
+
+	for name, rpc := range calls {
+		// Credentials bound to acme may not name another tenant
+		wantCode(t, name+" naming another tenant", rpc(call("acme-admin", "globex")), codes.PermissionDenied)
+		wantCode(t, name+" naming an invalid tenant", rpc(call("admin", "Not A Tenant")), codes.InvalidArgument)
+	}
+
+	// Workflows of acme don't exist for globex
+	for _, name := range []string{"GetWorkflow", "CancelWorkflow", "WatchWorkflow"} {
+		wantCode(t, name+" of another tenant", calls[name](call("admin", "globex")), codes.NotFound)
+	}
+	list, err := client.ListWorkflows(call("admin", "globex"), &tempov1.ListWorkflowsRequest{})
+	if err != nil {
+		t.Fatalf("ListWorkflows: %v", err)
+	}
+	if len(list.Workflows) != 0 {
+		t.Errorf("ListWorkflows of another tenant returned %d workflows", len(list.Workflows))
+	}
+
+	// Credentials bound to acme act for it without naming it
+	workflow, err := client.GetWorkflow(call("acme-admin", ""), &tempov1.GetWorkflowRequest{ExecutionId: created.ExecutionId})
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if workflow.Tenant != "acme" {
+		t.Errorf("tenant = %q, want acme", workflow.Tenant)
+	}
+	list, err = client.ListWorkflows(call("acme-admin", "acme"), &tempov1.ListWorkflowsRequest{})
+	if err != nil {
+		t.Fatalf("ListWorkflows: %v", err)
+	}
+	if len(list.Workflows) != 1 || list.Workflows[0].ExecutionId != created.ExecutionId {
+		t.Errorf("ListWorkflows of acme returned %d workflows, want the one submitted", len(list.Workflows))
+	}
+	if _, err := client.CancelWorkflow(call("acme-admin", ""), &tempov1.CancelWorkflowRequest{ExecutionId: created.ExecutionId}); err != nil {
+		t.Fatalf("CancelWorkflow: %v", err)
+	}
+}
+
+func TestWatchWorkflow(t *testing.T) {
+	client := newClient(t)
+	created, err := client.SubmitWorkflow(call("acme-admin", ""), submitRequest(acmeUser))
+	if err != nil {
+		t.Fatalf("SubmitWorkflow: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(call("acme-reader", ""), 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchWorkflow(ctx, &tempov1.WatchWorkflowRequest{ExecutionId: created.ExecutionId})
+	if err != nil {
+		t.Fatalf("WatchWorkflow: %v", err)
+	}
+
+	// The history replays in order
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	second, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if first.Type != string(domain.EventWorkflowSubmitted) || second.Type != string(domain.EventTaskQueued) || second.RefId != "a" {
+		t.Errorf("events = %s, %s %s, want workflow.submitted, task.queued a", first.Type, second.Type, second.RefId)
+	}
+
+	// Resuming after the first event starts at the second
+	resumed, err := client.WatchWorkflow(ctx, &tempov1.WatchWorkflowRequest{ExecutionId: created.ExecutionId, LastEventId: first.Id})
+	if err != nil {
+		t.Fatalf("WatchWorkflow: %v", err)
+	}
+	event, err := resumed.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if event.Id != second.Id {
+		t.Errorf("resumed watch started at %s %s, want %s", event.Type, event.Id, second.Id)
+	}
+}
@@ -0,0 +1,523 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"go-tempo/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds every tunable of the server. Values are layered, each overriding the last:
+// defaults, the YAML file (--config or TEMPO_CONFIG), environment variables, then flags.
+//
+// Every leaf field is addressable as:
+//   - YAML:  section.key           (e.g. database: {max_open_conns: 50})
+//   - env:   TEMPO_SECTION_KEY     (e.g. TEMPO_DATABASE_MAX_OPEN_CONNS), or the `env` tag alias
+//   - flag:  --section.key         (e.g. --database.max-open-conns, underscores become dashes)
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	HTTP        HTTPConfig        `yaml:"http"`
+	GRPC        GRPCConfig        `yaml:"grpc"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Queues      QueueConfig       `yaml:"queues"`
+	Coordinator CoordinatorConfig `yaml:"coordinator"`
+	Workers     WorkerConfig      `yaml:"workers"`
+	External    ExternalConfig    `yaml:"external_workers"`
+	Limits      LimitConfig       `yaml:"limits"`
+	Tenants     TenantConfig      `yaml:"tenants"`
+	Events      EventConfig       `yaml:"events"`
+	ResultCache ResultCacheConfig `yaml:"result_cache"`
+	HTTPAction  HTTPActionConfig  `yaml:"http_action"`
+	ShellAction ShellActionConfig `yaml:"shell_action"`
+	Webhooks    WebhookConfig     `yaml:"webhooks"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+}
+
+// Process roles selectable with --roles
+const (
+	RoleAPI         = "api"
+	RoleCoordinator = "coordinator"
+	RoleWorker      = "worker"
+)
+
+// StorePostgres is the default store; "sqlite:<path>" and "memory" select the embedded single-process mode
+const (
+	StorePostgres     = "postgres"
+	StoreMemory       = "memory"
+	storeSQLitePrefix = "sqlite:"
+)
+
+type ServerConfig struct {
+	Store        string        `yaml:"store" flag:"store" usage:"Storage backend: postgres (with Redis), sqlite:<path> to run in one process without Postgres or Redis, or memory to also keep no state across restarts"`
+	Roles        []string      `yaml:"roles" flag:"roles" usage:"Comma-separated roles to run: api, coordinator, worker"`
+	DrainTimeout time.Duration `yaml:"drain_timeout" usage:"Time running tasks get to finish on shutdown before they are canceled and requeued"`
+}
+
+// SQLitePath returns the database path of the embedded store, and false for Postgres
+func (c *Config) SQLitePath() (string, bool) {
+	if !strings.HasPrefix(c.Server.Store, storeSQLitePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(c.Server.Store, storeSQLitePrefix), true
+}
+
+// MemoryStore reports whether all state is kept in the process, with no database
+func (c *Config) MemoryStore() bool {
+	return c.Server.Store == StoreMemory
+}
+
+// Embedded reports whether every role runs in this process on the in-process queues and event bus
+func (c *Config) Embedded() bool {
+	_, sqlite := c.SQLitePath()
+	return sqlite || c.MemoryStore()
+}
+
+// HasRole reports whether this process runs the given role
+func (c *Config) HasRole(role string) bool {
+	for _, r := range c.Server.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskQueueRoutes returns the task queue of each action routed by queues.routes
+func (c *Config) TaskQueueRoutes() map[string]string {
+	routes := make(map[string]string, len(c.Queues.Routes))
+	for _, route := range c.Queues.Routes {
+		if action, taskQueue, ok := strings.Cut(route, "="); ok {
+			routes[action] = taskQueue
+		}
+	}
+	return routes
+}
+
+// TaskQueueConcurrency returns the main pool size of a task queue
+func (c *Config) TaskQueueConcurrency(taskQueue string) int {
+	for _, entry := range c.Workers.QueueConcurrency {
+		name, value, _ := strings.Cut(entry, "=")
+		if n, err := strconv.Atoi(value); err == nil && name == taskQueue {
+			return n
+		}
+	}
+	return c.Workers.MainConcurrency
+}
+
+// ActionLimits returns the limits of every action named in limits.concurrency or limits.rate
+func (c *Config) ActionLimits() map[string]domain.ActionLimit {
+	limits := make(map[string]domain.ActionLimit)
+	for _, entry := range c.Limits.Concurrency {
+		action, value, _ := strings.Cut(entry, "=")
+		if n, err := strconv.Atoi(value); err == nil {
+			limit := limits[action]
+			limit.MaxConcurrent = n
+			limits[action] = limit
+		}
+	}
+	for _, entry := range c.Limits.Rate {
+		action, value, _ := strings.Cut(entry, "=")
+		if n, period, err := parseRate(value); err == nil {
+			limit := limits[action]
+			limit.Rate, limit.RatePeriod = n, period
+			limits[action] = limit
+		}
+	}
+	return limits
+}
+
+// TenantQuota returns the limits applied to each tenant's submissions
+func (c *Config) TenantQuota() domain.TenantQuota {
+	return domain.TenantQuota{
+		MaxRunningWorkflows:  c.Tenants.MaxRunningWorkflows,
+		MaxQueuedTasks:       c.Tenants.MaxQueuedTasks,
+		SubmissionsPerSecond: c.Tenants.SubmissionsPerSecond,
+	}
+}
+
+// TenantWeights returns the dispatch weight of every tenant named in tenants.weights
+func (c *Config) TenantWeights() domain.TenantWeights {
+	weights := make(domain.TenantWeights, len(c.Tenants.Weights))
+	for _, entry := range c.Tenants.Weights {
+		tenant, value, _ := strings.Cut(entry, "=")
+		if n, err := strconv.Atoi(value); err == nil {
+			weights[tenant] = n
+		}
+	}
+	return weights
+}
+
+// parseRate parses "count/period", e.g. "50/1m"
+func parseRate(value string) (int, time.Duration, error) {
+	count, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("missing /period")
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, 0, err
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return 0, 0, err
+	}
+	if n <= 0 || d <= 0 {
+		return 0, 0, fmt.Errorf("count and period must be positive")
+	}
+	return n, d, nil
+}
+
+type HTTPConfig struct {
+	Addr              string        `yaml:"addr" usage:"HTTP listen address"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" usage:"Time allowed to read request headers"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" usage:"Time allowed to read a whole request, 0 for no limit"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" usage:"Keep-alive idle timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" usage:"Time open requests and event streams get to finish on shutdown"`
+}
+
+type GRPCConfig struct {
+	Addr string `yaml:"addr" usage:"gRPC listen address of the API role, empty to disable the gRPC API"`
+}
+
+type AuthConfig struct {
+	APIKeysFile string `yaml:"api_keys_file" usage:"YAML file of SHA-256 hashed API keys accepted in X-API-Key"`
+	JWKSFile    string `yaml:"jwks_file" usage:"JSON Web Key Set verifying bearer JWTs"`
+	JWTIssuer   string `yaml:"jwt_issuer" usage:"Required iss claim of JWTs, empty to accept any"`
+	JWTAudience string `yaml:"jwt_audience" usage:"Required aud claim of JWTs, empty to accept any"`
+	TenantClaim string `yaml:"tenant_claim" usage:"JWT claim binding the caller to a tenant"`
+	RolesClaim  string `yaml:"roles_claim" usage:"JWT claim listing the caller's roles"`
+}
+
+// AuthEnabled reports whether API requests must authenticate
+func (c *Config) AuthEnabled() bool {
+	return c.Auth.APIKeysFile != "" || c.Auth.JWKSFile != ""
+}
+
+type DatabaseConfig struct {
+	URL             string        `yaml:"url" env:"DB_URL" secret:"true" usage:"Postgres DSN"`
+	MaxOpenConns    int           `yaml:"max_open_conns" usage:"Maximum open connections"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" usage:"Maximum idle connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" usage:"Maximum connection lifetime, 0 reuses forever"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" usage:"Maximum idle time per connection, 0 for no limit"`
+	MigrateOnStart  bool          `yaml:"migrate_on_start" usage:"Apply pending schema migrations at startup"`
+}
+
+type RedisConfig struct {
+	Addr         string        `yaml:"addr" env:"REDIS_ADDR" usage:"Redis address"`
+	Password     string        `yaml:"password" secret:"true" usage:"Redis password"`
+	DB           int           `yaml:"db" usage:"Redis database number"`
+	PoolSize     int           `yaml:"pool_size" usage:"Maximum Redis socket connections"`
+	DialTimeout  time.Duration `yaml:"dial_timeout" usage:"Redis dial timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" usage:"Redis read timeout (blocking pops are not affected)"`
+	WriteTimeout time.Duration `yaml:"write_timeout" usage:"Redis write timeout"`
+}
+
+// Task queue backends selectable with queues.backend
+const (
+	QueueBackendRedis    = "redis"
+	QueueBackendPostgres = "postgres"
+)
+
+type QueueConfig struct {
+	Backend       string        `yaml:"backend" usage:"Task queue backend: redis, or postgres to queue on the tasks table"`
+	Pending       string        `yaml:"pending" usage:"Queue name for newly queued tasks"`
+	Retry         string        `yaml:"retry" usage:"Queue name for retried tasks"`
+	PollInterval  time.Duration `yaml:"poll_interval" usage:"Postgres backend: how often idle workers poll when no NOTIFY arrives"`
+	AgingInterval time.Duration `yaml:"aging_interval" usage:"Waiting time that counts as one priority level, so low-priority tasks aren't starved"`
+	Routes        []string      `yaml:"routes" usage:"Comma-separated action=task_queue defaults for tasks that don't name a task queue; other actions use the default task queue"`
+}
+
+type CoordinatorConfig struct {
+	LeaderLockTTL time.Duration `yaml:"leader_lock_ttl" usage:"Lease of the lock held by the one coordinator handling task events; a standby coordinator takes over within this long after the leader dies"`
+}
+
+type WorkerConfig struct {
+	MainConcurrency  int           `yaml:"main_concurrency" usage:"Worker goroutines consuming the pending queue"`
+	RetryConcurrency int           `yaml:"retry_concurrency" usage:"Worker goroutines consuming the retry queue"`
+	Actions          []string      `yaml:"actions" usage:"Comma-separated actions this process handles; it consumes only their task queues. Empty handles every action"`
+	QueueConcurrency []string      `yaml:"queue_concurrency" usage:"Comma-separated task_queue=goroutines for the main pool of named task queues; others use main_concurrency"`
+	ProgressInterval time.Duration `yaml:"progress_interval" usage:"Least time between writes of the progress a handler reports; reports in between are coalesced"`
+}
+
+type ExternalConfig struct {
+	LeaseTTL     time.Duration `yaml:"lease_ttl" usage:"Time an external worker holds a polled task without a heartbeat before it is retried"`
+	MaxPollWait  time.Duration `yaml:"max_poll_wait" usage:"Longest an external worker's poll waits for a task"`
+	ReapInterval time.Duration `yaml:"reap_interval" usage:"How often tasks of external workers whose lease expired are retried"`
+}
+
+type LimitConfig struct {
+	Concurrency   []string      `yaml:"concurrency" usage:"Comma-separated action=max executions running at once across all workers"`
+	Rate          []string      `yaml:"rate" usage:"Comma-separated action=count/period executions started, e.g. create_employee_profile=50/1m"`
+	LeaseTTL      time.Duration `yaml:"lease_ttl" usage:"Lease of a concurrency slot, renewed while the task runs; slots of crashed workers free up when it expires"`
+	ThrottleDelay time.Duration `yaml:"throttle_delay" usage:"Longest a worker waits before requeueing a task throttled by a limit"`
+}
+
+type TenantConfig struct {
+	MaxRunningWorkflows  int      `yaml:"max_running_workflows" usage:"RUNNING workflows each tenant may have before submissions are refused, 0 for no limit"`
+	MaxQueuedTasks       int      `yaml:"max_queued_tasks" usage:"Tasks each tenant may have waiting in a task queue before submissions are refused; tasks blocked on their parents don't count, 0 for no limit"`
+	SubmissionsPerSecond int      `yaml:"submissions_per_second" usage:"Workflow submissions each tenant may make per second, 0 for no limit"`
+	Weights              []string `yaml:"weights" usage:"Comma-separated tenant=weight shares of worker dispatch; unlisted tenants weigh 1"`
+}
+
+type EventConfig struct {
+	HistoryTTL    time.Duration `yaml:"history_ttl" usage:"How long the event stream history is kept for Last-Event-ID resume"`
+	HistoryMaxLen int64         `yaml:"history_max_len" usage:"Approximate maximum events kept per workflow stream"`
+}
+
+type ResultCacheConfig struct {
+	TTL     time.Duration `yaml:"ttl" usage:"How long completed task outputs are cached"`
+	Actions []string      `yaml:"actions" usage:"Comma-separated actions whose outputs are cached"`
+}
+
+type HTTPActionConfig struct {
+	DefaultTimeout   time.Duration `yaml:"default_timeout" usage:"Timeout of http action requests whose input sets none"`
+	MaxTimeout       time.Duration `yaml:"max_timeout" usage:"Longest timeout an http action input may set"`
+	MaxResponseBytes int64         `yaml:"max_response_bytes" usage:"Largest response body the http action reads; larger responses fail the task"`
+}
+
+type ShellActionConfig struct {
+	AllowedCommands []string      `yaml:"allowed_commands" usage:"Comma-separated executables the shell action may run, names looked up in PATH or absolute paths. Empty disables the shell action"`
+	WorkDir         string        `yaml:"work_dir" usage:"Directory holding the scratch directory of each shell action run, the system temp dir if empty"`
+	DefaultTimeout  time.Duration `yaml:"default_timeout" usage:"Timeout of shell action commands whose input sets none"`
+	MaxTimeout      time.Duration `yaml:"max_timeout" usage:"Longest timeout a shell action input may set"`
+	MaxOutputBytes  int           `yaml:"max_output_bytes" usage:"Bytes of stdout and of stderr kept in the shell action output; the rest is dropped"`
+}
+
+type WebhookConfig struct {
+	Secret         string        `yaml:"secret" secret:"true" usage:"HMAC key for per-workflow callbacks"`
+	MaxAttempts    int           `yaml:"max_attempts" usage:"Delivery attempts before dead-lettering"`
+	BaseBackoff    time.Duration `yaml:"base_backoff" usage:"Delay after the first failed delivery, doubled per attempt"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" usage:"Maximum delay between delivery attempts"`
+	PollInterval   time.Duration `yaml:"poll_interval" usage:"How often due deliveries are sent"`
+	RequestTimeout time.Duration `yaml:"request_timeout" usage:"Timeout of one delivery request"`
+}
+
+type MetricsConfig struct {
+	DBPoolInterval     time.Duration `yaml:"db_pool_interval" usage:"DB connection pool metrics collection interval"`
+	QueueDepthInterval time.Duration `yaml:"queue_depth_interval" usage:"Redis queue depth metrics collection interval"`
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Store:        StorePostgres,
+			Roles:        []string{RoleAPI, RoleCoordinator, RoleWorker},
+			DrainTimeout: 30 * time.Second,
+		},
+		HTTP: HTTPConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   10 * time.Second,
+		},
+		Auth: AuthConfig{
+			TenantClaim: "tenant",
+			RolesClaim:  "roles",
+		},
+		Database: DatabaseConfig{
+			URL:            "host=localhost user=postgres password=postgres dbname=workflow_db port=5432 sslmode=disable",
+			MaxOpenConns:   50,
+			MaxIdleConns:   10,
+			MigrateOnStart: true,
+		},
+		Redis: RedisConfig{
+			Addr:         "localhost:6379",
+			PoolSize:     100,
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+		},
+		Queues: QueueConfig{
+			Backend:       QueueBackendRedis,
+			Pending:       "workflow:queue:pending",
+			Retry:         "workflow:queue:retry",
+			PollInterval:  time.Second,
+			AgingInterval: 30 * time.Second,
+		},
+		Coordinator: CoordinatorConfig{
+			LeaderLockTTL: 15 * time.Second,
+		},
+		Workers: WorkerConfig{
+			MainConcurrency:  9,
+			RetryConcurrency: 1,
+			ProgressInterval: time.Second,
+		},
+		External: ExternalConfig{
+			LeaseTTL:     time.Minute,
+			MaxPollWait:  30 * time.Second,
+			ReapInterval: 10 * time.Second,
+		},
+		Events: EventConfig{
+			HistoryTTL:    24 * time.Hour,
+			HistoryMaxLen: 1000,
+		},
+		Limits: LimitConfig{
+			LeaseTTL:      time.Minute,
+			ThrottleDelay: time.Second,
+		},
+		ResultCache: ResultCacheConfig{
+			TTL:     24 * time.Hour,
+			Actions: []string{"create_employee_profile", "setup_email_account"},
+		},
+		HTTPAction: HTTPActionConfig{
+			DefaultTimeout:   30 * time.Second,
+			MaxTimeout:       5 * time.Minute,
+			MaxResponseBytes: 1 << 20,
+		},
+		ShellAction: ShellActionConfig{
+			DefaultTimeout: time.Minute,
+			MaxTimeout:     time.Hour,
+			MaxOutputBytes: 64 << 10,
+		},
+		Webhooks: WebhookConfig{
+			MaxAttempts:    8,
+			BaseBackoff:    5 * time.Second,
+			MaxBackoff:     time.Hour,
+			PollInterval:   time.Second,
+			RequestTimeout: 10 * time.Second,
+		},
+		Metrics: MetricsConfig{
+			DBPoolInterval:     10 * time.Second,
+			QueueDepthInterval: 10 * time.Second,
+		},
+	}
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(len(c.Server.Roles) > 0, "server.roles must name at least one role")
+	seen := make(map[string]bool)
+	for _, role := range c.Server.Roles {
+		check(role == RoleAPI || role == RoleCoordinator || role == RoleWorker,
+			"server.roles: unknown role %q (want api, coordinator or worker)", role)
+		check(!seen[role], "server.roles: duplicate role %q", role)
+		seen[role] = true
+	}
+
+	if c.Embedded() {
+		store := StoreMemory
+		if path, ok := c.SQLitePath(); ok {
+			store = "sqlite"
+			check(path != "", "server.store: sqlite needs a path, e.g. sqlite:tempo.db")
+		}
+		// The in-process queue and event bus only connect roles within one process
+		check(c.HasRole(RoleAPI) && c.HasRole(RoleCoordinator) && c.HasRole(RoleWorker),
+			"server.store: %s runs every role in one process, server.roles must include api, coordinator and worker", store)
+		check(c.Queues.Backend != QueueBackendPostgres, "queues.backend: postgres needs server.store postgres")
+	} else {
+		check(c.Server.Store == StorePostgres, "server.store must be postgres, memory or sqlite:<path>, got %q", c.Server.Store)
+	}
+	check(c.Server.DrainTimeout >= 0, "server.drain_timeout must not be negative")
+
+	check(c.HTTP.Addr != "", "http.addr must be set")
+	check(c.HTTP.ReadHeaderTimeout > 0, "http.read_header_timeout must be positive")
+	check(c.HTTP.ReadTimeout >= 0, "http.read_timeout must not be negative")
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout must not be negative")
+	check(c.HTTP.ShutdownTimeout >= 0, "http.shutdown_timeout must not be negative")
+	check(c.GRPC.Addr == "" || c.GRPC.Addr != c.HTTP.Addr, "grpc.addr must differ from http.addr")
+
+	check(c.Auth.JWKSFile == "" || (c.Auth.TenantClaim != "" && c.Auth.RolesClaim != ""),
+		"auth.tenant_claim and auth.roles_claim must be set with auth.jwks_file")
+
+	check(c.Database.URL != "", "database.url must be set")
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns must be positive")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "database.max_idle_conns must not exceed database.max_open_conns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time must not be negative")
+
+	check(c.Redis.Addr != "", "redis.addr must be set")
+	check(c.Redis.DB >= 0, "redis.db must not be negative")
+	check(c.Redis.PoolSize > 0, "redis.pool_size must be positive")
+	check(c.Redis.DialTimeout > 0, "redis.dial_timeout must be positive")
+	check(c.Redis.ReadTimeout > 0, "redis.read_timeout must be positive")
+	check(c.Redis.WriteTimeout > 0, "redis.write_timeout must be positive")
+
+	check(c.Queues.Pending != "", "queues.pending must be set")
+	check(c.Queues.Retry != "", "queues.retry must be set")
+	check(c.Queues.Pending != c.Queues.Retry, "queues.pending and queues.retry must differ")
+	check(c.Queues.Backend == QueueBackendRedis || c.Queues.Backend == QueueBackendPostgres,
+		"queues.backend must be redis or postgres, got %q", c.Queues.Backend)
+	check(c.Queues.PollInterval > 0, "queues.poll_interval must be positive")
+	check(c.Queues.AgingInterval > 0, "queues.aging_interval must be positive")
+
+	check(c.Coordinator.LeaderLockTTL >= time.Second, "coordinator.leader_lock_ttl must be at least 1s")
+
+	check(c.Workers.MainConcurrency > 0, "workers.main_concurrency must be positive")
+	check(c.Workers.RetryConcurrency > 0, "workers.retry_concurrency must be positive")
+	check(c.Workers.ProgressInterval > 0, "workers.progress_interval must be positive")
+	for _, route := range c.Queues.Routes {
+		action, taskQueue, ok := strings.Cut(route, "=")
+		check(ok && action != "" && taskQueue != "", "queues.routes: %q is not action=task_queue", route)
+	}
+	for _, entry := range c.Workers.QueueConcurrency {
+		taskQueue, value, _ := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(value)
+		check(taskQueue != "" && err == nil && n > 0, "workers.queue_concurrency: %q is not task_queue=positive number", entry)
+	}
+
+	for _, entry := range c.Limits.Concurrency {
+		action, value, _ := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(value)
+		check(action != "" && err == nil && n > 0, "limits.concurrency: %q is not action=positive number", entry)
+	}
+	for _, entry := range c.Limits.Rate {
+		action, value, _ := strings.Cut(entry, "=")
+		_, _, err := parseRate(value)
+		check(action != "" && err == nil, "limits.rate: %q is not action=count/period, e.g. send_email=50/1m", entry)
+	}
+	check(c.External.LeaseTTL > 0, "external_workers.lease_ttl must be positive")
+	check(c.External.MaxPollWait > 0, "external_workers.max_poll_wait must be positive")
+	check(c.External.ReapInterval > 0, "external_workers.reap_interval must be positive")
+
+	check(c.Limits.LeaseTTL > 0, "limits.lease_ttl must be positive")
+	check(c.Limits.ThrottleDelay > 0, "limits.throttle_delay must be positive")
+
+	check(c.Tenants.MaxRunningWorkflows >= 0, "tenants.max_running_workflows must not be negative")
+	check(c.Tenants.MaxQueuedTasks >= 0, "tenants.max_queued_tasks must not be negative")
+	check(c.Tenants.SubmissionsPerSecond >= 0, "tenants.submissions_per_second must not be negative")
+	for _, entry := range c.Tenants.Weights {
+		tenant, value, _ := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(value)
+		check(domain.ValidTenant(tenant) && err == nil && n > 0, "tenants.weights: %q is not tenant=positive number", entry)
+	}
+
+	check(c.Events.HistoryTTL > 0, "events.history_ttl must be positive")
+	check(c.Events.HistoryMaxLen > 0, "events.history_max_len must be positive")
+
+	check(c.ResultCache.TTL > 0, "result_cache.ttl must be positive")
+
+	check(c.HTTPAction.DefaultTimeout > 0, "http_action.default_timeout must be positive")
+	check(c.HTTPAction.MaxTimeout >= c.HTTPAction.DefaultTimeout, "http_action.max_timeout must be at least http_action.default_timeout")
+	check(c.HTTPAction.MaxResponseBytes > 0, "http_action.max_response_bytes must be positive")
+
+	for _, command := range c.ShellAction.AllowedCommands {
+		check(command != "", "shell_action.allowed_commands must not contain empty names")
+	}
+	check(c.ShellAction.DefaultTimeout > 0, "shell_action.default_timeout must be positive")
+	check(c.ShellAction.MaxTimeout >= c.ShellAction.DefaultTimeout, "shell_action.max_timeout must be at least shell_action.default_timeout")
+	check(c.ShellAction.MaxOutputBytes > 0, "shell_action.max_output_bytes must be positive")
+
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts must be positive")
+	check(c.Webhooks.BaseBackoff > 0, "webhooks.base_backoff must be positive")
+	check(c.Webhooks.MaxBackoff >= c.Webhooks.BaseBackoff, "webhooks.max_backoff must be at least webhooks.base_backoff")
+	check(c.Webhooks.PollInterval > 0, "webhooks.poll_interval must be positive")
+	check(c.Webhooks.RequestTimeout > 0, "webhooks.request_timeout must be positive")
+
+	check(c.Metrics.DBPoolInterval > 0, "metrics.db_pool_interval must be positive")
+	check(c.Metrics.QueueDepthInterval > 0, "metrics.queue_depth_interval must be positive")
+
+	return errors.Join(errs...)
+}
@@ -6,36 +6,129 @@ import (
 	"go-tempo/internal/domain"
 	"go-tempo/internal/metrics"
 	"log"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// leaderLockName is the lock only the coordinator handling task events holds
+const leaderLockName = "coordinator"
+
 type Coordinator struct {
 	taskRepo    ports.TaskRepository
 	workflowRepo ports.WorkflowRepository
-	queue        ports.TaskQueue
+	queues       ports.TaskQueues
 	eventBus     ports.EventBus
+	notifier     ports.WorkflowNotifier // Optional, nil disables webhooks
+	running      atomic.Bool            // True while the event loop is subscribed, or standing by for the leader lock
+
+	// Every coordinator receives every task event, so only the holder of the leader lock handles them
+	leaderLock ports.LeaderLock // Optional, nil handles events without a lock
+	lockTTL    time.Duration
 }
 
 func NewCoordinator(
 	taskRepo ports.TaskRepository,
 	workflowRepo ports.WorkflowRepository,
-	queue ports.TaskQueue,
+	queues ports.TaskQueues,
 	bus ports.EventBus,
 ) *Coordinator {
 	return &Coordinator{
 		taskRepo:     taskRepo,
 		workflowRepo: workflowRepo,
-		queue:        queue,
+		queues:       queues,
 		eventBus:     bus,
 	}
 }
 
+// UseNotifier forwards task and workflow state changes to the notifier (e.g. webhooks)
+func (c *Coordinator) UseNotifier(notifier ports.WorkflowNotifier) {
+	c.notifier = notifier
+}
+
+// UseLeaderLock makes the coordinator handle events only while it holds the leader lock, so
+// several coordinators can run with one active and the others standing by. A standby takes
+// over within ttl of the leader dying.
+func (c *Coordinator) UseLeaderLock(lock ports.LeaderLock, ttl time.Duration) {
+	c.leaderLock = lock
+	c.lockTTL = ttl
+}
+
 // Start begins the infinite listening loop. Call this in main.go as a goroutine.
+// With a leader lock it stands by until it holds the lock, and handles events until it's lost.
 func (c *Coordinator) Start(ctx context.Context) {
 	log.Println("Coordinator started, listening for events...")
 
+	if c.leaderLock == nil {
+		c.handleEvents(ctx)
+		log.Println("Coordinator shutting down...")
+		return
+	}
+
+	c.running.Store(true)
+	defer c.running.Store(false)
+
+	holder := uuid.NewString()
+	for {
+		acquired, err := c.leaderLock.Acquire(ctx, leaderLockName, holder, c.lockTTL)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Coordinator failed to acquire the leader lock: %v", err)
+		}
+		if acquired {
+			c.lead(ctx, holder)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Coordinator shutting down...")
+			return
+		case <-time.After(c.lockTTL / 3):
+		}
+	}
+}
+
+// lead handles events while the leader lock is renewed, and releases it when ctx is canceled
+func (c *Coordinator) lead(ctx context.Context, holder string) {
+	log.Printf("Coordinator %s acquired the leader lock", holder)
+	metrics.CoordinatorLeader.Set(1)
+	defer metrics.CoordinatorLeader.Set(0)
+
+	leaderCtx, resign := context.WithCancel(ctx)
+	defer resign()
+	go func() {
+		defer resign()
+		ticker := time.NewTicker(c.lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-leaderCtx.Done():
+				return
+			case <-ticker.C:
+				renewed, err := c.leaderLock.Renew(leaderCtx, leaderLockName, holder, c.lockTTL)
+				if err != nil && leaderCtx.Err() != nil {
+					return
+				}
+				if err != nil || !renewed {
+					log.Printf("Coordinator %s lost the leader lock (%v), standing by", holder, err)
+					return
+				}
+			}
+		}
+	}()
+
+	c.handleEvents(leaderCtx)
+
+	if ctx.Err() != nil {
+		if err := c.leaderLock.Release(context.WithoutCancel(ctx), leaderLockName, holder); err != nil {
+			log.Printf("Coordinator failed to release the leader lock: %v", err)
+		}
+	}
+}
+
+// handleEvents subscribes to task events and handles them until ctx is canceled
+func (c *Coordinator) handleEvents(ctx context.Context) {
 	// Subscribe returns a Go channel that receives messages from Redis
 	eventChannel, err := c.eventBus.SubscribeToEvents(ctx)
 	if err != nil {
@@ -48,28 +141,48 @@ func (c *Coordinator) Start(ctx context.Context) {
 		log.Fatalf("Failed to subscribe to termination events: %v", err)
 	}
 
+	if c.leaderLock == nil {
+		c.running.Store(true)
+		defer c.running.Store(false)
+	}
+
+	// An event being handled when ctx is canceled still completes its DAG updates
+	handleCtx := context.WithoutCancel(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Coordinator shutting down...")
 			return
 
-		case event := <-eventChannel:
+		case event, ok := <-eventChannel:
+			if !ok {
+				return
+			}
 			// Track completed event metric
 			metrics.CoordinatorEventsProcessedTotal.WithLabelValues("completed").Inc()
-			c.handleTaskCompleted(ctx, event)
+			c.handleTaskCompleted(handleCtx, event)
 
-		case event := <-terminationChannel:
+		case event, ok := <-terminationChannel:
+			if !ok {
+				return
+			}
 			// Track terminated event metric
 			metrics.CoordinatorEventsProcessedTotal.WithLabelValues("terminated").Inc()
-			c.handleTaskTerminated(ctx, event)
+			c.handleTaskTerminated(handleCtx, event)
 		}
 	}
 }
 
+// Running reports whether the event loop is active or standing by for the leader lock
+// (used by health checks)
+func (c *Coordinator) Running() bool {
+	return c.running.Load()
+}
+
 // handleTaskCompleted executes Kahn's Algorithm
 func (c *Coordinator) handleTaskCompleted(ctx context.Context, event domain.TaskCompletedEvent) {
 	log.Printf("Coordinator: Task %s (%s) completed. Checking children...", event.RefID, event.TaskID)
+	c.notify(ctx, event.ToWorkflowEvent())
 
 	// Track DAG resolution time
 	start := time.Now()
@@ -88,10 +201,10 @@ func (c *Coordinator) handleTaskCompleted(ctx context.Context, event domain.Task
 	for _, taskID := range readyTaskIDs {
 		log.Printf("Coordinator: Task %s is now unblocked! Queuing...", taskID)
 
-		err := c.queue.Push(ctx, taskID.String())
-		if err != nil {
+		if err := c.queueTask(ctx, taskID); err != nil {
 			log.Printf("Failed to push task %s to queue: %v\n", taskID, err)
 			// Note: In production, you would add a retry mechanism here
+			continue
 		}
 	}
 
@@ -119,30 +232,113 @@ func (c *Coordinator) checkIfWorkflowFinished(ctx context.Context, executionID u
 	}
 
 	if !allCompleted {
-		log.Printf("Workflow %s still has tasks in progress", executionID)
+		c.checkIfWorkflowFailed(ctx, executionID)
 		return
 	}
 
 	log.Printf("All tasks completed for workflow %s. Marking as COMPLETED...", executionID)
 
-	// UpdateStatus is idempotent - it only updates if status is different.
-	// This prevents duplicate logs when multiple terminal tasks complete simultaneously.
-	err = c.workflowRepo.UpdateStatus(ctx, executionID, string(domain.WorkflowCompleted))
-	if err != nil {
+	// UpdateStatus only affects a RUNNING workflow, so of several terminal tasks completing
+	// simultaneously only one sees it updated. A workflow cancelled while its last tasks ran
+	// stays CANCELLED and is announced as such below.
+	if _, err := c.workflowRepo.UpdateStatus(ctx, executionID, string(domain.WorkflowCompleted)); err != nil {
 		log.Printf("Failed to mark workflow %s as completed: %v\n", executionID, err)
 		return
 	}
 
-	log.Printf("Workflow %s marked as COMPLETED", executionID)
-	
-	// Track workflow completion metric
-	metrics.CoordinatorWorkflowCompletionsTotal.WithLabelValues("completed").Inc()
+	if status, announced := c.announceFinalStatus(ctx, executionID); announced {
+		log.Printf("Workflow %s finished as %s", executionID, status)
+	}
+}
+
+// checkIfWorkflowFailed announces the final FAILED or CANCELLED status once the last task of
+// a failed or cancelled workflow has terminated. The status itself is already set by the
+// worker or the cancel request.
+func (c *Coordinator) checkIfWorkflowFailed(ctx context.Context, executionID uuid.UUID) {
+	allTerminal, err := c.taskRepo.AreAllTasksTerminal(ctx, executionID)
+	if err != nil {
+		log.Printf("Failed to check workflow %s terminal status: %v\n", executionID, err)
+		return
+	}
+
+	if !allTerminal {
+		log.Printf("Workflow %s still has tasks in progress", executionID)
+		return
+	}
+
+	log.Printf("All tasks terminated for failed workflow %s", executionID)
+	c.announceFinalStatus(ctx, executionID)
+}
+
+// announceFinalStatus publishes the final event of a workflow whose tasks all finished, with
+// the status stored for it. MarkFinished lets only the first of several racing callers announce;
+// the others return false.
+func (c *Coordinator) announceFinalStatus(ctx context.Context, executionID uuid.UUID) (domain.WorkflowStatus, bool) {
+	finished, err := c.workflowRepo.MarkFinished(ctx, executionID)
+	if err != nil {
+		log.Printf("Failed to mark workflow %s as finished: %v\n", executionID, err)
+		return "", false
+	}
+	if !finished {
+		return "", false
+	}
+
+	execution, err := c.workflowRepo.GetByID(ctx, executionID)
+	if err != nil {
+		log.Printf("Failed to load workflow %s final status: %v\n", executionID, err)
+		return "", false
+	}
+
+	status := execution.Status
+	if !execution.IsFinished() {
+		log.Printf("Workflow %s finished its tasks but is still %s\n", executionID, status)
+		return "", false
+	}
+
+	// Track workflow completion metric, once per workflow: completed, failed or cancelled
+	metrics.CoordinatorWorkflowCompletionsTotal.WithLabelValues(strings.ToLower(string(status))).Inc()
+	c.publishWorkflowEvent(ctx, domain.NewWorkflowStatusEvent(executionID, status))
+	return status, true
+}
+
+// queueTask pushes an unblocked task to its task queue at its priority and emits its
+// task.queued lifecycle event
+func (c *Coordinator) queueTask(ctx context.Context, taskID uuid.UUID) error {
+	task, err := c.taskRepo.FindTaskByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.queues.Main(task.TaskQueue).Push(ctx, task); err != nil {
+		return err
+	}
+	c.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskQueued, task, ""))
+	return nil
+}
+
+// publishWorkflowEvent records a lifecycle event for event stream subscribers and the notifier
+func (c *Coordinator) publishWorkflowEvent(ctx context.Context, event domain.WorkflowEvent) {
+	if _, err := c.eventBus.PublishWorkflowEvent(ctx, event); err != nil {
+		log.Printf("Failed to publish %s event for workflow %s: %v\n", event.Type, event.ExecutionID, err)
+	}
+	c.notify(ctx, event)
+}
+
+// notify forwards a state change to the notifier, if one is configured
+func (c *Coordinator) notify(ctx context.Context, event domain.WorkflowEvent) {
+	if c.notifier == nil {
+		return
+	}
+	if err := c.notifier.Notify(ctx, event); err != nil {
+		log.Printf("Failed to notify %s event for workflow %s: %v\n", event.Type, event.ExecutionID, err)
+	}
 }
 
 // handleTaskTerminated propagates skip hints to child tasks when a task is terminated (failed or skipped)
 func (c *Coordinator) handleTaskTerminated(ctx context.Context, event domain.TaskTerminatedEvent) {
 	log.Printf("Coordinator: Task %s (%s) terminated with type '%s': %s. Propagating skip hint...", 
 		event.RefID, event.TaskID, event.Type, event.Error)
+	c.notify(ctx, event.ToWorkflowEvent())
 
 	// Track DAG resolution time
 	start := time.Now()
@@ -161,9 +357,9 @@ func (c *Coordinator) handleTaskTerminated(ctx context.Context, event domain.Tas
 	for _, taskID := range readyTaskIDs {
 		log.Printf("Coordinator: Task %s is now unblocked (will be skipped). Queuing...", taskID)
 
-		err := c.queue.Push(ctx, taskID.String())
-		if err != nil {
+		if err := c.queueTask(ctx, taskID); err != nil {
 			log.Printf("Failed to push task %s to queue: %v\n", taskID, err)
+			continue
 		}
 	}
 
@@ -174,11 +370,10 @@ func (c *Coordinator) handleTaskTerminated(ctx context.Context, event domain.Tas
 			metrics.CoordinatorTasksUnblockedTotal.Inc()
 		}
 	}
-	
-	// Track failed workflow metric if this is a failed task (not skipped)
-	if event.Type == domain.TaskTerminationFailed {
-		metrics.CoordinatorWorkflowCompletionsTotal.WithLabelValues("failed").Inc()
-	}
 
-	// No workflow completion check - workflow is already marked FAILED by worker for failed tasks
+	// The workflow is already marked FAILED by the worker; once the last task terminates,
+	// announce the final status to event stream subscribers.
+	if len(readyTaskIDs) == 0 {
+		c.checkIfWorkflowFailed(ctx, event.ExecutionID)
+	}
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"go-tempo/internal/domain"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
@@ -10,11 +11,70 @@ import (
 
 // TaskQueue represents the task queue operations
 type TaskQueue interface {
-	// Push a Task UUID to the "To-Do" list
-	Push(ctx context.Context, taskID string) error
+	// Push a Task UUID to the "To-Do" list of its tenant at the task's priority
+	Push(ctx context.Context, task *domain.Task) error
 
-	// Wait (Block) until a Task UUID is available
+	// Wait (Block) until a Task UUID is available. Tenants take weighted fair turns; within a
+	// tenant higher priorities come first, but every waiting task gains a level per aging
+	// interval so low priorities aren't starved.
 	Pop(ctx context.Context) (string, error)
+
+	// Count queued Task UUIDs per priority
+	DepthByPriority(ctx context.Context) (map[int]int64, error)
+}
+
+// ClaimingTaskQueue is a TaskQueue that claims the task it pops for the popping worker in the
+// same statement, so the worker needs no separate ClaimTask
+type ClaimingTaskQueue interface {
+	TaskQueue
+
+	// Wait (Block) until a Task is available and return it RUNNING on workerID
+	PopClaimed(ctx context.Context, workerID string) (*domain.Task, error)
+}
+
+// TaskQueues resolves named task queues to the TaskQueues their tasks are pushed to
+type TaskQueues interface {
+	// Task queue of an action when the task doesn't name one
+	Route(action string) string
+
+	// Queue of new and unblocked tasks of a task queue
+	Main(taskQueue string) TaskQueue
+
+	// Queue of retried tasks of a task queue
+	Retry(taskQueue string) TaskQueue
+}
+
+// ActionLimiter enforces per-action concurrency and rate limits across the cluster
+type ActionLimiter interface {
+	// Take a concurrency slot and a rate token for the task. When a limit is reached nothing is
+	// taken, and the limit kind is returned with how long to wait before trying again (0 if unknown).
+	Acquire(ctx context.Context, action string, taskID string) (throttledBy string, retryAfter time.Duration, err error)
+
+	// Extend the lease of a held slot; slots of crashed workers are freed when their lease expires
+	Renew(ctx context.Context, action string, taskID string) error
+
+	// Free the slot held for the task
+	Release(ctx context.Context, action string, taskID string) error
+}
+
+// RateLimiter is a token bucket per key shared across the cluster
+type RateLimiter interface {
+	// Take a token from the key's bucket, which holds rate tokens refilled over period. When the
+	// bucket is empty nothing is taken and the wait until the next token is returned.
+	Allow(ctx context.Context, key string, rate int, period time.Duration) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// LeaderLock elects a single holder of a named lock across the cluster. Locks expire after their
+// TTL unless renewed, so a crashed holder is replaced.
+type LeaderLock interface {
+	// Take the lock for holder; false if another holder has it
+	Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error)
+
+	// Extend the lock; false if holder no longer has it
+	Renew(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error)
+
+	// Free the lock if holder has it
+	Release(ctx context.Context, name string, holder string) error
 }
 
 // EventBus represents the event bus operations
@@ -30,6 +90,16 @@ type EventBus interface {
 
 	// Subscribe to termination events (failed/skipped) (Used by Coordinator)
 	SubscribeToTerminationEvents(ctx context.Context) (<-chan domain.TaskTerminatedEvent, error)
+
+	// Record a lifecycle event in the execution's history and broadcast it.
+	// Returns the ID assigned to the event.
+	PublishWorkflowEvent(ctx context.Context, event domain.WorkflowEvent) (string, error)
+
+	// Subscribe to live lifecycle events of a single execution (Used by the event stream API)
+	SubscribeToWorkflowEvents(ctx context.Context, executionID uuid.UUID) (<-chan domain.WorkflowEvent, error)
+
+	// Replay the recorded lifecycle events published after lastEventID ("" replays everything)
+	GetWorkflowEventsSince(ctx context.Context, executionID uuid.UUID, lastEventID string) ([]domain.WorkflowEvent, error)
 }
 
 // TaskRepository represents the task repository operations
@@ -51,12 +121,17 @@ type TaskRepository interface {
 
 	// 6. Update Final Status
 	MarkCompleted(ctx context.Context, taskID uuid.UUID, output datatypes.JSON) error
-	MarkFailed(ctx context.Context, taskID uuid.UUID, errMessage string) error
-	MarkSkipped(ctx context.Context, taskID uuid.UUID) error
+	// output is what the handler returned alongside the error, nil if nothing
+	MarkFailed(ctx context.Context, taskID uuid.UUID, errMessage string, output datatypes.JSON) error
+	MarkSkipped(ctx context.Context, taskID uuid.UUID, reason string) error
+
+	// Records the latest progress of a RUNNING task without bumping its version
+	UpdateProgress(ctx context.Context, taskID uuid.UUID, progress domain.TaskProgress) error
 
 	// 7. Retry Management
-	// Increments retry_count and resets status to PENDING using optimistic locking
-	IncrementRetryCount(ctx context.Context, taskID uuid.UUID, currentVersion int) error
+	// Increments retry_count and resets status to PENDING using optimistic locking. nextAttemptAt
+	// delays the retry until then; nil retries at once
+	IncrementRetryCount(ctx context.Context, taskID uuid.UUID, currentVersion int, nextAttemptAt *time.Time) error
 
 	// 8. Decrement in-degree and get ready tasks
 	// Decrements in_degree for all tasks dependent on completedRefID and returns IDs of tasks that became ready
@@ -67,8 +142,51 @@ type TaskRepository interface {
 	DecrementAndSetSkipHint(ctx context.Context, executionID uuid.UUID, failedRefID string) ([]uuid.UUID, error)
 
 	// 10. Check if all tasks in a workflow execution are completed
-	// Returns true if all tasks have status COMPLETED, false otherwise
+	// Returns true if all tasks have status COMPLETED or SKIPPED, false otherwise. A workflow
+	// with a FAILED task is never complete, so SKIPPED tasks there are those its handlers skipped.
 	AreAllTasksCompleted(ctx context.Context, executionID uuid.UUID) (bool, error)
+
+	// 11. Check if every task in a workflow execution reached a terminal status
+	// Returns true if all tasks are COMPLETED, FAILED or SKIPPED
+	AreAllTasksTerminal(ctx context.Context, executionID uuid.UUID) (bool, error)
+
+	// 12. Hand a claimed task back (used when a worker shuts down mid-execution)
+	// Resets status to QUEUED and clears worker_id using optimistic locking; the attempt is not counted
+	ReleaseTask(ctx context.Context, taskID uuid.UUID, currentVersion int) error
+
+	// 13. Count the tasks waiting in a task queue, like FindWaitingTasks (used for tenant quotas).
+	// PENDING tasks blocked on their parents aren't counted
+	CountWaitingTasks(ctx context.Context) (int64, error)
+
+	// 14. Lease a RUNNING task to an external worker until expiresAt; only the token's hash is stored
+	SetLease(ctx context.Context, taskID uuid.UUID, currentVersion int, tokenHash string, expiresAt time.Time) error
+
+	// 15. Extend a lease still held with the token hash
+	RenewLease(ctx context.Context, taskID uuid.UUID, tokenHash string, expiresAt time.Time) error
+
+	// 16. End a lease held with the token hash. Returns gorm.ErrRecordNotFound when the task is no
+	// longer RUNNING under that lease, e.g. because it expired and was reclaimed
+	EndLease(ctx context.Context, taskID uuid.UUID, tokenHash string) error
+
+	// 17. Find RUNNING tasks whose lease lapsed before now
+	FindExpiredLeases(ctx context.Context, now time.Time, limit int) ([]domain.Task, error)
+
+	// 18. Cancel a RUNNING workflow execution and set skip_hint on its PENDING and QUEUED tasks in
+	// one transaction, so they are skipped instead of run. Returns gorm.ErrRecordNotFound when no
+	// RUNNING execution matched
+	CancelExecution(ctx context.Context, executionID uuid.UUID) error
+
+	// 19. List the tasks of a workflow execution
+	FindTasksByExecution(ctx context.Context, executionID uuid.UUID) ([]domain.Task, error)
+
+	// 20. List the tasks a task queue should hold, oldest first: QUEUED tasks, and PENDING tasks
+	// with no unfinished parents, which are root tasks and retries. Used to refill in-process queues
+	FindWaitingTasks(ctx context.Context) ([]domain.Task, error)
+
+	// 21. Delete a workflow execution with its tasks, history and webhook deliveries. Used to undo
+	// a submission whose root tasks couldn't be queued. Returns gorm.ErrRecordNotFound when no
+	// execution matched
+	DeleteExecution(ctx context.Context, executionID uuid.UUID) error
 }
 
 // WorkflowRepository represents the workflow repository operations
@@ -79,6 +197,60 @@ type WorkflowRepository interface {
 	// Get the current status (Is Alice's onboarding done yet?)
 	GetByID(ctx context.Context, executionID uuid.UUID) (*domain.WorkflowExecution, error)
 
-	// Update status (e.g., mark as COMPLETED when all tasks are done)
-	UpdateStatus(ctx context.Context, executionID uuid.UUID, status string) error
+	// Get the execution submitted with the idempotency key (gorm.ErrRecordNotFound if none)
+	GetByIdempotencyKey(ctx context.Context, key string) (*domain.WorkflowExecution, error)
+
+	// Update status (e.g., mark as COMPLETED when all tasks are done). COMPLETED, FAILED and
+	// CANCELLED are final; returns false when the execution already had a final status
+	UpdateStatus(ctx context.Context, executionID uuid.UUID, status string) (bool, error)
+
+	// Record that the last task of an execution finished. Returns true only for the first call,
+	// so the final status is announced once however many terminal tasks race
+	MarkFinished(ctx context.Context, executionID uuid.UUID) (bool, error)
+
+	// Search executions newest first, up to filter.Limit rows after filter.After
+	List(ctx context.Context, filter domain.WorkflowFilter) ([]domain.WorkflowExecution, error)
+
+	// Count executions matching the filter per status (Status, After and Limit are ignored)
+	CountByStatus(ctx context.Context, filter domain.WorkflowFilter) (map[domain.WorkflowStatus]int64, error)
+}
+
+// ResultCache stores completed task outputs keyed by idempotency key
+type ResultCache interface {
+	// Get returns the cached output for key, and false if nothing is stored
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Put stores the output of a completed task under key
+	Put(ctx context.Context, key string, output []byte) error
+}
+
+// WorkflowNotifier forwards lifecycle events to external receivers (Used by Coordinator)
+type WorkflowNotifier interface {
+	Notify(ctx context.Context, event domain.WorkflowEvent) error
+}
+
+// WebhookRepository represents webhook subscription and delivery log operations
+type WebhookRepository interface {
+	// Global subscriptions
+	CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+
+	// Delivery log
+	CreateDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error)
+
+	// Claim up to limit PENDING deliveries that are due, hiding them from other
+	// dispatchers for the lease duration
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+}
+
+// WorkflowEventRepository represents the append-only execution history
+type WorkflowEventRepository interface {
+	// Append a lifecycle event; records are never updated or deleted
+	Append(ctx context.Context, record *domain.WorkflowEventRecord) error
+
+	// List the history of one execution in the order it happened
+	ListByExecution(ctx context.Context, executionID uuid.UUID) ([]domain.WorkflowEventRecord, error)
 }
@@ -0,0 +1,992 @@
+// Package portstest is the conformance suite every repository adapter must pass. Adapters run it
+// from their own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		portstest.Run(t, func(t *testing.T) portstest.Repositories { ... })
+//	}
+package portstest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Repositories are the adapters under test, sharing one store
+type Repositories struct {
+	Tasks     ports.TaskRepository
+	Workflows ports.WorkflowRepository
+	Webhooks  ports.WebhookRepository
+	History   ports.WorkflowEventRepository
+}
+
+// Run runs the suite against the repositories open returns. Every test works in a tenant of its
+// own, so the store may be shared between tests and need not start empty.
+func Run(t *testing.T, open func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, repos Repositories)
+	}{
+		{"CreateExecution", testCreateExecution},
+		{"ClaimTask", testClaimTask},
+		{"IncrementRetryCount", testIncrementRetryCount},
+		{"ReleaseTask", testReleaseTask},
+		{"MarkFinalStatus", testMarkFinalStatus},
+		{"UpdateProgress", testUpdateProgress},
+		{"DecrementAndGetReadyTasks", testDecrementAndGetReadyTasks},
+		{"DecrementAndSetSkipHint", testDecrementAndSetSkipHint},
+		{"AreAllTasks", testAreAllTasks},
+		{"WaitingTasks", testWaitingTasks},
+		{"Leases", testLeases},
+		{"CancelExecution", testCancelExecution},
+		{"DeleteExecution", testDeleteExecution},
+		{"UpdateStatus", testUpdateStatus},
+		{"MarkFinished", testMarkFinished},
+		{"IdempotencyKey", testIdempotencyKey},
+		{"ListWorkflows", testListWorkflows},
+		{"CountByStatus", testCountByStatus},
+		{"TenantIsolation", testTenantIsolation},
+		{"Webhooks", testWebhooks},
+		{"History", testHistory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := domain.WithTenant(context.Background(), "conformance-"+uuid.NewString())
+			tt.test(t, ctx, open(t))
+		})
+	}
+}
+
+// taskSpec describes a task of a test workflow by ref_id and dependencies
+type taskSpec struct {
+	refID string
+	deps  []string
+}
+
+// createWorkflow stores a workflow of the ctx tenant with one task per spec, and returns the
+// execution and its tasks by ref_id
+func createWorkflow(t *testing.T, ctx context.Context, repos Repositories, specs ...taskSpec) (*domain.WorkflowExecution, map[string]*domain.Task) {
+	t.Helper()
+	tenant, _ := domain.TenantFromContext(ctx)
+	execution := domain.NewWorkflow(uuid.New(), "conformance")
+	execution.Tenant = tenant
+
+	tasks := make([]domain.Task, 0, len(specs))
+	for _, spec := range specs {
+		task := domain.NewTask(execution.ID, spec.refID, "action_"+spec.refID)
+		task.Tenant = tenant
+		deps := "[]"
+		if len(spec.deps) > 0 {
+			deps = `["` + spec.deps[0]
+			for _, dep := range spec.deps[1:] {
+				deps += `","` + dep
+			}
+			deps += `"]`
+		}
+		task.Dependencies = datatypes.JSON(deps)
+		task.InDegree = len(spec.deps)
+		task.Input = datatypes.JSON(`{}`)
+		tasks = append(tasks, *task)
+	}
+	if err := repos.Tasks.CreateExecution(ctx, execution, tasks); err != nil {
+		t.Fatalf("CreateExecution: %v", err)
+	}
+
+	byRef := make(map[string]*domain.Task, len(tasks))
+	for i := range tasks {
+		byRef[tasks[i].RefID] = &tasks[i]
+	}
+	return execution, byRef
+}
+
+// findTask reloads a task, failing the test if it can't be found
+func findTask(t *testing.T, ctx context.Context, repos Repositories, id uuid.UUID) *domain.Task {
+	t.Helper()
+	task, err := repos.Tasks.FindTaskByID(ctx, id)
+	if err != nil {
+		t.Fatalf("FindTaskByID(%s): %v", id, err)
+	}
+	return task
+}
+
+// getWorkflow reloads an execution, failing the test if it can't be found
+func getWorkflow(t *testing.T, ctx context.Context, repos Repositories, id uuid.UUID) *domain.WorkflowExecution {
+	t.Helper()
+	execution, err := repos.Workflows.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID(%s): %v", id, err)
+	}
+	return execution
+}
+
+func wantNotFound(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("%s = %v, want gorm.ErrRecordNotFound", op, err)
+	}
+}
+
+func sortedIDs(ids []uuid.UUID) []string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	sort.Strings(s)
+	return s
+}
+
+func sameIDs(got []uuid.UUID, want ...uuid.UUID) bool {
+	g, w := sortedIDs(got), sortedIDs(want)
+	if len(g) != len(w) {
+		return false
+	}
+	for i := range g {
+		if g[i] != w[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testCreateExecution(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"}, taskSpec{refID: "b", deps: []string{"a"}})
+
+	stored := getWorkflow(t, ctx, repos, execution.ID)
+	if stored.Status != domain.WorkflowRunning || stored.WorkflowType != "conformance" || stored.FinishedAt != nil {
+		t.Errorf("stored execution = %s %q finished %v, want RUNNING conformance unfinished",
+			stored.Status, stored.WorkflowType, stored.FinishedAt)
+	}
+
+	task := findTask(t, ctx, repos, tasks["b"].ID)
+	if task.Status != domain.StatusPending || task.Version != 1 || task.InDegree != 1 || task.Action != "action_b" {
+		t.Errorf("stored task = %s v%d in-degree %d %q, want PENDING v1 in-degree 1 action_b",
+			task.Status, task.Version, task.InDegree, task.Action)
+	}
+
+	all, err := repos.Tasks.FindTasksByExecution(ctx, execution.ID)
+	if err != nil {
+		t.Fatalf("FindTasksByExecution: %v", err)
+	}
+	if len(all) != 2 || all[0].RefID != "a" || all[1].RefID != "b" {
+		t.Errorf("FindTasksByExecution returned %d tasks, want a then b", len(all))
+	}
+
+	children, err := repos.Tasks.FindChildren(ctx, execution.ID, "a")
+	if err != nil {
+		t.Fatalf("FindChildren: %v", err)
+	}
+	if len(children) != 1 || children[0].RefID != "b" {
+		t.Errorf("FindChildren(a) returned %d tasks, want b", len(children))
+	}
+
+	// A duplicate execution stores nothing
+	duplicate := *execution
+	if err := repos.Tasks.CreateExecution(ctx, &duplicate, []domain.Task{*domain.NewTask(execution.ID, "c", "action_c")}); err == nil {
+		t.Error("CreateExecution of an existing execution succeeded")
+	}
+	if all, _ := repos.Tasks.FindTasksByExecution(ctx, execution.ID); len(all) != 2 {
+		t.Errorf("failed CreateExecution left %d tasks, want 2", len(all))
+	}
+
+	_, err = repos.Tasks.FindTaskByID(ctx, uuid.New())
+	wantNotFound(t, "FindTaskByID(unknown)", err)
+	_, err = repos.Workflows.GetByID(ctx, uuid.New())
+	wantNotFound(t, "GetByID(unknown)", err)
+}
+
+func testClaimTask(t *testing.T, ctx context.Context, repos Repositories) {
+	_, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+	id := tasks["a"].ID
+
+	if err := repos.Tasks.ClaimTask(ctx, id, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	task := findTask(t, ctx, repos, id)
+	if task.Status != domain.StatusRunning || task.Version != 2 || task.WorkerID == nil || *task.WorkerID != "worker-1" {
+		t.Errorf("claimed task = %s v%d by %v, want RUNNING v2 by worker-1", task.Status, task.Version, task.WorkerID)
+	}
+	if task.StartedAt == nil {
+		t.Error("claimed task has no started_at")
+	}
+
+	// A second claimer holding the old version loses
+	wantNotFound(t, "ClaimTask with a stale version", repos.Tasks.ClaimTask(ctx, id, "worker-2", 1))
+	if task := findTask(t, ctx, repos, id); *task.WorkerID != "worker-1" {
+		t.Errorf("stale claim changed the worker to %s", *task.WorkerID)
+	}
+	wantNotFound(t, "ClaimTask(unknown)", repos.Tasks.ClaimTask(ctx, uuid.New(), "worker-1", 1))
+}
+
+func testIncrementRetryCount(t *testing.T, ctx context.Context, repos Repositories) {
+	_, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+	id := tasks["a"].ID
+	if err := repos.Tasks.ClaimTask(ctx, id, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+
+	// A delayed retry keeps its next attempt time
+	nextAttemptAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	if err := repos.Tasks.IncrementRetryCount(ctx, id, 2, &nextAttemptAt); err != nil {
+		t.Fatalf("IncrementRetryCount: %v", err)
+	}
+	task := findTask(t, ctx, repos, id)
+	if task.RetryCount != 1 || task.Status != domain.StatusPending || task.Version != 3 {
+		t.Errorf("retried task = %d retries %s v%d, want 1 retry PENDING v3", task.RetryCount, task.Status, task.Version)
+	}
+	if task.NextAttemptAt == nil || !task.NextAttemptAt.Equal(nextAttemptAt) {
+		t.Errorf("next attempt at %v, want %s", task.NextAttemptAt, nextAttemptAt)
+	}
+	wantNotFound(t, "IncrementRetryCount with a stale version", repos.Tasks.IncrementRetryCount(ctx, id, 2, nil))
+
+	// The next retry without a delay clears it
+	if err := repos.Tasks.ClaimTask(ctx, id, "worker-1", 3); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	if err := repos.Tasks.IncrementRetryCount(ctx, id, 4, nil); err != nil {
+		t.Fatalf("IncrementRetryCount: %v", err)
+	}
+	if task := findTask(t, ctx, repos, id); task.RetryCount != 2 || task.NextAttemptAt != nil {
+		t.Errorf("retried task = %d retries next at %v, want 2 retries at once", task.RetryCount, task.NextAttemptAt)
+	}
+}
+
+func testReleaseTask(t *testing.T, ctx context.Context, repos Repositories) {
+	_, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+	id := tasks["a"].ID
+
+	// Only RUNNING tasks are handed back
+	wantNotFound(t, "ReleaseTask of a PENDING task", repos.Tasks.ReleaseTask(ctx, id, 1))
+
+	if err := repos.Tasks.ClaimTask(ctx, id, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	if err := repos.Tasks.ReleaseTask(ctx, id, 2); err != nil {
+		t.Fatalf("ReleaseTask: %v", err)
+	}
+	task := findTask(t, ctx, repos, id)
+	if task.Status != domain.StatusQueued || task.WorkerID != nil || task.Version != 3 || task.RetryCount != 0 {
+		t.Errorf("released task = %s by %v v%d with %d retries, want QUEUED by nobody v3 with 0",
+			task.Status, task.WorkerID, task.Version, task.RetryCount)
+	}
+}
+
+func testMarkFinalStatus(t *testing.T, ctx context.Context, repos Repositories) {
+	_, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"}, taskSpec{refID: "b"}, taskSpec{refID: "c"})
+
+	if err := repos.Tasks.MarkCompleted(ctx, tasks["a"].ID, datatypes.JSON(`{"ok":true}`)); err != nil {
+		t.Fatalf("MarkCompleted: %v", err)
+	}
+	if task := findTask(t, ctx, repos, tasks["a"].ID); task.Status != domain.StatusCompleted || !jsonEqual(task.Output, `{"ok":true}`) {
+		t.Errorf("completed task = %s with %s, want COMPLETED with {\"ok\":true}", task.Status, task.Output)
+	}
+
+	if err := repos.Tasks.MarkFailed(ctx, tasks["b"].ID, "boom", datatypes.JSON(`{"exit_code":1}`)); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if task := findTask(t, ctx, repos, tasks["b"].ID); task.Status != domain.StatusFailed || task.LastError != "boom" ||
+		!jsonEqual(task.Output, `{"error":"boom","output":{"exit_code":1}}`) {
+		t.Errorf("failed task = %s %q with %s, want FAILED boom with the handler's output", task.Status, task.LastError, task.Output)
+	}
+
+	if err := repos.Tasks.MarkSkipped(ctx, tasks["c"].ID, "parent failed"); err != nil {
+		t.Fatalf("MarkSkipped: %v", err)
+	}
+	if task := findTask(t, ctx, repos, tasks["c"].ID); task.Status != domain.StatusSkipped ||
+		!jsonEqual(task.Output, string(domain.SkippedOutput("parent failed"))) {
+		t.Errorf("skipped task = %s with %s, want SKIPPED", task.Status, task.Output)
+	}
+
+	// Marking a missing task is a no-op, like an UPDATE matching no rows
+	if err := repos.Tasks.MarkCompleted(ctx, uuid.New(), datatypes.JSON(`{}`)); err != nil {
+		t.Errorf("MarkCompleted(unknown) = %v, want nil", err)
+	}
+}
+
+func testUpdateProgress(t *testing.T, ctx context.Context, repos Repositories) {
+	_, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+	id := tasks["a"].ID
+	progress := domain.TaskProgress{Percent: 40, Message: "halfway", Details: []byte(`{"rows":4}`), UpdatedAt: time.Now()}
+
+	// Progress of tasks that aren't RUNNING is dropped
+	if err := repos.Tasks.UpdateProgress(ctx, id, progress); err != nil {
+		t.Fatalf("UpdateProgress: %v", err)
+	}
+	if task := findTask(t, ctx, repos, id); task.Progress() != nil {
+		t.Errorf("PENDING task has progress %+v", task.Progress())
+	}
+
+	if err := repos.Tasks.ClaimTask(ctx, id, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	if err := repos.Tasks.UpdateProgress(ctx, id, progress); err != nil {
+		t.Fatalf("UpdateProgress: %v", err)
+	}
+	task := findTask(t, ctx, repos, id)
+	got := task.Progress()
+	if got == nil || got.Percent != 40 || got.Message != "halfway" || !jsonEqual(got.Details, `{"rows":4}`) {
+		t.Fatalf("progress = %+v, want 40%% halfway with details", got)
+	}
+	if task.Version != 2 {
+		t.Errorf("UpdateProgress bumped the version to %d", task.Version)
+	}
+
+	// A new attempt starts without the progress of the last one
+	if err := repos.Tasks.ReleaseTask(ctx, id, 2); err != nil {
+		t.Fatalf("ReleaseTask: %v", err)
+	}
+	if err := repos.Tasks.ClaimTask(ctx, id, "worker-2", 3); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	if task := findTask(t, ctx, repos, id); task.Progress() != nil {
+		t.Errorf("reclaimed task kept progress %+v", task.Progress())
+	}
+}
+
+func testDecrementAndGetReadyTasks(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, tasks := createWorkflow(t, ctx, repos,
+		taskSpec{refID: "a"},
+		taskSpec{refID: "b", deps: []string{"a"}},
+		taskSpec{refID: "c", deps: []string{"a"}},
+		taskSpec{refID: "d", deps: []string{"b", "c"}},
+	)
+
+	ready, err := repos.Tasks.DecrementAndGetReadyTasks(ctx, execution.ID, "a")
+	if err != nil {
+		t.Fatalf("DecrementAndGetReadyTasks(a): %v", err)
+	}
+	if !sameIDs(ready, tasks["b"].ID, tasks["c"].ID) {
+		t.Errorf("ready after a = %v, want b and c", ready)
+	}
+	for _, refID := range []string{"b", "c"} {
+		if task := findTask(t, ctx, repos, tasks[refID].ID); task.Status != domain.StatusQueued || task.InDegree != 0 {
+			t.Errorf("task %s = %s in-degree %d, want QUEUED 0", refID, task.Status, task.InDegree)
+		}
+	}
+
+	ready, err = repos.Tasks.DecrementAndGetReadyTasks(ctx, execution.ID, "b")
+	if err != nil {
+		t.Fatalf("DecrementAndGetReadyTasks(b): %v", err)
+	}
+	if len(ready) != 0 {
+		t.Errorf("ready after b = %v, want none", ready)
+	}
+	if task := findTask(t, ctx, repos, tasks["d"].ID); task.Status != domain.StatusPending || task.InDegree != 1 {
+		t.Errorf("task d = %s in-degree %d, want PENDING 1", task.Status, task.InDegree)
+	}
+
+	ready, err = repos.Tasks.DecrementAndGetReadyTasks(ctx, execution.ID, "c")
+	if err != nil {
+		t.Fatalf("DecrementAndGetReadyTasks(c): %v", err)
+	}
+	if !sameIDs(ready, tasks["d"].ID) {
+		t.Errorf("ready after c = %v, want d", ready)
+	}
+	if task := findTask(t, ctx, repos, tasks["d"].ID); task.SkipHint {
+		t.Error("completed parents set the skip hint")
+	}
+
+	// Only tasks of the same execution are decremented
+	other, otherTasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"}, taskSpec{refID: "b", deps: []string{"a"}})
+	if _, err := repos.Tasks.DecrementAndGetReadyTasks(ctx, execution.ID, "a"); err != nil {
+		t.Fatalf("DecrementAndGetReadyTasks: %v", err)
+	}
+	if task := findTask(t, ctx, repos, otherTasks["b"].ID); task.InDegree != 1 {
+		t.Errorf("task of execution %s was decremented to %d", other.ID, task.InDegree)
+	}
+}
+
+func testDecrementAndSetSkipHint(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, tasks := createWorkflow(t, ctx, repos,
+		taskSpec{refID: "a"},
+		taskSpec{refID: "b"},
+		taskSpec{refID: "c", deps: []string{"a", "b"}},
+	)
+
+	ready, err := repos.Tasks.DecrementAndSetSkipHint(ctx, execution.ID, "a")
+	if err != nil {
+		t.Fatalf("DecrementAndSetSkipHint: %v", err)
+	}
+	if len(ready) != 0 {
+		t.Errorf("ready after a failed = %v, want none", ready)
+	}
+	if task := findTask(t, ctx, repos, tasks["c"].ID); !task.SkipHint || task.InDegree != 1 || task.Status != domain.StatusPending {
+		t.Errorf("task c = skip %v in-degree %d %s, want skip true 1 PENDING", task.SkipHint, task.InDegree, task.Status)
+	}
+
+	// The hint stays when the other parent completes and the task is queued to be skipped
+	ready, err = repos.Tasks.DecrementAndGetReadyTasks(ctx, execution.ID, "b")
+	if err != nil {
+		t.Fatalf("DecrementAndGetReadyTasks: %v", err)
+	}
+	if !sameIDs(ready, tasks["c"].ID) {
+		t.Errorf("ready after b = %v, want c", ready)
+	}
+	if task := findTask(t, ctx, repos, tasks["c"].ID); !task.SkipHint || task.Status != domain.StatusQueued {
+		t.Errorf("task c = skip %v %s, want skip true QUEUED", task.SkipHint, task.Status)
+	}
+}
+
+func testAreAllTasks(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"}, taskSpec{refID: "b"})
+	check := func(wantCompleted, wantTerminal bool) {
+		t.Helper()
+		completed, err := repos.Tasks.AreAllTasksCompleted(ctx, execution.ID)
+		if err != nil {
+			t.Fatalf("AreAllTasksCompleted: %v", err)
+		}
+		terminal, err := repos.Tasks.AreAllTasksTerminal(ctx, execution.ID)
+		if err != nil {
+			t.Fatalf("AreAllTasksTerminal: %v", err)
+		}
+		if completed != wantCompleted || terminal != wantTerminal {
+			t.Errorf("completed %v terminal %v, want %v %v", completed, terminal, wantCompleted, wantTerminal)
+		}
+	}
+
+	check(false, false)
+	repos.Tasks.MarkCompleted(ctx, tasks["a"].ID, datatypes.JSON(`{}`))
+	check(false, false)
+	repos.Tasks.MarkSkipped(ctx, tasks["b"].ID, "skipped")
+	check(true, true)
+	repos.Tasks.MarkFailed(ctx, tasks["b"].ID, "boom", nil)
+	check(false, true)
+}
+
+func testWaitingTasks(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, tasks := createWorkflow(t, ctx, repos,
+		taskSpec{refID: "a"},
+		taskSpec{refID: "b"},
+		taskSpec{refID: "c"},
+		taskSpec{refID: "d", deps: []string{"a"}},
+		taskSpec{refID: "e", deps: []string{"b"}},
+	)
+	// a is retried, b completes and queues e, c runs and d still waits for a
+	if err := repos.Tasks.ClaimTask(ctx, tasks["a"].ID, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	if err := repos.Tasks.IncrementRetryCount(ctx, tasks["a"].ID, 2, nil); err != nil {
+		t.Fatalf("IncrementRetryCount: %v", err)
+	}
+	repos.Tasks.MarkCompleted(ctx, tasks["b"].ID, datatypes.JSON(`{}`))
+	if _, err := repos.Tasks.DecrementAndGetReadyTasks(ctx, execution.ID, "b"); err != nil {
+		t.Fatalf("DecrementAndGetReadyTasks: %v", err)
+	}
+	if err := repos.Tasks.ClaimTask(ctx, tasks["c"].ID, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+
+	waiting, err := repos.Tasks.FindWaitingTasks(ctx)
+	if err != nil {
+		t.Fatalf("FindWaitingTasks: %v", err)
+	}
+	if len(waiting) != 2 || waiting[0].ID != tasks["a"].ID || waiting[1].ID != tasks["e"].ID {
+		t.Fatalf("FindWaitingTasks returned %d tasks, want a then e", len(waiting))
+	}
+	if waiting[0].Status != domain.StatusPending || waiting[0].RetryCount != 1 || waiting[1].Status != domain.StatusQueued {
+		t.Errorf("waiting tasks = %s with %d retries and %s, want PENDING with 1 and QUEUED",
+			waiting[0].Status, waiting[0].RetryCount, waiting[1].Status)
+	}
+
+	count, err := repos.Tasks.CountWaitingTasks(ctx)
+	if err != nil {
+		t.Fatalf("CountWaitingTasks: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("CountWaitingTasks = %d, want 2", count)
+	}
+
+	other := domain.WithTenant(context.Background(), "conformance-"+uuid.NewString())
+	if waiting, _ := repos.Tasks.FindWaitingTasks(other); len(waiting) != 0 {
+		t.Errorf("FindWaitingTasks of another tenant returned %d tasks", len(waiting))
+	}
+}
+
+func testLeases(t *testing.T, ctx context.Context, repos Repositories) {
+	_, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"}, taskSpec{refID: "b"})
+	id := tasks["a"].ID
+	now := time.Now()
+
+	wantNotFound(t, "SetLease of a PENDING task", repos.Tasks.SetLease(ctx, id, 1, "hash-1", now.Add(time.Minute)))
+	if err := repos.Tasks.ClaimTask(ctx, id, "external", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	wantNotFound(t, "SetLease with a stale version", repos.Tasks.SetLease(ctx, id, 1, "hash-1", now.Add(time.Minute)))
+	if err := repos.Tasks.SetLease(ctx, id, 2, "hash-1", now.Add(-time.Minute)); err != nil {
+		t.Fatalf("SetLease: %v", err)
+	}
+	task := findTask(t, ctx, repos, id)
+	if task.LeaseTokenHash == nil || *task.LeaseTokenHash != "hash-1" || task.LeaseExpiresAt == nil || task.Version != 2 {
+		t.Errorf("leased task = %v until %v v%d, want hash-1 v2", task.LeaseTokenHash, task.LeaseExpiresAt, task.Version)
+	}
+
+	// The expired lease is found, oldest first and up to the limit
+	if err := repos.Tasks.ClaimTask(ctx, tasks["b"].ID, "external", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	if err := repos.Tasks.SetLease(ctx, tasks["b"].ID, 2, "hash-2", now.Add(-2*time.Minute)); err != nil {
+		t.Fatalf("SetLease: %v", err)
+	}
+	expired, err := repos.Tasks.FindExpiredLeases(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("FindExpiredLeases: %v", err)
+	}
+	if len(expired) != 2 || expired[0].ID != tasks["b"].ID || expired[1].ID != id {
+		t.Errorf("FindExpiredLeases returned %d tasks, want b then a", len(expired))
+	}
+	if expired, _ := repos.Tasks.FindExpiredLeases(ctx, now, 1); len(expired) != 1 {
+		t.Errorf("FindExpiredLeases with limit 1 returned %d tasks", len(expired))
+	}
+
+	wantNotFound(t, "RenewLease with another token", repos.Tasks.RenewLease(ctx, id, "hash-2", now.Add(time.Minute)))
+	if err := repos.Tasks.RenewLease(ctx, id, "hash-1", now.Add(time.Minute)); err != nil {
+		t.Fatalf("RenewLease: %v", err)
+	}
+	if expired, _ := repos.Tasks.FindExpiredLeases(ctx, now, 10); len(expired) != 1 || expired[0].ID != tasks["b"].ID {
+		t.Errorf("renewed lease is still expired")
+	}
+
+	wantNotFound(t, "EndLease with another token", repos.Tasks.EndLease(ctx, id, "hash-2"))
+	if err := repos.Tasks.EndLease(ctx, id, "hash-1"); err != nil {
+		t.Fatalf("EndLease: %v", err)
+	}
+	if task := findTask(t, ctx, repos, id); task.LeaseTokenHash != nil || task.LeaseExpiresAt != nil {
+		t.Error("EndLease left the lease")
+	}
+	wantNotFound(t, "EndLease of an ended lease", repos.Tasks.EndLease(ctx, id, "hash-1"))
+}
+
+func testCancelExecution(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, tasks := createWorkflow(t, ctx, repos,
+		taskSpec{refID: "a"},
+		taskSpec{refID: "b"},
+		taskSpec{refID: "c", deps: []string{"a"}},
+	)
+	if err := repos.Tasks.ClaimTask(ctx, tasks["a"].ID, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	if err := repos.Tasks.ClaimTask(ctx, tasks["b"].ID, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	if err := repos.Tasks.ReleaseTask(ctx, tasks["b"].ID, 2); err != nil {
+		t.Fatalf("ReleaseTask: %v", err)
+	}
+
+	if err := repos.Tasks.CancelExecution(ctx, execution.ID); err != nil {
+		t.Fatalf("CancelExecution: %v", err)
+	}
+	if stored := getWorkflow(t, ctx, repos, execution.ID); stored.Status != domain.WorkflowCancelled {
+		t.Errorf("cancelled execution = %s, want CANCELLED", stored.Status)
+	}
+	// The RUNNING task finishes; the QUEUED and PENDING ones are skipped when popped
+	for refID, want := range map[string]bool{"a": false, "b": true, "c": true} {
+		if task := findTask(t, ctx, repos, tasks[refID].ID); task.SkipHint != want {
+			t.Errorf("task %s skip hint = %v, want %v", refID, task.SkipHint, want)
+		}
+	}
+
+	wantNotFound(t, "CancelExecution of a cancelled execution", repos.Tasks.CancelExecution(ctx, execution.ID))
+	wantNotFound(t, "CancelExecution(unknown)", repos.Tasks.CancelExecution(ctx, uuid.New()))
+}
+
+func testDeleteExecution(t *testing.T, ctx context.Context, repos Repositories) {
+	tenant, _ := domain.TenantFromContext(ctx)
+	execution, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"}, taskSpec{refID: "b", deps: []string{"a"}})
+	kept, keptTasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+	for _, id := range []uuid.UUID{execution.ID, kept.ID} {
+		if err := repos.History.Append(ctx, domain.NewWorkflowEventRecord(domain.NewWorkflowStatusEvent(id, domain.WorkflowRunning))); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	delivery := domain.NewWebhookDelivery(domain.NewWorkflowStatusEvent(execution.ID, domain.WorkflowRunning), "https://example.com/hook", nil, datatypes.JSON(`{}`))
+	delivery.Tenant = tenant
+	if err := repos.Webhooks.CreateDeliveries(ctx, []domain.WebhookDelivery{*delivery}); err != nil {
+		t.Fatalf("CreateDeliveries: %v", err)
+	}
+
+	other := domain.WithTenant(context.Background(), "conformance-"+uuid.NewString())
+	wantNotFound(t, "DeleteExecution of another tenant", repos.Tasks.DeleteExecution(other, execution.ID))
+	if err := repos.Tasks.DeleteExecution(ctx, execution.ID); err != nil {
+		t.Fatalf("DeleteExecution: %v", err)
+	}
+
+	_, err := repos.Workflows.GetByID(ctx, execution.ID)
+	wantNotFound(t, "GetByID of a deleted execution", err)
+	_, err = repos.Tasks.FindTaskByID(ctx, tasks["a"].ID)
+	wantNotFound(t, "FindTaskByID of a task of a deleted execution", err)
+	if records, _ := repos.History.ListByExecution(ctx, execution.ID); len(records) != 0 {
+		t.Errorf("deleted execution kept %d history records", len(records))
+	}
+	if deliveries, _ := repos.Webhooks.ListDeliveries(ctx, domain.WebhookDeliveryFilter{ExecutionID: execution.ID}); len(deliveries) != 0 {
+		t.Errorf("deleted execution kept %d deliveries", len(deliveries))
+	}
+
+	// Other executions are untouched
+	findTask(t, ctx, repos, keptTasks["a"].ID)
+	if records, _ := repos.History.ListByExecution(ctx, kept.ID); len(records) != 1 {
+		t.Errorf("kept execution has %d history records, want 1", len(records))
+	}
+	wantNotFound(t, "DeleteExecution of a deleted execution", repos.Tasks.DeleteExecution(ctx, execution.ID))
+}
+
+func testUpdateStatus(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, _ := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+
+	for _, tt := range []struct {
+		status string
+		want   bool
+	}{
+		{string(domain.WorkflowRunning), false}, // Unchanged
+		{string(domain.WorkflowPaused), true},
+		{string(domain.WorkflowFailed), true},
+		{string(domain.WorkflowCompleted), false}, // FAILED is final
+		{string(domain.WorkflowFailed), false},
+	} {
+		updated, err := repos.Workflows.UpdateStatus(ctx, execution.ID, tt.status)
+		if err != nil {
+			t.Fatalf("UpdateStatus(%s): %v", tt.status, err)
+		}
+		if updated != tt.want {
+			t.Errorf("UpdateStatus(%s) = %v, want %v", tt.status, updated, tt.want)
+		}
+	}
+	if stored := getWorkflow(t, ctx, repos, execution.ID); stored.Status != domain.WorkflowFailed {
+		t.Errorf("status = %s, want FAILED", stored.Status)
+	}
+
+	if updated, err := repos.Workflows.UpdateStatus(ctx, uuid.New(), string(domain.WorkflowCompleted)); err != nil || updated {
+		t.Errorf("UpdateStatus(unknown) = %v, %v, want false, nil", updated, err)
+	}
+}
+
+func testMarkFinished(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, _ := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+
+	for i, want := range []bool{true, false} {
+		finished, err := repos.Workflows.MarkFinished(ctx, execution.ID)
+		if err != nil {
+			t.Fatalf("MarkFinished: %v", err)
+		}
+		if finished != want {
+			t.Errorf("MarkFinished call %d = %v, want %v", i+1, finished, want)
+		}
+	}
+	if stored := getWorkflow(t, ctx, repos, execution.ID); stored.FinishedAt == nil {
+		t.Error("finished_at not set")
+	}
+}
+
+func testIdempotencyKey(t *testing.T, ctx context.Context, repos Repositories) {
+	tenant, _ := domain.TenantFromContext(ctx)
+	key := "key-" + uuid.NewString()
+
+	execution := domain.NewWorkflow(uuid.New(), "conformance")
+	execution.Tenant = tenant
+	execution.IdempotencyKey = &key
+	if err := repos.Workflows.Create(ctx, execution); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	found, err := repos.Workflows.GetByIdempotencyKey(ctx, key)
+	if err != nil {
+		t.Fatalf("GetByIdempotencyKey: %v", err)
+	}
+	if found.ID != execution.ID {
+		t.Errorf("GetByIdempotencyKey = %s, want %s", found.ID, execution.ID)
+	}
+
+	duplicate := domain.NewWorkflow(uuid.New(), "conformance")
+	duplicate.Tenant = tenant
+	duplicate.IdempotencyKey = &key
+	if err := repos.Workflows.Create(ctx, duplicate); err == nil {
+		t.Error("Create with a taken idempotency key succeeded")
+	}
+
+	// Keys are unique per tenant
+	otherCtx := domain.WithTenant(context.Background(), "conformance-"+uuid.NewString())
+	other := domain.NewWorkflow(uuid.New(), "conformance")
+	other.Tenant, _ = domain.TenantFromContext(otherCtx)
+	other.IdempotencyKey = &key
+	if err := repos.Workflows.Create(otherCtx, other); err != nil {
+		t.Errorf("Create with the key of another tenant: %v", err)
+	}
+
+	_, err = repos.Workflows.GetByIdempotencyKey(ctx, "missing-"+key)
+	wantNotFound(t, "GetByIdempotencyKey(unknown)", err)
+}
+
+// createListed stores an execution of the ctx tenant created at the given time
+func createListed(t *testing.T, ctx context.Context, repos Repositories, userID uuid.UUID, workflowType string, createdAt time.Time) *domain.WorkflowExecution {
+	t.Helper()
+	tenant, _ := domain.TenantFromContext(ctx)
+	execution := domain.NewWorkflow(userID, workflowType)
+	execution.Tenant = tenant
+	execution.CreatedAt = createdAt
+	if err := repos.Workflows.Create(ctx, execution); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return execution
+}
+
+func testListWorkflows(t *testing.T, ctx context.Context, repos Repositories) {
+	base := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	alice, bob := uuid.New(), uuid.New()
+	first := createListed(t, ctx, repos, alice, "onboarding", base)
+	second := createListed(t, ctx, repos, bob, "onboarding", base.Add(time.Minute))
+	third := createListed(t, ctx, repos, alice, "billing", base.Add(2*time.Minute))
+	if _, err := repos.Workflows.UpdateStatus(ctx, second.ID, string(domain.WorkflowCompleted)); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	list := func(filter domain.WorkflowFilter) []uuid.UUID {
+		t.Helper()
+		executions, err := repos.Workflows.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		ids := make([]uuid.UUID, len(executions))
+		for i, execution := range executions {
+			ids[i] = execution.ID
+		}
+		return ids
+	}
+	equal := func(name string, got []uuid.UUID, want ...uuid.UUID) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s returned %d executions, want %d", name, len(got), len(want))
+			return
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s[%d] = %s, want %s", name, i, got[i], want[i])
+			}
+		}
+	}
+
+	equal("List()", list(domain.WorkflowFilter{}), third.ID, second.ID, first.ID)
+	equal("List(user)", list(domain.WorkflowFilter{UserID: alice}), third.ID, first.ID)
+	equal("List(type)", list(domain.WorkflowFilter{WorkflowType: "onboarding"}), second.ID, first.ID)
+	equal("List(status)", list(domain.WorkflowFilter{Status: domain.WorkflowCompleted}), second.ID)
+	equal("List(created range)", list(domain.WorkflowFilter{
+		CreatedAfter: base.Add(time.Minute), CreatedBefore: base.Add(2 * time.Minute),
+	}), second.ID)
+
+	// Pages continue after the cursor of the last row
+	page := list(domain.WorkflowFilter{Limit: 2})
+	equal("List(limit 2)", page, third.ID, second.ID)
+	equal("List(after)", list(domain.WorkflowFilter{
+		Limit: 2, After: &domain.WorkflowCursor{CreatedAt: base.Add(time.Minute), ID: second.ID},
+	}), first.ID)
+
+	// Only workflows with a FAILED task of the action
+	failing, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"}, taskSpec{refID: "b"})
+	repos.Tasks.MarkFailed(ctx, tasks["a"].ID, "boom", nil)
+	repos.Tasks.MarkCompleted(ctx, tasks["b"].ID, datatypes.JSON(`{}`))
+	equal("List(failing action_a)", list(domain.WorkflowFilter{FailingAction: "action_a"}), failing.ID)
+	equal("List(failing action_b)", list(domain.WorkflowFilter{FailingAction: "action_b"}))
+}
+
+func testCountByStatus(t *testing.T, ctx context.Context, repos Repositories) {
+	userID := uuid.New()
+	now := time.Now()
+	createListed(t, ctx, repos, userID, "onboarding", now)
+	done := createListed(t, ctx, repos, userID, "onboarding", now)
+	createListed(t, ctx, repos, uuid.New(), "billing", now)
+	if _, err := repos.Workflows.UpdateStatus(ctx, done.ID, string(domain.WorkflowCompleted)); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	counts, err := repos.Workflows.CountByStatus(ctx, domain.WorkflowFilter{UserID: userID, Status: domain.WorkflowFailed})
+	if err != nil {
+		t.Fatalf("CountByStatus: %v", err)
+	}
+	if len(counts) != 2 || counts[domain.WorkflowRunning] != 1 || counts[domain.WorkflowCompleted] != 1 {
+		t.Errorf("CountByStatus(user) = %v, want 1 RUNNING and 1 COMPLETED", counts)
+	}
+}
+
+func testTenantIsolation(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"}, taskSpec{refID: "b", deps: []string{"a"}})
+	id := tasks["a"].ID
+	other := domain.WithTenant(context.Background(), "conformance-"+uuid.NewString())
+
+	// Reads of another tenant see nothing
+	_, err := repos.Tasks.FindTaskByID(other, id)
+	wantNotFound(t, "FindTaskByID of another tenant", err)
+	_, err = repos.Workflows.GetByID(other, execution.ID)
+	wantNotFound(t, "GetByID of another tenant", err)
+	if found, _ := repos.Tasks.FindTasksByExecution(other, execution.ID); len(found) != 0 {
+		t.Errorf("FindTasksByExecution of another tenant returned %d tasks", len(found))
+	}
+	if found, _ := repos.Tasks.FindChildren(other, execution.ID, "a"); len(found) != 0 {
+		t.Errorf("FindChildren of another tenant returned %d tasks", len(found))
+	}
+	if count, _ := repos.Tasks.CountWaitingTasks(other); count != 0 {
+		t.Errorf("CountWaitingTasks of another tenant = %d", count)
+	}
+
+	// Writes of another tenant change nothing
+	wantNotFound(t, "ClaimTask of another tenant", repos.Tasks.ClaimTask(other, id, "worker-1", 1))
+	wantNotFound(t, "IncrementRetryCount of another tenant", repos.Tasks.IncrementRetryCount(other, id, 1, nil))
+	repos.Tasks.MarkCompleted(other, id, datatypes.JSON(`{}`))
+	repos.Tasks.MarkFailed(other, id, "boom", nil)
+	repos.Tasks.MarkSkipped(other, id, "skipped")
+	if task := findTask(t, ctx, repos, id); task.Status != domain.StatusPending || task.Version != 1 {
+		t.Errorf("task after writes of another tenant = %s v%d, want PENDING v1", task.Status, task.Version)
+	}
+
+	if err := repos.Tasks.ClaimTask(ctx, id, "worker-1", 1); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	wantNotFound(t, "ReleaseTask of another tenant", repos.Tasks.ReleaseTask(other, id, 2))
+	wantNotFound(t, "SetLease of another tenant", repos.Tasks.SetLease(other, id, 2, "hash", time.Now()))
+	repos.Tasks.UpdateProgress(other, id, domain.TaskProgress{Percent: 50, UpdatedAt: time.Now()})
+	if task := findTask(t, ctx, repos, id); task.Status != domain.StatusRunning || task.Version != 2 || task.Progress() != nil {
+		t.Errorf("task after writes of another tenant = %s v%d with progress %v, want RUNNING v2 without",
+			task.Status, task.Version, task.Progress())
+	}
+
+	if updated, _ := repos.Workflows.UpdateStatus(other, execution.ID, string(domain.WorkflowFailed)); updated {
+		t.Error("UpdateStatus of another tenant updated the execution")
+	}
+	if finished, _ := repos.Workflows.MarkFinished(other, execution.ID); finished {
+		t.Error("MarkFinished of another tenant finished the execution")
+	}
+	wantNotFound(t, "CancelExecution of another tenant", repos.Tasks.CancelExecution(other, execution.ID))
+	if stored := getWorkflow(t, ctx, repos, execution.ID); stored.Status != domain.WorkflowRunning || stored.FinishedAt != nil {
+		t.Errorf("execution after writes of another tenant = %s finished %v, want RUNNING unfinished", stored.Status, stored.FinishedAt)
+	}
+}
+
+func testWebhooks(t *testing.T, ctx context.Context, repos Repositories) {
+	tenant, _ := domain.TenantFromContext(ctx)
+	sub := domain.NewWebhookSubscription("https://example.com/hook", nil, "secret")
+	sub.Tenant = tenant
+	if err := repos.Webhooks.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	subs, err := repos.Webhooks.ListSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if len(subs) != 1 || subs[0].ID != sub.ID || subs[0].Secret != "secret" {
+		t.Errorf("ListSubscriptions returned %d subscriptions, want the one created", len(subs))
+	}
+
+	execution, _ := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+	event := domain.NewWorkflowStatusEvent(execution.ID, domain.WorkflowCompleted)
+	delivery := domain.NewWebhookDelivery(event, sub.URL, &sub.ID, datatypes.JSON(`{}`))
+	delivery.Tenant = tenant
+	delivery.NextAttemptAt = time.Now().Add(-time.Second)
+	if err := repos.Webhooks.CreateDeliveries(ctx, []domain.WebhookDelivery{*delivery}); err != nil {
+		t.Fatalf("CreateDeliveries: %v", err)
+	}
+
+	// A claimed delivery is hidden from other dispatchers for the lease
+	claimed, err := repos.Webhooks.ClaimDueDeliveries(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDueDeliveries: %v", err)
+	}
+	found := false
+	for _, c := range claimed {
+		found = found || c.ID == delivery.ID
+	}
+	if !found {
+		t.Fatal("due delivery was not claimed")
+	}
+	again, err := repos.Webhooks.ClaimDueDeliveries(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDueDeliveries: %v", err)
+	}
+	for _, c := range again {
+		if c.ID == delivery.ID {
+			t.Error("leased delivery was claimed again")
+		}
+	}
+
+	deliveredAt := time.Now()
+	delivery.Status, delivery.Attempts, delivery.ResponseCode, delivery.DeliveredAt = domain.DeliveryDelivered, 1, 204, &deliveredAt
+	if err := repos.Webhooks.UpdateDelivery(ctx, delivery); err != nil {
+		t.Fatalf("UpdateDelivery: %v", err)
+	}
+	deliveries, err := repos.Webhooks.ListDeliveries(ctx, domain.WebhookDeliveryFilter{SubscriptionID: sub.ID})
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != domain.DeliveryDelivered || deliveries[0].Attempts != 1 ||
+		deliveries[0].ResponseCode != 204 || deliveries[0].DeliveredAt == nil {
+		t.Errorf("ListDeliveries returned %d deliveries, want the delivered one", len(deliveries))
+	}
+	if deliveries, _ := repos.Webhooks.ListDeliveries(ctx, domain.WebhookDeliveryFilter{Status: domain.DeliveryDead}); len(deliveries) != 0 {
+		t.Errorf("ListDeliveries(DEAD) returned %d deliveries", len(deliveries))
+	}
+
+	other := domain.WithTenant(context.Background(), "conformance-"+uuid.NewString())
+	if subs, _ := repos.Webhooks.ListSubscriptions(other); len(subs) != 0 {
+		t.Errorf("ListSubscriptions of another tenant returned %d subscriptions", len(subs))
+	}
+	wantNotFound(t, "DeleteSubscription of another tenant", repos.Webhooks.DeleteSubscription(other, sub.ID))
+	if err := repos.Webhooks.DeleteSubscription(ctx, sub.ID); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	if subs, _ := repos.Webhooks.ListSubscriptions(ctx); len(subs) != 0 {
+		t.Errorf("deleted subscription is still listed")
+	}
+	wantNotFound(t, "DeleteSubscription of a deleted subscription", repos.Webhooks.DeleteSubscription(ctx, sub.ID))
+}
+
+func testHistory(t *testing.T, ctx context.Context, repos Repositories) {
+	execution, tasks := createWorkflow(t, ctx, repos, taskSpec{refID: "a"})
+	events := []domain.WorkflowEvent{
+		domain.NewWorkflowStatusEvent(execution.ID, domain.WorkflowRunning),
+		domain.NewTaskEvent(domain.EventTaskQueued, tasks["a"], ""),
+		domain.NewWorkflowStatusEvent(execution.ID, domain.WorkflowCompleted),
+	}
+	for _, event := range events {
+		if err := repos.History.Append(ctx, domain.NewWorkflowEventRecord(event)); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	records, err := repos.History.ListByExecution(ctx, execution.ID)
+	if err != nil {
+		t.Fatalf("ListByExecution: %v", err)
+	}
+	if len(records) != len(events) {
+		t.Fatalf("ListByExecution returned %d records, want %d", len(records), len(events))
+	}
+	for i, record := range records {
+		if record.Type != events[i].Type {
+			t.Errorf("record %d = %s, want %s", i, record.Type, events[i].Type)
+		}
+	}
+	if records[1].TaskID == nil || *records[1].TaskID != tasks["a"].ID || records[1].RefID != "a" {
+		t.Errorf("task record = %v %q, want task a", records[1].TaskID, records[1].RefID)
+	}
+
+	other := domain.WithTenant(context.Background(), "conformance-"+uuid.NewString())
+	if records, _ := repos.History.ListByExecution(other, execution.ID); len(records) != 0 {
+		t.Errorf("ListByExecution of another tenant returned %d records", len(records))
+	}
+}
+
+// jsonEqual compares JSON documents ignoring formatting, since Postgres normalizes jsonb
+func jsonEqual(got []byte, want string) bool {
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(g, w)
+}
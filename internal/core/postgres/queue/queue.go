@@ -0,0 +1,282 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/metrics"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// PostgresQueue is a TaskQueue backed by the tasks table itself. Push marks the task QUEUED
+// on this queue and NOTIFYs listeners; Pop takes a QUEUED row with FOR UPDATE SKIP LOCKED and
+// moves it to RUNNING in the same statement, so a task can never be handed to two workers and
+// queue membership can't drift from task state. PopClaimed also records the popping worker in
+// that statement, which makes it the worker's claim.
+//
+// Tenants take weighted fair turns as on the Redis queue: task_queue_clocks holds a virtual
+// time per tenant, Pop takes the tenant with the lowest one and then the row with the earliest
+// queued_at - priority × agingInterval, and each turn advances the tenant by 1/weight.
+//
+// A retry delayed by its handler stays QUEUED with its next_attempt_at in the future and isn't
+// popped before then. Nothing is notified when it falls due, so idle poppers take it on their
+// next poll.
+type PostgresQueue struct {
+	db            *gorm.DB
+	dsn           string // Connection string for the dedicated LISTEN connection
+	name          string // Stored in tasks.queue; main and retry queues share the table
+	channel       string // NOTIFY channel
+	pollInterval  time.Duration
+	agingInterval time.Duration
+	weights       domain.TenantWeights
+
+	mu    sync.Mutex
+	ready chan struct{} // Closed and replaced on every notification to wake blocked poppers
+}
+
+// NewPostgresQueue creates the queue. Run Listen so idle poppers wake on NOTIFY; without it
+// they fall back to polling every pollInterval.
+func NewPostgresQueue(db *gorm.DB, dsn string, name string, pollInterval, agingInterval time.Duration, weights domain.TenantWeights) *PostgresQueue {
+	return &PostgresQueue{
+		db:            db,
+		dsn:           dsn,
+		name:          name,
+		channel:       "tempo_queue:" + name,
+		pollInterval:  pollInterval,
+		agingInterval: agingInterval,
+		weights:       weights,
+		ready:         make(chan struct{}),
+	}
+}
+
+// Push queues a PENDING or QUEUED task on this queue. Tasks that are already running
+// or finished are left alone. The priority and tenant are read from the task row, which the
+// argument mirrors.
+func (q *PostgresQueue) Push(ctx context.Context, task *domain.Task) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("queue_push").Observe(time.Since(start).Seconds())
+	}()
+
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// A tenant that had nothing queued rejoins at the queue's virtual time, so it can't
+		// bank the turns it missed while idle
+		err := tx.Exec(`
+			INSERT INTO task_queue_clocks (queue, tenant, virtual_time)
+			VALUES (?, ?, COALESCE((SELECT virtual_time FROM task_queue_clocks WHERE queue = ? AND tenant = ''), 0))
+			ON CONFLICT (queue, tenant) DO UPDATE
+			SET virtual_time = GREATEST(task_queue_clocks.virtual_time, EXCLUDED.virtual_time)`,
+			q.name, task.Tenant, q.name,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`
+			UPDATE tasks
+			SET status = ?, queue = ?, queued_at = NOW()
+			WHERE id = ? AND status IN ?`,
+			domain.StatusQueued, q.name, task.ID, []domain.TaskStatus{domain.StatusPending, domain.StatusQueued},
+		).Error
+		if err != nil {
+			return err
+		}
+		// Delivered on commit, so listeners never see the task before it is visible
+		return tx.Exec(`SELECT pg_notify(?, ?)`, q.channel, task.ID.String()).Error
+	})
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("queue_push").Inc()
+	}
+	return err
+}
+
+// Pop claims the next task queued on this queue, waiting until one is available
+func (q *PostgresQueue) Pop(ctx context.Context) (string, error) {
+	task, err := q.pop(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return task.ID.String(), nil
+}
+
+// PopClaimed claims the next task queued on this queue for workerID, waiting until one is
+// available. The row is returned as claimed, so the worker doesn't fetch or claim it again.
+func (q *PostgresQueue) PopClaimed(ctx context.Context, workerID string) (*domain.Task, error) {
+	return q.pop(ctx, workerID)
+}
+
+func (q *PostgresQueue) pop(ctx context.Context, workerID string) (*domain.Task, error) {
+	for {
+		// Take the wake-up channel before querying so a push racing the query isn't missed
+		q.mu.Lock()
+		ready := q.ready
+		q.mu.Unlock()
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// A claim that commits must reach the caller, so it isn't canceled halfway
+		task, err := q.claimNext(context.WithoutCancel(ctx), workerID)
+		if err != nil {
+			return nil, err
+		}
+		if task != nil {
+			return task, nil
+		}
+
+		timer := time.NewTimer(q.pollInterval)
+		select {
+		case <-ready:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		timer.Stop()
+	}
+}
+
+// claimNext moves the next QUEUED task of this queue to RUNNING, claimed by workerID unless it
+// is empty, and returns the claimed row, or nil if the queue is empty. Rows locked by concurrent poppers
+// are skipped.
+func (q *PostgresQueue) claimNext(ctx context.Context, workerID string) (*domain.Task, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("queue_pop").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		WITH next AS (
+			SELECT t.id, COALESCE(c.virtual_time, 0) AS virtual_time
+			FROM tasks t
+			LEFT JOIN task_queue_clocks c ON c.queue = t.queue AND c.tenant = t.tenant
+			WHERE t.status = ? AND t.queue = ? AND (t.next_attempt_at IS NULL OR t.next_attempt_at <= NOW())
+			ORDER BY COALESCE(c.virtual_time, 0), t.queued_at - make_interval(secs => t.priority * ?)
+			LIMIT 1
+			FOR UPDATE OF t SKIP LOCKED
+		)
+		UPDATE tasks
+		SET status = ?, worker_id = NULLIF(?, ''), started_at = NOW(), version = tasks.version + 1,
+			progress_percent = NULL, progress_message = '', progress_details = NULL, progress_updated_at = NULL
+		FROM next
+		WHERE tasks.id = next.id
+		RETURNING tasks.*, next.virtual_time
+	`
+
+	var claimed []struct {
+		domain.Task
+		VirtualTime float64
+	}
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw(query, domain.StatusQueued, q.name, q.agingInterval.Seconds(), domain.StatusRunning, workerID).
+			Scan(&claimed).Error
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+
+		// The queue's virtual time moves up to the turn just served; the tenant pays 1/weight
+		next := claimed[0]
+		err = tx.Exec(`
+			INSERT INTO task_queue_clocks (queue, tenant, virtual_time) VALUES (?, '', ?)
+			ON CONFLICT (queue, tenant) DO UPDATE
+			SET virtual_time = GREATEST(task_queue_clocks.virtual_time, EXCLUDED.virtual_time)`,
+			q.name, next.VirtualTime,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		cost := 1 / float64(q.weights.Of(next.Tenant))
+		return tx.Exec(`
+			INSERT INTO task_queue_clocks (queue, tenant, virtual_time) VALUES (?, ?, ?)
+			ON CONFLICT (queue, tenant) DO UPDATE
+			SET virtual_time = task_queue_clocks.virtual_time + ?`,
+			q.name, next.Tenant, next.VirtualTime+cost, cost,
+		).Error
+	})
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("queue_pop").Inc()
+		return nil, err
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+	return &claimed[0].Task, nil
+}
+
+// DepthByPriority counts the tasks QUEUED on this queue per priority
+func (q *PostgresQueue) DepthByPriority(ctx context.Context) (map[int]int64, error) {
+	var rows []struct {
+		Priority int
+		Count    int64
+	}
+	err := q.db.WithContext(ctx).
+		Raw(`SELECT priority, COUNT(*) AS count FROM tasks WHERE status = ? AND queue = ? GROUP BY priority`,
+			domain.StatusQueued, q.name).
+		Scan(&rows).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("queue_depth").Inc()
+		return nil, err
+	}
+
+	depth := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		depth[row.Priority] = row.Count
+	}
+	return depth, nil
+}
+
+// Listen holds a dedicated connection LISTENing on the queue's channel and wakes blocked
+// poppers on every notification, reconnecting until ctx is canceled
+func (q *PostgresQueue) Listen(ctx context.Context) {
+	for {
+		err := q.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Queue %s listener disconnected, retrying: %v", q.name, err)
+
+		// Notifications may have been missed while disconnected
+		q.wake()
+
+		select {
+		case <-time.After(q.pollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *PostgresQueue) listenOnce(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, q.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{q.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+		q.wake()
+	}
+}
+
+func (q *PostgresQueue) wake() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	close(q.ready)
+	q.ready = make(chan struct{})
+}
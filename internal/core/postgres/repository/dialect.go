@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// The repositories are written for Postgres and also run on SQLite (--store=sqlite:path).
+// These helpers cover the few statements whose syntax differs between the two.
+
+func isSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == "sqlite"
+}
+
+// dependsOnCondition returns the condition and argument matching tasks whose
+// dependencies JSON array contains refID
+func dependsOnCondition(db *gorm.DB, refID string) (string, interface{}) {
+	if isSQLite(db) {
+		return "EXISTS (SELECT 1 FROM json_each(tasks.dependencies) WHERE json_each.value = ?)", refID
+	}
+	return "dependencies @> ?", fmt.Sprintf(`["%s"]`, refID)
+}
+
+// skipLockedClause locks the selected rows against concurrent claimers. SQLite allows
+// a single writer, so the claiming statement is already exclusive there.
+func skipLockedClause(db *gorm.DB) string {
+	if isSQLite(db) {
+		return ""
+	}
+	return "FOR UPDATE SKIP LOCKED"
+}
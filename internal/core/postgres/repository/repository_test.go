@@ -0,0 +1,82 @@
+package repository_test
+
+import (
+	"context"
+	"go-tempo/internal/core/ports/portstest"
+	"go-tempo/internal/core/postgres/migrate"
+	"go-tempo/internal/core/postgres/repository"
+	"go-tempo/migrations"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSQLiteConformance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tempo.db")
+	db, err := gorm.Open(sqlite.Open(path+"?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on"), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+		Logger:  logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrate.NewSQLiteMigrator(sqlDB, migrations.SQLiteFS)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	runConformance(t, db)
+}
+
+// TestPostgresConformance runs against the database of TEMPO_TEST_DATABASE_URL. Every test
+// works in a tenant of its own, so a database shared with other data is fine.
+func TestPostgresConformance(t *testing.T) {
+	url := os.Getenv("TEMPO_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEMPO_TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(url), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrate.NewMigrator(sqlDB, migrations.FS)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	runConformance(t, db)
+}
+
+func runConformance(t *testing.T, db *gorm.DB) {
+	portstest.Run(t, func(t *testing.T) portstest.Repositories {
+		return portstest.Repositories{
+			Tasks:     repository.NewTaskRepository(db),
+			Workflows: repository.NewWorkflowRepository(db),
+			Webhooks:  repository.NewWebhookRepository(db),
+			History:   repository.NewWorkflowEventRepository(db),
+		}
+	})
+}
@@ -2,7 +2,7 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"go-tempo/internal/domain"
 	"go-tempo/internal/metrics"
 	"time"
@@ -60,7 +60,7 @@ func (r *taskRepository) FindTaskByID(ctx context.Context, id uuid.UUID) (*domai
 	}()
 	
 	var task domain.Task
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&task).Error
+	err := scoped(ctx, r.db).Where("id = ?", id).First(&task).Error
 	if err != nil {
 		if err != gorm.ErrRecordNotFound {
 			metrics.DBQueryErrorsTotal.WithLabelValues("find_task").Inc()
@@ -76,13 +76,18 @@ func (r *taskRepository) ClaimTask(ctx context.Context, taskID uuid.UUID, worker
 		metrics.DBQueryDuration.WithLabelValues("claim_task").Observe(time.Since(start).Seconds())
 	}()
 	
-	result := r.db.WithContext(ctx).
+	result := scoped(ctx, r.db).
 		Model(&domain.Task{}).
 		Where("id = ? AND version = ?", taskID, currentVersion).
 		Updates(map[string]interface{}{
-			"status":    domain.StatusRunning,
-			"worker_id": workerID,
-			"version":   currentVersion + 1,
+			"status":              domain.StatusRunning,
+			"worker_id":           workerID,
+			"started_at":          time.Now(),
+			"version":             currentVersion + 1,
+			"progress_percent":    nil,
+			"progress_message":    "",
+			"progress_details":    nil,
+			"progress_updated_at": nil,
 		})
 	
 	if result.Error != nil {
@@ -102,9 +107,10 @@ func (r *taskRepository) ClaimTask(ctx context.Context, taskID uuid.UUID, worker
 func (r *taskRepository) FindChildren(ctx context.Context, executionID uuid.UUID, parentName string) ([]domain.Task, error) {
 	var tasks []domain.Task
 	// Find tasks where dependencies JSON array contains the parentName
-	err := r.db.WithContext(ctx).
+	condition, arg := dependsOnCondition(r.db, parentName)
+	err := scoped(ctx, r.db).
 		Where("execution_id = ?", executionID).
-		Where("dependencies @> ?", `["`+parentName+`"]`).
+		Where(condition, arg).
 		Find(&tasks).Error
 	
 	return tasks, err
@@ -116,7 +122,7 @@ func (r *taskRepository) MarkCompleted(ctx context.Context, taskID uuid.UUID, ou
 		metrics.DBQueryDuration.WithLabelValues("mark_completed").Observe(time.Since(start).Seconds())
 	}()
 	
-	err := r.db.WithContext(ctx).
+	err := scoped(ctx, r.db).
 		Model(&domain.Task{}).
 		Where("id = ?", taskID).
 		Updates(map[string]interface{}{
@@ -130,19 +136,19 @@ func (r *taskRepository) MarkCompleted(ctx context.Context, taskID uuid.UUID, ou
 	return err
 }
 
-func (r *taskRepository) MarkFailed(ctx context.Context, taskID uuid.UUID, errMessage string) error {
+func (r *taskRepository) MarkFailed(ctx context.Context, taskID uuid.UUID, errMessage string, output datatypes.JSON) error {
 	start := time.Now()
 	defer func() {
 		metrics.DBQueryDuration.WithLabelValues("mark_failed").Observe(time.Since(start).Seconds())
 	}()
 	
-	err := r.db.WithContext(ctx).
+	err := scoped(ctx, r.db).
 		Model(&domain.Task{}).
 		Where("id = ?", taskID).
 		Updates(map[string]interface{}{
 			"status":     domain.StatusFailed,
 			"last_error": errMessage,
-			"output":     datatypes.JSON([]byte(`{"error":"` + errMessage + `"}`)),
+			"output":     domain.FailureOutput(errMessage, output),
 		}).Error
 	
 	if err != nil {
@@ -151,18 +157,18 @@ func (r *taskRepository) MarkFailed(ctx context.Context, taskID uuid.UUID, errMe
 	return err
 }
 
-func (r *taskRepository) MarkSkipped(ctx context.Context, taskID uuid.UUID) error {
+func (r *taskRepository) MarkSkipped(ctx context.Context, taskID uuid.UUID, reason string) error {
 	start := time.Now()
 	defer func() {
 		metrics.DBQueryDuration.WithLabelValues("mark_skipped").Observe(time.Since(start).Seconds())
 	}()
 	
-	err := r.db.WithContext(ctx).
+	err := scoped(ctx, r.db).
 		Model(&domain.Task{}).
 		Where("id = ?", taskID).
 		Updates(map[string]interface{}{
 			"status": domain.StatusSkipped,
-			"output": datatypes.JSON([]byte(`{"skipped": true, "reason": "parent task failed"}`)),
+			"output": domain.SkippedOutput(reason),
 		}).Error
 	
 	if err != nil {
@@ -171,19 +177,46 @@ func (r *taskRepository) MarkSkipped(ctx context.Context, taskID uuid.UUID) erro
 	return err
 }
 
-func (r *taskRepository) IncrementRetryCount(ctx context.Context, taskID uuid.UUID, currentVersion int) error {
+func (r *taskRepository) UpdateProgress(ctx context.Context, taskID uuid.UUID, progress domain.TaskProgress) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("update_progress").Observe(time.Since(start).Seconds())
+	}()
+
+	var details interface{}
+	if len(progress.Details) > 0 {
+		details = datatypes.JSON(progress.Details)
+	}
+	err := scoped(ctx, r.db).
+		Model(&domain.Task{}).
+		Where("id = ? AND status = ?", taskID, domain.StatusRunning).
+		Updates(map[string]interface{}{
+			"progress_percent":    progress.Percent,
+			"progress_message":    progress.Message,
+			"progress_details":    details,
+			"progress_updated_at": progress.UpdatedAt,
+		}).Error
+
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("update_progress").Inc()
+	}
+	return err
+}
+
+func (r *taskRepository) IncrementRetryCount(ctx context.Context, taskID uuid.UUID, currentVersion int, nextAttemptAt *time.Time) error {
 	start := time.Now()
 	defer func() {
 		metrics.DBQueryDuration.WithLabelValues("increment_retry").Observe(time.Since(start).Seconds())
 	}()
 	
-	result := r.db.WithContext(ctx).
+	result := scoped(ctx, r.db).
 		Model(&domain.Task{}).
 		Where("id = ? AND version = ?", taskID, currentVersion).
 		Updates(map[string]interface{}{
-			"retry_count": gorm.Expr("retry_count + 1"),
-			"status":      domain.StatusPending,
-			"version":     currentVersion + 1,
+			"retry_count":     gorm.Expr("retry_count + 1"),
+			"status":          domain.StatusPending,
+			"next_attempt_at": nextAttemptAt,
+			"version":         currentVersion + 1,
 		})
 	
 	if result.Error != nil {
@@ -199,6 +232,34 @@ func (r *taskRepository) IncrementRetryCount(ctx context.Context, taskID uuid.UU
 	return nil
 }
 
+func (r *taskRepository) ReleaseTask(ctx context.Context, taskID uuid.UUID, currentVersion int) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("release_task").Observe(time.Since(start).Seconds())
+	}()
+	
+	result := scoped(ctx, r.db).
+		Model(&domain.Task{}).
+		Where("id = ? AND version = ? AND status = ?", taskID, currentVersion, domain.StatusRunning).
+		Updates(map[string]interface{}{
+			"status":    domain.StatusQueued,
+			"worker_id": nil,
+			"version":   currentVersion + 1,
+		})
+	
+	if result.Error != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("release_task").Inc()
+		return result.Error
+	}
+	
+	if result.RowsAffected == 0 {
+		metrics.DBOptimisticLockConflictsTotal.WithLabelValues("release_task").Inc()
+		return gorm.ErrRecordNotFound
+	}
+	
+	return nil
+}
+
 func (r *taskRepository) DecrementAndGetReadyTasks(ctx context.Context, executionID uuid.UUID, completedRefID string) ([]uuid.UUID, error) {
 	start := time.Now()
 	defer func() {
@@ -207,17 +268,16 @@ func (r *taskRepository) DecrementAndGetReadyTasks(ctx context.Context, executio
 	
 	var readyTaskIDs []uuid.UUID
 
+	condition, depParam := dependsOnCondition(r.db, completedRefID)
 	query := `
 		UPDATE tasks 
 		SET in_degree = in_degree - 1,
 		    status = CASE WHEN in_degree - 1 = 0 THEN 'QUEUED' ELSE status END
 		WHERE execution_id = ? 
-		  AND dependencies @> ?
+		  AND ` + condition + `
 		RETURNING id, in_degree
 	`
 
-	depParam := fmt.Sprintf(`["%s"]`, completedRefID)
-
 	rows, err := r.db.WithContext(ctx).Raw(query, executionID, depParam).Rows()
 	if err != nil {
 		metrics.DBQueryErrorsTotal.WithLabelValues("decrement_ready").Inc()
@@ -248,18 +308,17 @@ func (r *taskRepository) DecrementAndSetSkipHint(ctx context.Context, executionI
 	
 	var readyTaskIDs []uuid.UUID
 
+	condition, depParam := dependsOnCondition(r.db, failedRefID)
 	query := `
 		UPDATE tasks 
 		SET in_degree = in_degree - 1,
 		    skip_hint = true,
 		    status = CASE WHEN in_degree - 1 = 0 THEN 'QUEUED' ELSE status END
 		WHERE execution_id = ? 
-		  AND dependencies @> ?
+		  AND ` + condition + `
 		RETURNING id, in_degree
 	`
 
-	depParam := fmt.Sprintf(`["%s"]`, failedRefID)
-
 	rows, err := r.db.WithContext(ctx).Raw(query, executionID, depParam).Rows()
 	if err != nil {
 		metrics.DBQueryErrorsTotal.WithLabelValues("decrement_skip").Inc()
@@ -289,9 +348,10 @@ func (r *taskRepository) AreAllTasksCompleted(ctx context.Context, executionID u
 	}()
 	
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := scoped(ctx, r.db).
 		Model(&domain.Task{}).
-		Where("execution_id = ? AND status != ?", executionID, domain.StatusCompleted).
+		Where("execution_id = ? AND status NOT IN ?", executionID,
+			[]domain.TaskStatus{domain.StatusCompleted, domain.StatusSkipped}).
 		Count(&count).Error
 	
 	if err != nil {
@@ -301,3 +361,197 @@ func (r *taskRepository) AreAllTasksCompleted(ctx context.Context, executionID u
 	
 	return count == 0, nil
 }
+
+func (r *taskRepository) AreAllTasksTerminal(ctx context.Context, executionID uuid.UUID) (bool, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("check_terminal").Observe(time.Since(start).Seconds())
+	}()
+
+	var count int64
+	err := scoped(ctx, r.db).
+		Model(&domain.Task{}).
+		Where("execution_id = ? AND status NOT IN ?", executionID,
+			[]domain.TaskStatus{domain.StatusCompleted, domain.StatusFailed, domain.StatusSkipped}).
+		Count(&count).Error
+
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("check_terminal").Inc()
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+func (r *taskRepository) CountWaitingTasks(ctx context.Context) (int64, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("count_waiting_tasks").Observe(time.Since(start).Seconds())
+	}()
+
+	var count int64
+	err := waiting(scoped(ctx, r.db)).
+		Model(&domain.Task{}).
+		Count(&count).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("count_waiting_tasks").Inc()
+	}
+	return count, err
+}
+
+func (r *taskRepository) SetLease(ctx context.Context, taskID uuid.UUID, currentVersion int, tokenHash string, expiresAt time.Time) error {
+	return r.updateLease(ctx, "set_lease",
+		scoped(ctx, r.db).Model(&domain.Task{}).
+			Where("id = ? AND version = ? AND status = ?", taskID, currentVersion, domain.StatusRunning),
+		map[string]interface{}{"lease_token_hash": tokenHash, "lease_expires_at": expiresAt},
+	)
+}
+
+func (r *taskRepository) RenewLease(ctx context.Context, taskID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	return r.updateLease(ctx, "renew_lease",
+		scoped(ctx, r.db).Model(&domain.Task{}).
+			Where("id = ? AND lease_token_hash = ? AND status = ?", taskID, tokenHash, domain.StatusRunning),
+		map[string]interface{}{"lease_expires_at": expiresAt},
+	)
+}
+
+func (r *taskRepository) EndLease(ctx context.Context, taskID uuid.UUID, tokenHash string) error {
+	return r.updateLease(ctx, "end_lease",
+		scoped(ctx, r.db).Model(&domain.Task{}).
+			Where("id = ? AND lease_token_hash = ? AND status = ?", taskID, tokenHash, domain.StatusRunning),
+		map[string]interface{}{"lease_token_hash": nil, "lease_expires_at": nil},
+	)
+}
+
+// updateLease applies a lease update, reporting gorm.ErrRecordNotFound if no row matched
+func (r *taskRepository) updateLease(ctx context.Context, operation string, query *gorm.DB, updates map[string]interface{}) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	}()
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues(operation).Inc()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *taskRepository) FindExpiredLeases(ctx context.Context, now time.Time, limit int) ([]domain.Task, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("find_expired_leases").Observe(time.Since(start).Seconds())
+	}()
+
+	var tasks []domain.Task
+	err := scoped(ctx, r.db).
+		Where("lease_expires_at < ? AND status = ?", now, domain.StatusRunning).
+		Order("lease_expires_at").
+		Limit(limit).
+		Find(&tasks).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("find_expired_leases").Inc()
+	}
+	return tasks, err
+}
+
+func (r *taskRepository) CancelExecution(ctx context.Context, executionID uuid.UUID) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("cancel_execution").Observe(time.Since(start).Seconds())
+	}()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := scoped(ctx, tx).
+			Model(&domain.WorkflowExecution{}).
+			Where("id = ? AND status = ?", executionID, domain.WorkflowRunning).
+			Update("status", domain.WorkflowCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Running tasks finish; the rest are skipped when popped, which propagates to their children
+		return tx.Model(&domain.Task{}).
+			Where("execution_id = ? AND status IN ?", executionID, []domain.TaskStatus{domain.StatusPending, domain.StatusQueued}).
+			Update("skip_hint", true).Error
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("cancel_execution").Inc()
+		metrics.DBTransactionsTotal.WithLabelValues("failed").Inc()
+		return err
+	}
+
+	metrics.DBTransactionsTotal.WithLabelValues("success").Inc()
+	return nil
+}
+
+func (r *taskRepository) FindTasksByExecution(ctx context.Context, executionID uuid.UUID) ([]domain.Task, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("find_tasks_by_execution").Observe(time.Since(start).Seconds())
+	}()
+
+	var tasks []domain.Task
+	err := scoped(ctx, r.db).
+		Where("execution_id = ?", executionID).
+		Order("created_at, ref_id").
+		Find(&tasks).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("find_tasks_by_execution").Inc()
+	}
+	return tasks, err
+}
+
+func (r *taskRepository) FindWaitingTasks(ctx context.Context) ([]domain.Task, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("find_waiting_tasks").Observe(time.Since(start).Seconds())
+	}()
+
+	var tasks []domain.Task
+	err := waiting(scoped(ctx, r.db)).
+		Order("created_at, ref_id").
+		Find(&tasks).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("find_waiting_tasks").Inc()
+	}
+	return tasks, err
+}
+
+// DeleteExecution deletes the execution; its tasks, history and deliveries go with it by the
+// ON DELETE CASCADE of their foreign keys
+func (r *taskRepository) DeleteExecution(ctx context.Context, executionID uuid.UUID) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("delete_execution").Observe(time.Since(start).Seconds())
+	}()
+
+	result := scoped(ctx, r.db).
+		Where("id = ?", executionID).
+		Delete(&domain.WorkflowExecution{})
+	if result.Error != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("delete_execution").Inc()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// waiting restricts a query to tasks waiting in a task queue: QUEUED tasks, and PENDING tasks
+// with no unfinished parents
+func waiting(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ? OR (status = ? AND in_degree = 0)", domain.StatusQueued, domain.StatusPending)
+}
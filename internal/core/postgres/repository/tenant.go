@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"go-tempo/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// scoped starts a query on a table with a tenant column, limited to the tenant ctx is scoped
+// to. API requests carry a tenant; the coordinator, workers and dispatcher see every tenant.
+func scoped(ctx context.Context, db *gorm.DB) *gorm.DB {
+	query := db.WithContext(ctx)
+	if tenant, ok := domain.TenantFromContext(ctx); ok {
+		query = query.Where("tenant = ?", tenant)
+	}
+	return query
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/metrics"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new instance of WebhookRepository
+func NewWebhookRepository(db *gorm.DB) ports.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("create_webhook_subscription").Observe(time.Since(start).Seconds())
+	}()
+
+	err := r.db.WithContext(ctx).Create(sub).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("create_webhook_subscription").Inc()
+	}
+	return err
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("list_webhook_subscriptions").Observe(time.Since(start).Seconds())
+	}()
+
+	var subs []domain.WebhookSubscription
+	err := scoped(ctx, r.db).Order("created_at").Find(&subs).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("list_webhook_subscriptions").Inc()
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("delete_webhook_subscription").Observe(time.Since(start).Seconds())
+	}()
+
+	result := scoped(ctx, r.db).Where("id = ?", id).Delete(&domain.WebhookSubscription{})
+	if result.Error != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("delete_webhook_subscription").Inc()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("create_webhook_deliveries").Observe(time.Since(start).Seconds())
+	}()
+
+	err := r.db.WithContext(ctx).Create(&deliveries).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("create_webhook_deliveries").Inc()
+	}
+	return err
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("update_webhook_delivery").Observe(time.Since(start).Seconds())
+	}()
+
+	err := scoped(ctx, r.db).
+		Model(&domain.WebhookDelivery{}).
+		Where("id = ?", delivery.ID).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"last_error":      delivery.LastError,
+			"response_code":   delivery.ResponseCode,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"delivered_at":    delivery.DeliveredAt,
+		}).Error
+
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("update_webhook_delivery").Inc()
+	}
+	return err
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("list_webhook_deliveries").Observe(time.Since(start).Seconds())
+	}()
+
+	query := scoped(ctx, r.db).Model(&domain.WebhookDelivery{})
+	if filter.ExecutionID != uuid.Nil {
+		query = query.Where("execution_id = ?", filter.ExecutionID)
+	}
+	if filter.SubscriptionID != uuid.Nil {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var deliveries []domain.WebhookDelivery
+	err := query.Order("created_at DESC").Find(&deliveries).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("list_webhook_deliveries").Inc()
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries pushes next_attempt_at forward by the lease in the same statement
+// that selects the rows, so concurrent dispatchers never send the same delivery twice.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("claim_webhook_deliveries").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			` + skipLockedClause(r.db) + `
+		)
+		RETURNING *
+	`
+
+	now := time.Now()
+	var deliveries []domain.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Raw(query, now.Add(lease), domain.DeliveryPending, now, limit).
+		Scan(&deliveries).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("claim_webhook_deliveries").Inc()
+		return nil, err
+	}
+	return deliveries, nil
+}
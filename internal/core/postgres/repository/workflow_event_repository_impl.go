@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/metrics"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type workflowEventRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkflowEventRepository creates a new instance of WorkflowEventRepository
+func NewWorkflowEventRepository(db *gorm.DB) ports.WorkflowEventRepository {
+	return &workflowEventRepository{db: db}
+}
+
+func (r *workflowEventRepository) Append(ctx context.Context, record *domain.WorkflowEventRecord) error {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("append_workflow_event").Observe(time.Since(start).Seconds())
+	}()
+
+	err := r.db.WithContext(ctx).Create(record).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("append_workflow_event").Inc()
+	}
+	return err
+}
+
+func (r *workflowEventRepository) ListByExecution(ctx context.Context, executionID uuid.UUID) ([]domain.WorkflowEventRecord, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("list_workflow_events").Observe(time.Since(start).Seconds())
+	}()
+
+	query := r.db.WithContext(ctx).Where("execution_id = ?", executionID)
+	if tenant, ok := domain.TenantFromContext(ctx); ok {
+		// Events carry no tenant of their own; they belong to their execution's
+		query = query.Where("execution_id IN (SELECT id FROM workflow_executions WHERE tenant = ?)", tenant)
+	}
+
+	var records []domain.WorkflowEventRecord
+	err := query.Order("id").Find(&records).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("list_workflow_events").Inc()
+		return nil, err
+	}
+	return records, nil
+}
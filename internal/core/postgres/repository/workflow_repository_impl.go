@@ -40,7 +40,7 @@ func (r *workflowRepository) GetByID(ctx context.Context, executionID uuid.UUID)
 	}()
 	
 	var execution domain.WorkflowExecution
-	err := r.db.WithContext(ctx).Where("id = ?", executionID).First(&execution).Error
+	err := scoped(ctx, r.db).Where("id = ?", executionID).First(&execution).Error
 	if err != nil {
 		if err != gorm.ErrRecordNotFound {
 			metrics.DBQueryErrorsTotal.WithLabelValues("get_workflow").Inc()
@@ -50,25 +50,144 @@ func (r *workflowRepository) GetByID(ctx context.Context, executionID uuid.UUID)
 	return &execution, nil
 }
 
+func (r *workflowRepository) GetByIdempotencyKey(ctx context.Context, key string) (*domain.WorkflowExecution, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("get_workflow_by_idempotency_key").Observe(time.Since(start).Seconds())
+	}()
+
+	var execution domain.WorkflowExecution
+	err := scoped(ctx, r.db).Where("idempotency_key = ?", key).First(&execution).Error
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			metrics.DBQueryErrorsTotal.WithLabelValues("get_workflow_by_idempotency_key").Inc()
+		}
+		return nil, err
+	}
+	return &execution, nil
+}
+
 // UpdateStatus updates the workflow execution status.
 // The status check in the WHERE clause prevents duplicate updates when multiple terminal tasks
 // (tasks with no children) complete simultaneously. Each completion triggers a workflow check,
-// but only the first one will actually update the status - subsequent attempts will be no-ops
-// since the status is already set. This eliminates duplicate "workflow completed" log messages.
-// Additionally, once a workflow is FAILED, it cannot be overwritten to COMPLETED.
-func (r *workflowRepository) UpdateStatus(ctx context.Context, executionID uuid.UUID, status string) error {
+// but only the first one will actually update the status - subsequent attempts affect no rows
+// and return false. Once a workflow is COMPLETED, FAILED or CANCELLED it is never overwritten.
+func (r *workflowRepository) UpdateStatus(ctx context.Context, executionID uuid.UUID, status string) (bool, error) {
 	start := time.Now()
 	defer func() {
 		metrics.DBQueryDuration.WithLabelValues("update_workflow_status").Observe(time.Since(start).Seconds())
 	}()
 	
-	err := r.db.WithContext(ctx).
+	result := scoped(ctx, r.db).
 		Model(&domain.WorkflowExecution{}).
-		Where("id = ? AND status != ? AND status != 'FAILED'", executionID, status).
-		Update("status", status).Error
+		Where("id = ? AND status != ? AND status NOT IN ('COMPLETED', 'FAILED', 'CANCELLED')", executionID, status).
+		Update("status", status)
 	
-	if err != nil {
+	if result.Error != nil {
 		metrics.DBQueryErrorsTotal.WithLabelValues("update_workflow_status").Inc()
+		return false, result.Error
 	}
-	return err
+	return result.RowsAffected == 1, nil
+}
+
+// MarkFinished sets finished_at if it isn't set yet. Of several coordinators or events racing
+// to announce the final status, only the one whose update affected the row gets true.
+func (r *workflowRepository) MarkFinished(ctx context.Context, executionID uuid.UUID) (bool, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("mark_workflow_finished").Observe(time.Since(start).Seconds())
+	}()
+
+	result := scoped(ctx, r.db).
+		Model(&domain.WorkflowExecution{}).
+		Where("id = ? AND finished_at IS NULL", executionID).
+		Update("finished_at", time.Now())
+
+	if result.Error != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("mark_workflow_finished").Inc()
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *workflowRepository) List(ctx context.Context, filter domain.WorkflowFilter) ([]domain.WorkflowExecution, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("list_workflows").Observe(time.Since(start).Seconds())
+	}()
+
+	query := applyWorkflowFilter(scoped(ctx, r.db).Model(&domain.WorkflowExecution{}), filter)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.After != nil {
+		// Row comparison keeps pagination stable when created_at values collide
+		query = query.Where("(created_at, id) < (?, ?)", filter.After.CreatedAt, filter.After.ID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var executions []domain.WorkflowExecution
+	err := query.Order("created_at DESC, id DESC").Find(&executions).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("list_workflows").Inc()
+		return nil, err
+	}
+	return executions, nil
+}
+
+func (r *workflowRepository) CountByStatus(ctx context.Context, filter domain.WorkflowFilter) (map[domain.WorkflowStatus]int64, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DBQueryDuration.WithLabelValues("count_workflows").Observe(time.Since(start).Seconds())
+	}()
+
+	var rows []struct {
+		Status domain.WorkflowStatus
+		Count  int64
+	}
+	err := applyWorkflowFilter(scoped(ctx, r.db).Model(&domain.WorkflowExecution{}), filter).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		metrics.DBQueryErrorsTotal.WithLabelValues("count_workflows").Inc()
+		return nil, err
+	}
+
+	counts := make(map[domain.WorkflowStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// applyWorkflowFilter adds the filter conditions shared by List and CountByStatus
+func applyWorkflowFilter(query *gorm.DB, filter domain.WorkflowFilter) *gorm.DB {
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.WorkflowType != "" {
+		query = query.Where("workflow_type = ?", filter.WorkflowType)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		query = query.Where("updated_at >= ?", filter.UpdatedAfter)
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		query = query.Where("updated_at < ?", filter.UpdatedBefore)
+	}
+	if filter.FailingAction != "" {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM tasks WHERE tasks.execution_id = workflow_executions.id AND tasks.action = ? AND tasks.status = ?)",
+			filter.FailingAction, domain.StatusFailed,
+		)
+	}
+	return query
 }
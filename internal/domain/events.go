@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -9,6 +11,9 @@ type TaskCompletedEvent struct {
 	ExecutionID uuid.UUID `json:"execution_id"`
 	TaskID      uuid.UUID `json:"task_id"`
 	RefID       string    `json:"ref_id"` // e.g., "step_1"
+
+	// Set when the task's handler chose to skip it; its children still run
+	Skipped bool `json:"skipped,omitempty"`
 }
 
 type TaskTerminationType string
@@ -39,3 +44,170 @@ func NewTaskTerminatedEvent(executionID, taskID uuid.UUID, refID string, termina
 	}
 }
 
+// ToWorkflowEvent converts the completion into a task.completed or task.skipped lifecycle event
+func (e TaskCompletedEvent) ToWorkflowEvent() WorkflowEvent {
+	eventType := EventTaskCompleted
+	if e.Skipped {
+		eventType = EventTaskSkipped
+	}
+	return WorkflowEvent{
+		ExecutionID: e.ExecutionID,
+		Type:        eventType,
+		TaskID:      e.TaskID,
+		RefID:       e.RefID,
+		Timestamp:   time.Now(),
+	}
+}
+
+// ToWorkflowEvent converts the termination into a task.failed or task.skipped lifecycle event
+func (e TaskTerminatedEvent) ToWorkflowEvent() WorkflowEvent {
+	eventType := EventTaskFailed
+	if e.Type == TaskTerminationSkipped {
+		eventType = EventTaskSkipped
+	}
+	return WorkflowEvent{
+		ExecutionID: e.ExecutionID,
+		Type:        eventType,
+		TaskID:      e.TaskID,
+		RefID:       e.RefID,
+		Error:       e.Error,
+		Timestamp:   time.Now(),
+	}
+}
+
+type WorkflowEventType string
+
+const (
+	EventWorkflowSubmitted WorkflowEventType = "workflow.submitted"
+	EventTaskQueued        WorkflowEventType = "task.queued"
+	EventTaskStarted       WorkflowEventType = "task.started" // Task claimed by a worker
+	EventTaskAttemptFailed WorkflowEventType = "task.attempt_failed"
+	EventTaskRetried       WorkflowEventType = "task.retried"
+	EventTaskCompleted     WorkflowEventType = "task.completed"
+	EventTaskFailed        WorkflowEventType = "task.failed"
+	EventTaskSkipped       WorkflowEventType = "task.skipped"
+	EventTaskProgress      WorkflowEventType = "task.progress" // Streamed only; not recorded in the history or sent to webhooks
+	EventWorkflowCompleted WorkflowEventType = "workflow.completed"
+	EventWorkflowFailed    WorkflowEventType = "workflow.failed"
+	EventWorkflowCancelled WorkflowEventType = "workflow.cancelled"
+)
+
+// IsWebhookEventType reports whether t names a lifecycle event the coordinator delivers to webhooks.
+// The submitted, started, attempt_failed, retried and progress events are only streamed to API clients.
+func IsWebhookEventType(t WorkflowEventType) bool {
+	switch t {
+	case EventTaskQueued, EventTaskCompleted, EventTaskFailed, EventTaskSkipped,
+		EventWorkflowCompleted, EventWorkflowFailed, EventWorkflowCancelled:
+		return true
+	}
+	return false
+}
+
+// WorkflowEvent is a lifecycle event scoped to one workflow execution.
+// It is streamed to API clients, unlike the completed/terminated events which drive the Coordinator.
+type WorkflowEvent struct {
+	ID          string            `json:"id"` // Assigned by the event history on publish
+	ExecutionID uuid.UUID         `json:"execution_id"`
+	Type        WorkflowEventType `json:"type"`
+	TaskID      uuid.UUID         `json:"task_id,omitempty"`
+	RefID       string            `json:"ref_id,omitempty"`
+	Action      string            `json:"action,omitempty"`
+	Attempt     int               `json:"attempt,omitempty"`
+	WorkerID    string            `json:"worker_id,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Status      string            `json:"status,omitempty"` // Workflow status for workflow.* events
+	Progress    *TaskProgress     `json:"progress,omitempty"` // Set for task.progress events
+	Timestamp   time.Time         `json:"timestamp"`
+}
+
+// NewTaskEvent creates a lifecycle event for a task
+func NewTaskEvent(eventType WorkflowEventType, task *Task, errorMsg string) WorkflowEvent {
+	return WorkflowEvent{
+		ExecutionID: task.ExecutionID,
+		Type:        eventType,
+		TaskID:      task.ID,
+		RefID:       task.RefID,
+		Action:      task.Action,
+		Attempt:     task.RetryCount + 1,
+		Error:       errorMsg,
+		Timestamp:   time.Now(),
+	}
+}
+
+// NewTaskProgressEvent creates a task.progress event
+func NewTaskProgressEvent(task *Task, progress TaskProgress) WorkflowEvent {
+	event := NewTaskEvent(EventTaskProgress, task, "")
+	event.Progress = &progress
+	return event
+}
+
+// NewWorkflowSubmittedEvent creates the first event of a workflow execution
+func NewWorkflowSubmittedEvent(execution *WorkflowExecution) WorkflowEvent {
+	return WorkflowEvent{
+		ExecutionID: execution.ID,
+		Type:        EventWorkflowSubmitted,
+		Status:      string(execution.Status),
+		Timestamp:   time.Now(),
+	}
+}
+
+// NewWorkflowStatusEvent creates the final event of a workflow execution
+func NewWorkflowStatusEvent(executionID uuid.UUID, status WorkflowStatus) WorkflowEvent {
+	eventType := EventWorkflowCompleted
+	switch status {
+	case WorkflowFailed:
+		eventType = EventWorkflowFailed
+	case WorkflowCancelled:
+		eventType = EventWorkflowCancelled
+	}
+	return WorkflowEvent{
+		ExecutionID: executionID,
+		Type:        eventType,
+		Status:      string(status),
+		Timestamp:   time.Now(),
+	}
+}
+
+// IsFinal reports whether no further events follow this one for the execution
+func (e WorkflowEvent) IsFinal() bool {
+	return e.Type == EventWorkflowCompleted || e.Type == EventWorkflowFailed || e.Type == EventWorkflowCancelled
+}
+
+// WorkflowEventRecord is the persisted, append-only form of a WorkflowEvent
+type WorkflowEventRecord struct {
+	ID          int64             `gorm:"primaryKey;autoIncrement"`
+	ExecutionID uuid.UUID         `gorm:"type:uuid;index;not null"`
+	Type        WorkflowEventType `gorm:"type:varchar(50);not null"`
+	TaskID      *uuid.UUID        `gorm:"type:uuid"`
+	RefID       string            `gorm:"type:varchar(100)"`
+	Action      string            `gorm:"type:varchar(100)"`
+	Attempt     int
+	WorkerID    string    `gorm:"type:varchar(100)"`
+	Error       string    `gorm:"type:text"`
+	Status      string    `gorm:"type:varchar(20)"`
+	OccurredAt  time.Time `gorm:"not null"`
+}
+
+func (WorkflowEventRecord) TableName() string {
+	return "workflow_events"
+}
+
+// NewWorkflowEventRecord converts a lifecycle event for persistence
+func NewWorkflowEventRecord(event WorkflowEvent) *WorkflowEventRecord {
+	record := &WorkflowEventRecord{
+		ExecutionID: event.ExecutionID,
+		Type:        event.Type,
+		RefID:       event.RefID,
+		Action:      event.Action,
+		Attempt:     event.Attempt,
+		WorkerID:    event.WorkerID,
+		Error:       event.Error,
+		Status:      event.Status,
+		OccurredAt:  event.Timestamp,
+	}
+	if event.TaskID != uuid.Nil {
+		taskID := event.TaskID
+		record.TaskID = &taskID
+	}
+	return record
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Role grants a set of API operations to a caller
+type Role string
+
+const (
+	RoleSubmit Role = "submit" // Submit workflows
+	RoleRead   Role = "read"   // Read workflows, their events and history
+	RoleCancel Role = "cancel" // Cancel running workflows
+	RoleWorker Role = "worker" // Poll and report tasks of every tenant as an external worker
+	RoleAdmin  Role = "admin"  // Everything, including webhooks and acting for any user
+)
+
+// ValidRole reports whether r is one of the defined roles
+func ValidRole(r Role) bool {
+	switch r {
+	case RoleSubmit, RoleRead, RoleCancel, RoleWorker, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Identity is the authenticated caller of an API request
+type Identity struct {
+	Subject string    // API key name or JWT subject
+	UserID  uuid.UUID // Set when the subject is a user; uuid.Nil for service credentials
+	Tenant  string    // Tenant the caller is bound to; empty for any tenant
+	Roles   []Role
+}
+
+// HasRole reports whether the caller holds the role; admin holds every role
+func (i *Identity) HasRole(role Role) bool {
+	for _, r := range i.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// CanActFor reports whether the caller may submit workflows on behalf of userID
+func (i *Identity) CanActFor(userID uuid.UUID) bool {
+	return i.HasRole(RoleAdmin) || (i.UserID != uuid.Nil && i.UserID == userID)
+}
+
+type identityKey struct{}
+
+// WithIdentity attaches the authenticated caller to ctx
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the authenticated caller, if authentication is enabled
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
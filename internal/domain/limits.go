@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Limit kinds reported when a task is throttled
+const (
+	LimitConcurrency = "concurrency"
+	LimitRate        = "rate"
+)
+
+// ActionLimit caps how often an action runs across all workers. Zero values are unlimited.
+type ActionLimit struct {
+	MaxConcurrent int           // Executions running at the same time
+	Rate          int           // Executions started per RatePeriod, refilled as a token bucket
+	RatePeriod    time.Duration // Also bounds bursts: at most Rate executions start at once
+}
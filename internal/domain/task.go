@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,16 +19,28 @@ const (
 	StatusSkipped   TaskStatus = "SKIPPED"
 )
 
+// Task priorities; higher values are popped first. Tasks inherit the priority of their workflow
+// unless they set their own.
+const (
+	MinPriority     = 0
+	MaxPriority     = 9
+	DefaultPriority = 5
+)
+
+// DefaultTaskQueue serves actions that are neither routed nor given an explicit task queue
+const DefaultTaskQueue = "default"
+
 type Task struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key;"`
 	ExecutionID uuid.UUID `gorm:"type:uuid;index;not null"`
+	Tenant      string    `gorm:"type:varchar(63);not null;index"`
 	
 	// --- THE FIX IS HERE ---
 	RefID       string    `gorm:"type:varchar(100);not null"` // e.g. "step_1_welcome_email"
-	Action      string    `gorm:"type:varchar(100);not null"` // e.g. "send_email"
+	Action      string    `gorm:"type:varchar(100);not null;index:idx_tasks_action_status,priority:1"` // e.g. "send_email"
 	// -----------------------
 
-	Status       TaskStatus     `gorm:"type:varchar(20);index;default:'PENDING'"`
+	Status       TaskStatus     `gorm:"type:varchar(20);index;default:'PENDING';index:idx_tasks_action_status,priority:2"`
 	RetryCount   int            `gorm:"default:0"`
 	MaxRetries   int            `gorm:"default:3"`
 	LastError    string         `gorm:"type:text"`
@@ -40,11 +53,29 @@ type Task struct {
 	SkipHint     bool           `gorm:"default:false"` 
 	
 	WorkerID     *string        `gorm:"type:varchar(100);index"`
+	StartedAt    *time.Time     // When the worker claimed the running attempt
+	Priority     int            `gorm:"not null"` // No gorm default, so priority 0 isn't replaced by it
+	TaskQueue    string         `gorm:"type:varchar(100);not null"` // Named queue whose workers handle the action
+	Queue        string         `gorm:"type:varchar(100)"` // Set by the Postgres queue backend
+	QueuedAt     *time.Time
 	Version      int            `gorm:"default:1"`
 
+	// Set on a retry delayed by its handler; queues don't hand the task out before then
+	NextAttemptAt *time.Time
+
+	// Lease of a task claimed by an external worker; the hash of its token and when it lapses
+	LeaseTokenHash *string    `gorm:"type:varchar(64)"`
+	LeaseExpiresAt *time.Time `gorm:"index"`
+
 	Input        datatypes.JSON `gorm:"type:jsonb"` // Args for the Action
 	Output       datatypes.JSON `gorm:"type:jsonb"` // Result from the Action
 
+	// Latest progress reported by the handler of the running attempt, cleared on every claim
+	ProgressPercent   *int
+	ProgressMessage   string         `gorm:"type:text"`
+	ProgressDetails   datatypes.JSON `gorm:"type:jsonb"`
+	ProgressUpdatedAt *time.Time
+
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -54,9 +85,12 @@ func NewTask(executionID uuid.UUID, refID string, action string) *Task {
 	return &Task{
 		ID:          uuid.New(),
 		ExecutionID: executionID,
+		Tenant:      DefaultTenant,
 		RefID:       refID,
 		Action:      action,
 		Status:      StatusPending,
+		Priority:    DefaultPriority,
+		TaskQueue:   DefaultTaskQueue,
 		Version:     1,
 		CreatedAt:   time.Now(),
 	}
@@ -66,6 +100,58 @@ func (t *Task) CanRetry(maxRetry int) bool {
 	return t.RetryCount < maxRetry
 }
 
+// TaskProgress is the progress a handler reported for its task
+type TaskProgress struct {
+	Percent   int             `json:"percent"` // 0 to 100
+	Message   string          `json:"message,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Progress returns the latest progress of the task, nil if none was reported
+func (t *Task) Progress() *TaskProgress {
+	if t.ProgressPercent == nil || t.ProgressUpdatedAt == nil {
+		return nil
+	}
+	return &TaskProgress{
+		Percent:   *t.ProgressPercent,
+		Message:   t.ProgressMessage,
+		Details:   json.RawMessage(t.ProgressDetails),
+		UpdatedAt: *t.ProgressUpdatedAt,
+	}
+}
+
+// Reasons recorded for tasks skipped on their skip hint
+const (
+	SkipReasonParentFailed = "parent task failed" // A dependency failed or was skipped
+	SkipReasonCancelled    = "workflow cancelled" // The workflow was cancelled before the task started
+)
+
+// SkipReason is the reason a task with a skip hint is skipped, given the status of its workflow
+func SkipReason(status WorkflowStatus) string {
+	if status == WorkflowCancelled {
+		return SkipReasonCancelled
+	}
+	return SkipReasonParentFailed
+}
+
+// FailureOutput is the output recorded for a failed task, with the output its handler returned
+// alongside the error, if any
+func FailureOutput(errMessage string, handlerOutput []byte) datatypes.JSON {
+	failure := map[string]any{"error": errMessage}
+	if len(handlerOutput) > 0 && json.Valid(handlerOutput) {
+		failure["output"] = json.RawMessage(handlerOutput)
+	}
+	output, _ := json.Marshal(failure)
+	return datatypes.JSON(output)
+}
+
+// SkippedOutput is the output recorded for a skipped task
+func SkippedOutput(reason string) datatypes.JSON {
+	output, _ := json.Marshal(map[string]any{"skipped": true, "reason": reason})
+	return datatypes.JSON(output)
+}
+
 
 
 
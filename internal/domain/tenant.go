@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultTenant owns workflows submitted without a tenant
+const DefaultTenant = "default"
+
+// tenantPattern keeps tenant names usable in queue keys, metric labels and URLs
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidTenant reports whether name can be used as a tenant
+func ValidTenant(name string) bool {
+	return tenantPattern.MatchString(name)
+}
+
+type tenantKey struct{}
+
+// WithTenant scopes ctx to a tenant. Repositories only see that tenant's rows for queries made
+// with the returned context; contexts without a tenant (coordinator, workers) see every tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant ctx is scoped to, if any
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// TenantWeights are the relative shares of worker dispatch each tenant gets when several have
+// tasks queued. Tenants that aren't listed weigh 1.
+type TenantWeights map[string]int
+
+// Of returns the weight of a tenant
+func (w TenantWeights) Of(tenant string) int {
+	if weight := w[tenant]; weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// TenantQuota bounds what one tenant may have in flight. Zero fields are unlimited.
+type TenantQuota struct {
+	MaxRunningWorkflows  int
+	MaxQueuedTasks       int // Tasks waiting in a task queue
+	SubmissionsPerSecond int
+}
+
+// Quotas a submission can exceed
+const (
+	QuotaRunningWorkflows = "running_workflows"
+	QuotaQueuedTasks      = "queued_tasks"
+	QuotaSubmissionRate   = "submission_rate"
+)
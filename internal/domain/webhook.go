@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "PENDING"
+	DeliveryDelivered WebhookDeliveryStatus = "DELIVERED"
+	DeliveryDead      WebhookDeliveryStatus = "DEAD" // Dead-lettered after exhausting attempts
+)
+
+// DefaultCallbackEvents are delivered to a workflow's callback_url when no events are listed
+var DefaultCallbackEvents = []WorkflowEventType{EventWorkflowCompleted, EventWorkflowFailed, EventWorkflowCancelled}
+
+// WebhookSubscription is a globally registered receiver for workflow events
+type WebhookSubscription struct {
+	ID     uuid.UUID      `gorm:"type:uuid;primary_key;"`
+	Tenant string         `gorm:"type:varchar(63);not null;index"` // Receives only this tenant's events
+	URL    string         `gorm:"type:text;not null"`
+	Events datatypes.JSON `gorm:"type:jsonb"`                 // Array of WorkflowEventType, empty means all
+	Secret string         `gorm:"type:varchar(100);not null"` // HMAC signing key
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookDelivery is one event sent to one receiver, including its retry state
+type WebhookDelivery struct {
+	ID             uuid.UUID             `gorm:"type:uuid;primary_key;"`
+	ExecutionID    uuid.UUID             `gorm:"type:uuid;index;not null"`
+	Tenant         string                `gorm:"type:varchar(63);not null;index"`
+	SubscriptionID *uuid.UUID            `gorm:"type:uuid;index"` // Nil for per-workflow callbacks
+	URL            string                `gorm:"type:text;not null"`
+	EventType      WorkflowEventType     `gorm:"type:varchar(50);not null"`
+	Payload        datatypes.JSON        `gorm:"type:jsonb"`
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);index;default:'PENDING'"`
+	Attempts       int                   `gorm:"default:0"`
+	LastError      string                `gorm:"type:text"`
+	ResponseCode   int                   `gorm:"default:0"`
+	NextAttemptAt  time.Time             `gorm:"index"`
+	DeliveredAt    *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookDeliveryFilter narrows the delivery log; zero values match everything
+type WebhookDeliveryFilter struct {
+	ExecutionID    uuid.UUID
+	SubscriptionID uuid.UUID
+	Status         WebhookDeliveryStatus
+	Limit          int // The API caps it at service.MaxPageSize
+}
+
+// NewWebhookSubscription creates a subscription for the given URL and event types
+func NewWebhookSubscription(url string, events datatypes.JSON, secret string) *WebhookSubscription {
+	return &WebhookSubscription{
+		ID:        uuid.New(),
+		Tenant:    DefaultTenant,
+		URL:       url,
+		Events:    events,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+}
+
+// NewWebhookDelivery creates a pending delivery that is due immediately
+func NewWebhookDelivery(event WorkflowEvent, url string, subscriptionID *uuid.UUID, payload datatypes.JSON) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		ID:             uuid.New(),
+		ExecutionID:    event.ExecutionID,
+		Tenant:         DefaultTenant,
+		SubscriptionID: subscriptionID,
+		URL:            url,
+		EventType:      event.Type,
+		Payload:        payload,
+		Status:         DeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
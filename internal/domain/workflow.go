@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
 type WorkflowStatus string
@@ -13,23 +14,63 @@ const (
 	WorkflowCompleted WorkflowStatus = "COMPLETED"
 	WorkflowFailed    WorkflowStatus = "FAILED"
 	WorkflowPaused    WorkflowStatus = "PAUSED"
+	WorkflowCancelled WorkflowStatus = "CANCELLED"
 )
 
 type WorkflowExecution struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key;"`
-	UserID       uuid.UUID `gorm:"type:uuid;index;not null"`
-	WorkflowType string    `gorm:"type:varchar(50);not null"`
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;index:idx_workflow_executions_created,priority:2"`
+	UserID       uuid.UUID `gorm:"type:uuid;index;not null;index:idx_workflow_executions_user_created,priority:1"`
+	WorkflowType string    `gorm:"type:varchar(50);not null;index:idx_workflow_executions_type_created,priority:1"`
+	Tenant       string    `gorm:"type:varchar(63);not null;index:idx_workflow_executions_tenant_created,priority:1"`
 	
 	// State
-	Status       WorkflowStatus    `gorm:"type:varchar(20);default:'RUNNING'"`
+	Status       WorkflowStatus    `gorm:"type:varchar(20);default:'RUNNING';index:idx_workflow_executions_status_created,priority:1"`
+	Priority     int               `gorm:"not null"` // Default for tasks that don't set their own
+
+	// Idempotency-Key of the submission, unique per tenant (optional)
+	IdempotencyKey *string `gorm:"type:varchar(255)"`
+	
+	// Webhook callback for this execution (optional)
+	CallbackURL    string         `gorm:"type:text"`
+	CallbackEvents datatypes.JSON `gorm:"type:jsonb"` // Array of WorkflowEventType
 	
 	// Relationships
 	// Note: We don't necessarily need to load Tasks every time we load a Workflow
 	Tasks        []Task    `gorm:"foreignKey:ExecutionID"` 
 	
 	// Audit
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	// Listing is ordered by (created_at, id); each filter column has a composite index with created_at
+	CreatedAt    time.Time `gorm:"index:idx_workflow_executions_created,priority:1;index:idx_workflow_executions_user_created,priority:2;index:idx_workflow_executions_type_created,priority:2;index:idx_workflow_executions_status_created,priority:2;index:idx_workflow_executions_tenant_created,priority:2"`
+	UpdatedAt    time.Time `gorm:"index"`
+	FinishedAt   *time.Time // Set once the last task finished and the final status was announced
+}
+
+// WorkflowCursor marks the position after the last workflow of a page
+type WorkflowCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// WorkflowFilter narrows workflow listings; zero values match everything.
+// Results are ordered newest first.
+type WorkflowFilter struct {
+	UserID        uuid.UUID
+	WorkflowType  string
+	Status        WorkflowStatus
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	FailingAction string // Only workflows with a FAILED task running this action
+
+	After *WorkflowCursor // Continue after this position
+	Limit int
+}
+
+// WorkflowPage is one page of a workflow listing
+type WorkflowPage struct {
+	Workflows  []WorkflowExecution
+	NextCursor *WorkflowCursor // Nil on the last page
 }
 
 // --- FACTORY ---
@@ -38,12 +79,14 @@ func NewWorkflow(userID uuid.UUID, workflowType string) *WorkflowExecution {
 		ID:           uuid.New(),
 		UserID:       userID,
 		WorkflowType: workflowType,
+		Tenant:       DefaultTenant,
 		Status:       WorkflowRunning,
+		Priority:     DefaultPriority,
 		CreatedAt:    time.Now(),
 	}
 }
 
 // --- METHODS ---
 func (w *WorkflowExecution) IsFinished() bool {
-	return w.Status == WorkflowCompleted || w.Status == WorkflowFailed
+	return w.Status == WorkflowCompleted || w.Status == WorkflowFailed || w.Status == WorkflowCancelled
 }
\ No newline at end of file
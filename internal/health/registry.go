@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Check reports an unhealthy dependency or component by returning an error
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Registry groups health checks by the process role that registered them
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string][]namedCheck
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string][]namedCheck)}
+}
+
+// Register adds a named check to a role
+func (r *Registry) Register(role string, name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[role] = append(r.checks[role], namedCheck{name: name, check: check})
+}
+
+// Roles lists the roles with registered checks
+func (r *Registry) Roles() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := make([]string, 0, len(r.checks))
+	for role := range r.checks {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Has reports whether the role registered any checks
+func (r *Registry) Has(role string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.checks[role]
+	return ok
+}
+
+// Run executes the role's checks and returns the failures by check name
+func (r *Registry) Run(ctx context.Context, role string) map[string]string {
+	r.mu.RLock()
+	checks := r.checks[role]
+	r.mu.RUnlock()
+
+	failures := make(map[string]string)
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			failures[c.name] = err.Error()
+		}
+	}
+	return failures
+}
@@ -0,0 +1,35 @@
+package history
+
+import (
+	"context"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"log"
+)
+
+// RecordingEventBus persists every lifecycle event to the execution history before
+// publishing it. All other EventBus operations pass through unchanged.
+type RecordingEventBus struct {
+	ports.EventBus
+	repo ports.WorkflowEventRepository
+}
+
+func NewRecordingEventBus(bus ports.EventBus, repo ports.WorkflowEventRepository) *RecordingEventBus {
+	return &RecordingEventBus{
+		EventBus: bus,
+		repo:     repo,
+	}
+}
+
+// PublishWorkflowEvent appends the event to the history, then publishes it.
+// A failed append is logged and does not stop live subscribers from seeing the event.
+// Progress events are only published; the task row keeps the latest progress.
+func (b *RecordingEventBus) PublishWorkflowEvent(ctx context.Context, event domain.WorkflowEvent) (string, error) {
+	if event.Type == domain.EventTaskProgress {
+		return b.EventBus.PublishWorkflowEvent(ctx, event)
+	}
+	if err := b.repo.Append(ctx, domain.NewWorkflowEventRecord(event)); err != nil {
+		log.Printf("Failed to record %s event for workflow %s: %v", event.Type, event.ExecutionID, err)
+	}
+	return b.EventBus.PublishWorkflowEvent(ctx, event)
+}
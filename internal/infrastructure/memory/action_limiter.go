@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"go-tempo/internal/domain"
+	"math"
+	"sync"
+	"time"
+)
+
+// ActionLimiter is an in-process ActionLimiter with the same semantics as the Redis one:
+// leased concurrency slots and a token bucket per action, both checked before either is taken
+type ActionLimiter struct {
+	limits   map[string]domain.ActionLimit
+	leaseTTL time.Duration
+
+	mu      sync.Mutex
+	slots   map[string]map[string]time.Time // action → task ID → lease expiry
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+// take refills the bucket of rate tokens per period and takes one, or returns the wait until
+// the next token
+func (b *tokenBucket) take(now time.Time, rate int, period time.Duration) (bool, time.Duration) {
+	perNano := float64(rate) / float64(period)
+	tokens := math.Min(float64(rate), b.tokens+float64(now.Sub(b.ts))*perNano)
+	if tokens < 1 {
+		return false, time.Duration(math.Ceil((1 - tokens) / perNano))
+	}
+	b.tokens, b.ts = tokens-1, now
+	return true, 0
+}
+
+func NewActionLimiter(limits map[string]domain.ActionLimit, leaseTTL time.Duration) *ActionLimiter {
+	return &ActionLimiter{
+		limits:   limits,
+		leaseTTL: leaseTTL,
+		slots:    make(map[string]map[string]time.Time),
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+func (l *ActionLimiter) Acquire(ctx context.Context, action string, taskID string) (string, time.Duration, error) {
+	limit, ok := l.limits[action]
+	if !ok {
+		return "", 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+
+	slots := l.slots[action]
+	if limit.MaxConcurrent > 0 {
+		if slots == nil {
+			slots = make(map[string]time.Time)
+			l.slots[action] = slots
+		}
+		for id, expiry := range slots {
+			if !expiry.After(now) {
+				delete(slots, id)
+			}
+		}
+		if _, held := slots[taskID]; !held && len(slots) >= limit.MaxConcurrent {
+			return domain.LimitConcurrency, 0, nil
+		}
+	}
+
+	if limit.Rate > 0 {
+		bucket, ok := l.buckets[action]
+		if !ok {
+			bucket = &tokenBucket{tokens: float64(limit.Rate), ts: now}
+			l.buckets[action] = bucket
+		}
+		if ok, retryAfter := bucket.take(now, limit.Rate, limit.RatePeriod); !ok {
+			return domain.LimitRate, retryAfter, nil
+		}
+	}
+
+	if limit.MaxConcurrent > 0 {
+		slots[taskID] = now.Add(l.leaseTTL)
+	}
+	return "", 0, nil
+}
+
+func (l *ActionLimiter) Renew(ctx context.Context, action string, taskID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, held := l.slots[action][taskID]; held {
+		l.slots[action][taskID] = time.Now().Add(l.leaseTTL)
+	}
+	return nil
+}
+
+func (l *ActionLimiter) Release(ctx context.Context, action string, taskID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.slots[action], taskID)
+	return nil
+}
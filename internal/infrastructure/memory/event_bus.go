@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"go-tempo/internal/domain"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// EventBus is an in-process EventBus. Like Redis Pub/Sub, events are delivered to every
+// subscriber present at publish time and dropped when nobody is listening. Lifecycle event
+// history is kept per execution and trimmed to historyMaxLen entries; it is never expired.
+type EventBus struct {
+	completed  *topic[domain.TaskCompletedEvent]
+	terminated *topic[domain.TaskTerminatedEvent]
+
+	mu            sync.Mutex
+	lifecycle     map[uuid.UUID]*topic[domain.WorkflowEvent] // One topic per execution
+	history       map[uuid.UUID][]domain.WorkflowEvent
+	historyMaxLen int
+	lastID        uint64 // Event IDs are increasing decimal numbers
+}
+
+// NewEventBus creates the event bus. historyMaxLen <= 0 keeps the whole history.
+func NewEventBus(historyMaxLen int) *EventBus {
+	return &EventBus{
+		completed:     newTopic[domain.TaskCompletedEvent](),
+		terminated:    newTopic[domain.TaskTerminatedEvent](),
+		lifecycle:     make(map[uuid.UUID]*topic[domain.WorkflowEvent]),
+		history:       make(map[uuid.UUID][]domain.WorkflowEvent),
+		historyMaxLen: historyMaxLen,
+	}
+}
+
+func (b *EventBus) PublishTaskCompleted(ctx context.Context, event domain.TaskCompletedEvent) error {
+	b.completed.publish(event)
+	return nil
+}
+
+func (b *EventBus) PublishTaskTerminated(ctx context.Context, event domain.TaskTerminatedEvent) error {
+	b.terminated.publish(event)
+	return nil
+}
+
+func (b *EventBus) SubscribeToEvents(ctx context.Context) (<-chan domain.TaskCompletedEvent, error) {
+	return b.completed.subscribe(ctx), nil
+}
+
+func (b *EventBus) SubscribeToTerminationEvents(ctx context.Context) (<-chan domain.TaskTerminatedEvent, error) {
+	return b.terminated.subscribe(ctx), nil
+}
+
+// PublishWorkflowEvent appends the event to the execution's history and broadcasts it
+func (b *EventBus) PublishWorkflowEvent(ctx context.Context, event domain.WorkflowEvent) (string, error) {
+	b.mu.Lock()
+	b.lastID++
+	event.ID = strconv.FormatUint(b.lastID, 10)
+
+	events := append(b.history[event.ExecutionID], event)
+	if b.historyMaxLen > 0 && len(events) > b.historyMaxLen {
+		events = events[len(events)-b.historyMaxLen:]
+	}
+	b.history[event.ExecutionID] = events
+	t := b.lifecycleTopic(event.ExecutionID)
+	b.mu.Unlock()
+
+	t.publish(event)
+	return event.ID, nil
+}
+
+func (b *EventBus) SubscribeToWorkflowEvents(ctx context.Context, executionID uuid.UUID) (<-chan domain.WorkflowEvent, error) {
+	b.mu.Lock()
+	t := b.lifecycleTopic(executionID)
+	b.mu.Unlock()
+
+	return t.subscribe(ctx), nil
+}
+
+// GetWorkflowEventsSince returns the recorded events after lastEventID ("" returns all)
+func (b *EventBus) GetWorkflowEventsSince(ctx context.Context, executionID uuid.UUID, lastEventID string) ([]domain.WorkflowEvent, error) {
+	var after uint64
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event ID %q", lastEventID)
+		}
+		after = id
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make([]domain.WorkflowEvent, 0)
+	for _, event := range b.history[executionID] {
+		if id, _ := strconv.ParseUint(event.ID, 10, 64); id > after {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// lifecycleTopic returns the execution's topic, creating it if needed. Callers hold b.mu.
+func (b *EventBus) lifecycleTopic(executionID uuid.UUID) *topic[domain.WorkflowEvent] {
+	t, ok := b.lifecycle[executionID]
+	if !ok {
+		t = newTopic[domain.WorkflowEvent]()
+		b.lifecycle[executionID] = t
+	}
+	return t
+}
+
+// topic fans published values out to its current subscribers
+type topic[T any] struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber[T]]struct{}
+}
+
+func newTopic[T any]() *topic[T] {
+	return &topic[T]{subscribers: make(map[*subscriber[T]]struct{})}
+}
+
+func (t *topic[T]) publish(value T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for s := range t.subscribers {
+		s.enqueue(value)
+	}
+}
+
+// subscribe returns a channel of values published from now until ctx is canceled
+func (t *topic[T]) subscribe(ctx context.Context) <-chan T {
+	s := &subscriber[T]{wake: make(chan struct{}, 1)}
+
+	t.mu.Lock()
+	t.subscribers[s] = struct{}{}
+	t.mu.Unlock()
+
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		defer func() {
+			t.mu.Lock()
+			delete(t.subscribers, s)
+			t.mu.Unlock()
+		}()
+		s.forward(ctx, out)
+	}()
+	return out
+}
+
+// subscriber buffers values without bound so a slow reader never blocks publishers,
+// the same guarantee Redis gives by buffering on the connection
+type subscriber[T any] struct {
+	mu      sync.Mutex
+	pending []T
+	wake    chan struct{}
+}
+
+func (s *subscriber[T]) enqueue(value T) {
+	s.mu.Lock()
+	s.pending = append(s.pending, value)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscriber[T]) forward(ctx context.Context, out chan<- T) {
+	for {
+		s.mu.Lock()
+		batch := s.pending
+		s.pending = nil
+		s.mu.Unlock()
+
+		for _, value := range batch {
+			select {
+			case out <- value:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-s.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type heldLock struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// LeaderLock is an in-process LeaderLock. Like a Redis SET NX, a lock is only taken once it
+// was released or expired, even by its own holder.
+type LeaderLock struct {
+	mu    sync.Mutex
+	locks map[string]heldLock
+}
+
+func NewLeaderLock() *LeaderLock {
+	return &LeaderLock{locks: make(map[string]heldLock)}
+}
+
+func (l *LeaderLock) Acquire(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if lock, ok := l.locks[name]; ok && now.Before(lock.expiresAt) {
+		return false, nil
+	}
+	l.locks[name] = heldLock{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (l *LeaderLock) Renew(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	lock, ok := l.locks[name]
+	if !ok || lock.holder != holder || !now.Before(lock.expiresAt) {
+		return false, nil
+	}
+	l.locks[name] = heldLock{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (l *LeaderLock) Release(ctx context.Context, name string, holder string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lock, ok := l.locks[name]; ok && lock.holder == holder {
+		delete(l.locks, name)
+	}
+	return nil
+}
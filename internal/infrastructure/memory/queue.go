@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"container/heap"
+	"context"
+	"go-tempo/internal/domain"
+	"sync"
+	"time"
+)
+
+// Queue is an in-process priority TaskQueue. Pop blocks until a task ID is pushed
+// or the context is canceled, like the Redis queue, and takes turns between tenants the same
+// way: the tenant with the lowest virtual time is served and its virtual time advances by
+// 1/weight. Within a tenant it takes the entry with the lowest score: the push time minus
+// priority × agingInterval. A task pushed with a NextAttemptAt in the future is held back
+// until then and queued as if pushed at that time.
+type Queue struct {
+	mu            sync.Mutex
+	tenants       map[string]*tenantQueue
+	delayed       []delayedItem // Held back until due, in push order
+	clock         float64       // Virtual time of the last turn served
+	seq           uint64        // Breaks score ties in push order
+	agingInterval time.Duration
+	weights       domain.TenantWeights
+	ready         chan struct{} // Closed and replaced on every push to wake blocked poppers
+}
+
+type tenantQueue struct {
+	items queueHeap
+	vt    float64 // Virtual time of the tenant's next turn
+}
+
+func NewQueue(agingInterval time.Duration, weights domain.TenantWeights) *Queue {
+	return &Queue{
+		tenants:       make(map[string]*tenantQueue),
+		agingInterval: agingInterval,
+		weights:       weights,
+		ready:         make(chan struct{}),
+	}
+}
+
+type delayedItem struct {
+	tenant, taskID string
+	priority       int
+	due            time.Time
+}
+
+func (q *Queue) Push(ctx context.Context, task *domain.Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if task.NextAttemptAt != nil && task.NextAttemptAt.After(time.Now()) {
+		q.delayed = append(q.delayed, delayedItem{
+			tenant:   task.Tenant,
+			taskID:   task.ID.String(),
+			priority: task.Priority,
+			due:      *task.NextAttemptAt,
+		})
+		q.wake() // Blocked poppers wait for the new due time too
+		return nil
+	}
+	q.push(task.Tenant, task.ID.String(), task.Priority, time.Now())
+	return nil
+}
+
+// wake wakes blocked poppers. Callers hold mu.
+func (q *Queue) wake() {
+	close(q.ready)
+	q.ready = make(chan struct{})
+}
+
+// push queues a task ID on its tenant's heap as pushed at the time and wakes poppers.
+// Callers hold mu.
+func (q *Queue) push(tenant, taskID string, priority int, at time.Time) {
+	tq, ok := q.tenants[tenant]
+	if !ok {
+		tq = &tenantQueue{}
+		q.tenants[tenant] = tq
+	}
+	if tq.items.Len() == 0 && tq.vt < q.clock {
+		tq.vt = q.clock // An idle tenant can't bank the turns it missed
+	}
+
+	q.seq++
+	heap.Push(&tq.items, queueItem{
+		taskID:   taskID,
+		priority: priority,
+		score:    at.Add(-time.Duration(priority) * q.agingInterval).UnixNano(),
+		seq:      q.seq,
+	})
+	q.wake()
+}
+
+// promoteDue queues the delayed tasks that are due and returns when the next one is, or the
+// zero time if none is left. Callers hold mu.
+func (q *Queue) promoteDue(now time.Time) time.Time {
+	var next time.Time
+	waiting := q.delayed[:0]
+	for _, item := range q.delayed {
+		if item.due.After(now) {
+			waiting = append(waiting, item)
+			if next.IsZero() || item.due.Before(next) {
+				next = item.due
+			}
+			continue
+		}
+		q.push(item.tenant, item.taskID, item.priority, item.due)
+	}
+	q.delayed = waiting
+	return next
+}
+
+func (q *Queue) Pop(ctx context.Context) (string, error) {
+	for {
+		q.mu.Lock()
+		nextDue := q.promoteDue(time.Now())
+		if tenant, tq := q.next(); tq != nil {
+			item := heap.Pop(&tq.items).(queueItem)
+			q.clock = tq.vt
+			tq.vt += 1 / float64(q.weights.Of(tenant))
+			q.mu.Unlock()
+			return item.taskID, nil
+		}
+		ready := q.ready
+		q.mu.Unlock()
+
+		// Wake for the next delayed task too
+		var due <-chan time.Time
+		timer := time.NewTimer(time.Until(nextDue))
+		if !nextDue.IsZero() {
+			due = timer.C
+		}
+		select {
+		case <-ready:
+		case <-due:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		}
+		timer.Stop()
+	}
+}
+
+// next returns the tenant with tasks queued and the lowest virtual time. Callers hold mu.
+func (q *Queue) next() (string, *tenantQueue) {
+	var (
+		name string
+		best *tenantQueue
+	)
+	for tenant, tq := range q.tenants {
+		if tq.items.Len() == 0 {
+			continue
+		}
+		if best == nil || tq.vt < best.vt || (tq.vt == best.vt && tenant < name) {
+			name, best = tenant, tq
+		}
+	}
+	return name, best
+}
+
+func (q *Queue) DepthByPriority(ctx context.Context) (map[int]int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	depth := make(map[int]int64)
+	for _, tq := range q.tenants {
+		for _, item := range tq.items {
+			depth[item.priority]++
+		}
+	}
+	return depth, nil
+}
+
+// Len returns the number of queued task IDs, delayed ones included
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := len(q.delayed)
+	for _, tq := range q.tenants {
+		n += tq.items.Len()
+	}
+	return n
+}
+
+type queueItem struct {
+	taskID   string
+	priority int
+	score    int64
+	seq      uint64
+}
+
+// queueHeap implements heap.Interface as a min-heap on (score, seq)
+type queueHeap []queueItem
+
+func (h queueHeap) Len() int { return len(h) }
+
+func (h queueHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score < h[j].score
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h queueHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *queueHeap) Push(x interface{}) { *h = append(*h, x.(queueItem)) }
+
+func (h *queueHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is an in-process RateLimiter with a token bucket per key
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, rate int, period time.Duration) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(rate), ts: now}
+		l.buckets[key] = bucket
+	}
+	allowed, retryAfter := bucket.take(now, rate, period)
+	return allowed, retryAfter, nil
+}
@@ -0,0 +1,19 @@
+package memory_test
+
+import (
+	"go-tempo/internal/core/ports/portstest"
+	"go-tempo/internal/infrastructure/memory"
+	"testing"
+)
+
+func TestRepositoryConformance(t *testing.T) {
+	portstest.Run(t, func(t *testing.T) portstest.Repositories {
+		store := memory.NewStore()
+		return portstest.Repositories{
+			Tasks:     memory.NewTaskRepository(store),
+			Workflows: memory.NewWorkflowRepository(store),
+			Webhooks:  memory.NewWebhookRepository(store),
+			History:   memory.NewWorkflowEventRepository(store),
+		}
+	})
+}
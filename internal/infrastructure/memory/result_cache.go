@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type cachedResult struct {
+	output    []byte
+	expiresAt time.Time
+}
+
+// ResultCache is an in-process ResultCache. Expired entries are dropped when read.
+type ResultCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResult
+	ttl     time.Duration
+}
+
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{entries: make(map[string]cachedResult), ttl: ttl}
+}
+
+// Get returns the cached output stored under the idempotency key
+func (c *ResultCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	return entry.output, true, nil
+}
+
+// Put stores the output under the idempotency key until the TTL expires
+func (c *ResultCache) Put(ctx context.Context, key string, output []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cachedResult{output: output, expiresAt: time.Now().Add(c.ttl)}
+	return nil
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"encoding/json"
+	"go-tempo/internal/domain"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Store holds the workflows, tasks, webhooks and history shared by the in-memory repositories.
+// One mutex serializes every operation, which makes each one atomic the way
+// a single SQL statement or transaction is in Postgres.
+type Store struct {
+	mu          sync.Mutex
+	executions  map[uuid.UUID]*domain.WorkflowExecution
+	tasks       map[uuid.UUID]*domain.Task
+	byExecution map[uuid.UUID][]uuid.UUID // Task IDs per execution, in insertion order
+
+	subscriptions []domain.WebhookSubscription // In creation order
+	deliveries    map[uuid.UUID]*domain.WebhookDelivery
+	events        []domain.WorkflowEventRecord // Append-only, in ID order
+}
+
+func NewStore() *Store {
+	return &Store{
+		executions:  make(map[uuid.UUID]*domain.WorkflowExecution),
+		tasks:       make(map[uuid.UUID]*domain.Task),
+		byExecution: make(map[uuid.UUID][]uuid.UUID),
+		deliveries:  make(map[uuid.UUID]*domain.WebhookDelivery),
+	}
+}
+
+// executionTasks returns the stored tasks of an execution. Callers hold s.mu.
+func (s *Store) executionTasks(executionID uuid.UUID) []*domain.Task {
+	ids := s.byExecution[executionID]
+	tasks := make([]*domain.Task, 0, len(ids))
+	for _, id := range ids {
+		tasks = append(tasks, s.tasks[id])
+	}
+	return tasks
+}
+
+// dependsOn reports whether the task's dependencies array contains refID,
+// the in-memory equivalent of "dependencies @> '["refID"]'"
+func dependsOn(task *domain.Task, refID string) bool {
+	var deps []string
+	if err := json.Unmarshal(task.Dependencies, &deps); err != nil {
+		return false
+	}
+	for _, dep := range deps {
+		if dep == refID {
+			return true
+		}
+	}
+	return false
+}
+
+// copyTask returns a detached copy so callers can't mutate stored state
+func copyTask(task *domain.Task) *domain.Task {
+	c := *task
+	if task.WorkerID != nil {
+		workerID := *task.WorkerID
+		c.WorkerID = &workerID
+	}
+	if task.LeaseTokenHash != nil {
+		tokenHash := *task.LeaseTokenHash
+		c.LeaseTokenHash = &tokenHash
+	}
+	if task.LeaseExpiresAt != nil {
+		expiresAt := *task.LeaseExpiresAt
+		c.LeaseExpiresAt = &expiresAt
+	}
+	c.NextAttemptAt = copyTime(task.NextAttemptAt)
+	if task.StartedAt != nil {
+		startedAt := *task.StartedAt
+		c.StartedAt = &startedAt
+	}
+	if task.ProgressPercent != nil {
+		percent := *task.ProgressPercent
+		c.ProgressPercent = &percent
+	}
+	if task.ProgressUpdatedAt != nil {
+		updatedAt := *task.ProgressUpdatedAt
+		c.ProgressUpdatedAt = &updatedAt
+	}
+	return &c
+}
+
+// copyExecution returns a detached copy without the Tasks association, like a plain SELECT
+func copyExecution(execution *domain.WorkflowExecution) *domain.WorkflowExecution {
+	c := *execution
+	c.Tasks = nil
+	if execution.IdempotencyKey != nil {
+		key := *execution.IdempotencyKey
+		c.IdempotencyKey = &key
+	}
+	if execution.FinishedAt != nil {
+		finishedAt := *execution.FinishedAt
+		c.FinishedAt = &finishedAt
+	}
+	return &c
+}
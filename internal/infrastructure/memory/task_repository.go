@@ -0,0 +1,462 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"go-tempo/internal/domain"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type taskRepository struct {
+	store *Store
+}
+
+// NewTaskRepository creates a TaskRepository backed by the store
+func NewTaskRepository(store *Store) *taskRepository {
+	return &taskRepository{store: store}
+}
+
+// CreateExecution stores the execution and its tasks, or nothing if any ID already exists
+func (r *taskRepository) CreateExecution(ctx context.Context, execution *domain.WorkflowExecution, tasks []domain.Task) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.executions[execution.ID]; exists {
+		return fmt.Errorf("duplicate workflow execution %s", execution.ID)
+	}
+	if s.idempotencyKeyTaken(execution) {
+		return fmt.Errorf("duplicate idempotency key %s", *execution.IdempotencyKey)
+	}
+	for _, task := range tasks {
+		if _, exists := s.tasks[task.ID]; exists {
+			return fmt.Errorf("duplicate task %s", task.ID)
+		}
+	}
+
+	now := time.Now()
+	applyWorkflowDefaults(execution, now)
+	s.executions[execution.ID] = copyExecution(execution)
+
+	for i := range tasks {
+		applyTaskDefaults(&tasks[i], now)
+		s.tasks[tasks[i].ID] = copyTask(&tasks[i])
+		s.byExecution[tasks[i].ExecutionID] = append(s.byExecution[tasks[i].ExecutionID], tasks[i].ID)
+	}
+	return nil
+}
+
+func (r *taskRepository) FindTaskByID(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[id]
+	if !ok || !visible(ctx, task.Tenant) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return copyTask(task), nil
+}
+
+// ClaimTask sets the task RUNNING if its version still matches
+func (r *taskRepository) ClaimTask(ctx context.Context, taskID uuid.UUID, workerID string, currentVersion int) error {
+	return r.updateVersioned(ctx, taskID, currentVersion, func(task *domain.Task) bool {
+		task.Status = domain.StatusRunning
+		task.WorkerID = &workerID
+		startedAt := time.Now()
+		task.StartedAt = &startedAt
+		task.ProgressPercent, task.ProgressMessage, task.ProgressDetails, task.ProgressUpdatedAt = nil, "", nil, nil
+		return true
+	})
+}
+
+func (r *taskRepository) FindChildren(ctx context.Context, executionID uuid.UUID, parentName string) ([]domain.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	children := make([]domain.Task, 0)
+	for _, task := range s.executionTasks(executionID) {
+		if dependsOn(task, parentName) && visible(ctx, task.Tenant) {
+			children = append(children, *copyTask(task))
+		}
+	}
+	return children, nil
+}
+
+func (r *taskRepository) MarkCompleted(ctx context.Context, taskID uuid.UUID, output datatypes.JSON) error {
+	r.update(ctx, taskID, func(task *domain.Task) {
+		task.Status = domain.StatusCompleted
+		task.Output = output
+	})
+	return nil
+}
+
+func (r *taskRepository) MarkFailed(ctx context.Context, taskID uuid.UUID, errMessage string, output datatypes.JSON) error {
+	r.update(ctx, taskID, func(task *domain.Task) {
+		task.Status = domain.StatusFailed
+		task.LastError = errMessage
+		task.Output = domain.FailureOutput(errMessage, output)
+	})
+	return nil
+}
+
+func (r *taskRepository) MarkSkipped(ctx context.Context, taskID uuid.UUID, reason string) error {
+	r.update(ctx, taskID, func(task *domain.Task) {
+		task.Status = domain.StatusSkipped
+		task.Output = domain.SkippedOutput(reason)
+	})
+	return nil
+}
+
+func (r *taskRepository) UpdateProgress(ctx context.Context, taskID uuid.UUID, progress domain.TaskProgress) error {
+	r.update(ctx, taskID, func(task *domain.Task) {
+		if task.Status != domain.StatusRunning {
+			return
+		}
+		percent, updatedAt := progress.Percent, progress.UpdatedAt
+		task.ProgressPercent = &percent
+		task.ProgressMessage = progress.Message
+		task.ProgressDetails = datatypes.JSON(progress.Details)
+		task.ProgressUpdatedAt = &updatedAt
+	})
+	return nil
+}
+
+func (r *taskRepository) IncrementRetryCount(ctx context.Context, taskID uuid.UUID, currentVersion int, nextAttemptAt *time.Time) error {
+	return r.updateVersioned(ctx, taskID, currentVersion, func(task *domain.Task) bool {
+		task.RetryCount++
+		task.Status = domain.StatusPending
+		task.NextAttemptAt = copyTime(nextAttemptAt)
+		return true
+	})
+}
+
+func (r *taskRepository) ReleaseTask(ctx context.Context, taskID uuid.UUID, currentVersion int) error {
+	return r.updateVersioned(ctx, taskID, currentVersion, func(task *domain.Task) bool {
+		if task.Status != domain.StatusRunning {
+			return false
+		}
+		task.Status = domain.StatusQueued
+		task.WorkerID = nil
+		return true
+	})
+}
+
+func (r *taskRepository) DecrementAndGetReadyTasks(ctx context.Context, executionID uuid.UUID, completedRefID string) ([]uuid.UUID, error) {
+	return r.decrementChildren(executionID, completedRefID, false), nil
+}
+
+func (r *taskRepository) DecrementAndSetSkipHint(ctx context.Context, executionID uuid.UUID, failedRefID string) ([]uuid.UUID, error) {
+	return r.decrementChildren(executionID, failedRefID, true), nil
+}
+
+func (r *taskRepository) AreAllTasksCompleted(ctx context.Context, executionID uuid.UUID) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, task := range s.executionTasks(executionID) {
+		if !visible(ctx, task.Tenant) {
+			continue
+		}
+		if task.Status != domain.StatusCompleted && task.Status != domain.StatusSkipped {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *taskRepository) AreAllTasksTerminal(ctx context.Context, executionID uuid.UUID) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, task := range s.executionTasks(executionID) {
+		if !visible(ctx, task.Tenant) {
+			continue
+		}
+		switch task.Status {
+		case domain.StatusCompleted, domain.StatusFailed, domain.StatusSkipped:
+		default:
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// decrementChildren decrements in_degree of every task depending on refID in one atomic step,
+// queueing those that reach zero, and returns their IDs
+func (r *taskRepository) decrementChildren(executionID uuid.UUID, refID string, skip bool) []uuid.UUID {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var readyTaskIDs []uuid.UUID
+	now := time.Now()
+	for _, task := range s.executionTasks(executionID) {
+		if !dependsOn(task, refID) {
+			continue
+		}
+
+		task.InDegree--
+		if skip {
+			task.SkipHint = true
+		}
+		if task.InDegree == 0 {
+			task.Status = domain.StatusQueued
+			readyTaskIDs = append(readyTaskIDs, task.ID)
+		}
+		task.UpdatedAt = now
+	}
+	return readyTaskIDs
+}
+
+// update applies fn to the task; a missing or invisible task is a no-op, like an UPDATE matching no rows
+func (r *taskRepository) update(ctx context.Context, taskID uuid.UUID, fn func(task *domain.Task)) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task, ok := s.tasks[taskID]; ok && visible(ctx, task.Tenant) {
+		fn(task)
+		task.UpdatedAt = time.Now()
+	}
+}
+
+// updateVersioned applies fn only if the task is visible, at currentVersion and fn accepts it,
+// then bumps the version. Otherwise it returns gorm.ErrRecordNotFound like the Postgres repository.
+func (r *taskRepository) updateVersioned(ctx context.Context, taskID uuid.UUID, currentVersion int, fn func(task *domain.Task) bool) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok || task.Version != currentVersion || !visible(ctx, task.Tenant) {
+		return gorm.ErrRecordNotFound
+	}
+
+	updated := copyTask(task)
+	if !fn(updated) {
+		return gorm.ErrRecordNotFound
+	}
+	updated.Version = currentVersion + 1
+	updated.UpdatedAt = time.Now()
+	s.tasks[taskID] = updated
+	return nil
+}
+
+func (r *taskRepository) CountWaitingTasks(ctx context.Context) (int64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, task := range s.tasks {
+		if waiting(task) && visible(ctx, task.Tenant) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// SetLease leases the task if it is still RUNNING at currentVersion
+func (r *taskRepository) SetLease(ctx context.Context, taskID uuid.UUID, currentVersion int, tokenHash string, expiresAt time.Time) error {
+	return r.updateLease(ctx, taskID, func(task *domain.Task) bool {
+		if task.Version != currentVersion {
+			return false
+		}
+		task.LeaseTokenHash = &tokenHash
+		task.LeaseExpiresAt = &expiresAt
+		return true
+	})
+}
+
+func (r *taskRepository) RenewLease(ctx context.Context, taskID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	return r.updateLease(ctx, taskID, func(task *domain.Task) bool {
+		if task.LeaseTokenHash == nil || *task.LeaseTokenHash != tokenHash {
+			return false
+		}
+		task.LeaseExpiresAt = &expiresAt
+		return true
+	})
+}
+
+func (r *taskRepository) EndLease(ctx context.Context, taskID uuid.UUID, tokenHash string) error {
+	return r.updateLease(ctx, taskID, func(task *domain.Task) bool {
+		if task.LeaseTokenHash == nil || *task.LeaseTokenHash != tokenHash {
+			return false
+		}
+		task.LeaseTokenHash = nil
+		task.LeaseExpiresAt = nil
+		return true
+	})
+}
+
+func (r *taskRepository) FindExpiredLeases(ctx context.Context, now time.Time, limit int) ([]domain.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := make([]domain.Task, 0)
+	for _, task := range s.tasks {
+		if task.Status == domain.StatusRunning && task.LeaseExpiresAt != nil &&
+			task.LeaseExpiresAt.Before(now) && visible(ctx, task.Tenant) {
+			expired = append(expired, *copyTask(task))
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].LeaseExpiresAt.Before(*expired[j].LeaseExpiresAt)
+	})
+	if limit > 0 && len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
+
+// updateLease applies fn to a visible RUNNING task if fn accepts it, leaving the version alone.
+// Otherwise it returns gorm.ErrRecordNotFound like the Postgres repository.
+func (r *taskRepository) updateLease(ctx context.Context, taskID uuid.UUID, fn func(task *domain.Task) bool) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok || task.Status != domain.StatusRunning || !visible(ctx, task.Tenant) {
+		return gorm.ErrRecordNotFound
+	}
+
+	updated := copyTask(task)
+	if !fn(updated) {
+		return gorm.ErrRecordNotFound
+	}
+	updated.UpdatedAt = time.Now()
+	s.tasks[taskID] = updated
+	return nil
+}
+
+// CancelExecution cancels the execution and flags its unstarted tasks under one lock
+func (r *taskRepository) CancelExecution(ctx context.Context, executionID uuid.UUID) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	execution, ok := s.executions[executionID]
+	if !ok || !visible(ctx, execution.Tenant) || execution.Status != domain.WorkflowRunning {
+		return gorm.ErrRecordNotFound
+	}
+
+	now := time.Now()
+	execution.Status = domain.WorkflowCancelled
+	execution.UpdatedAt = now
+	for _, task := range s.executionTasks(executionID) {
+		if task.Status == domain.StatusPending || task.Status == domain.StatusQueued {
+			task.SkipHint = true
+			task.UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+func (r *taskRepository) FindTasksByExecution(ctx context.Context, executionID uuid.UUID) ([]domain.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]domain.Task, 0)
+	for _, task := range s.executionTasks(executionID) {
+		if visible(ctx, task.Tenant) {
+			tasks = append(tasks, *copyTask(task))
+		}
+	}
+	// Ordered like ORDER BY created_at, ref_id
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].RefID < tasks[j].RefID
+	})
+	return tasks, nil
+}
+
+func (r *taskRepository) FindWaitingTasks(ctx context.Context) ([]domain.Task, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]domain.Task, 0)
+	for _, task := range s.tasks {
+		if waiting(task) && visible(ctx, task.Tenant) {
+			tasks = append(tasks, *copyTask(task))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].RefID < tasks[j].RefID
+	})
+	return tasks, nil
+}
+
+// DeleteExecution removes the execution with its tasks, history and deliveries, like the
+// ON DELETE CASCADE foreign keys of the Postgres schema
+func (r *taskRepository) DeleteExecution(ctx context.Context, executionID uuid.UUID) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	execution, ok := s.executions[executionID]
+	if !ok || !visible(ctx, execution.Tenant) {
+		return gorm.ErrRecordNotFound
+	}
+	delete(s.executions, executionID)
+	for _, id := range s.byExecution[executionID] {
+		delete(s.tasks, id)
+	}
+	delete(s.byExecution, executionID)
+	for id, delivery := range s.deliveries {
+		if delivery.ExecutionID == executionID {
+			delete(s.deliveries, id)
+		}
+	}
+	events := s.events[:0]
+	for _, record := range s.events {
+		if record.ExecutionID != executionID {
+			events = append(events, record)
+		}
+	}
+	s.events = events
+	return nil
+}
+
+// waiting reports whether the task waits in a task queue: it is QUEUED, or PENDING with no
+// unfinished parents
+func waiting(task *domain.Task) bool {
+	return task.Status == domain.StatusQueued || (task.Status == domain.StatusPending && task.InDegree == 0)
+}
+
+// applyTaskDefaults fills zero values with the column defaults Postgres would apply
+func applyTaskDefaults(task *domain.Task, now time.Time) {
+	if task.Status == "" {
+		task.Status = domain.StatusPending
+	}
+	if task.Tenant == "" {
+		task.Tenant = domain.DefaultTenant
+	}
+	if task.MaxRetries == 0 {
+		task.MaxRetries = 3
+	}
+	if task.Version == 0 {
+		task.Version = 1
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	task.UpdatedAt = now
+}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"go-tempo/internal/domain"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type webhookRepository struct {
+	store *Store
+}
+
+// NewWebhookRepository creates a WebhookRepository backed by the store
+func NewWebhookRepository(store *Store) *webhookRepository {
+	return &webhookRepository{store: store}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.subscriptions {
+		if existing.ID == sub.ID {
+			return fmt.Errorf("duplicate webhook subscription %s", sub.ID)
+		}
+	}
+	now := time.Now()
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = now
+	}
+	sub.UpdatedAt = now
+	s.subscriptions = append(s.subscriptions, *sub)
+	return nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := make([]domain.WebhookSubscription, 0)
+	for _, sub := range s.subscriptions {
+		if visible(ctx, sub.Tenant) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, sub := range s.subscriptions {
+		if sub.ID == id && visible(ctx, sub.Tenant) {
+			s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// CreateDeliveries stores the deliveries, or nothing if any ID already exists
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		if _, exists := s.deliveries[delivery.ID]; exists {
+			return fmt.Errorf("duplicate webhook delivery %s", delivery.ID)
+		}
+	}
+	now := time.Now()
+	for i := range deliveries {
+		delivery := deliveries[i]
+		if delivery.Status == "" {
+			delivery.Status = domain.DeliveryPending
+		}
+		if delivery.CreatedAt.IsZero() {
+			delivery.CreatedAt = now
+		}
+		delivery.UpdatedAt = now
+		s.deliveries[delivery.ID] = &delivery
+	}
+	return nil
+}
+
+// UpdateDelivery records the outcome of an attempt; a missing or invisible delivery is a no-op
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.deliveries[delivery.ID]
+	if !ok || !visible(ctx, stored.Tenant) {
+		return nil
+	}
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.LastError = delivery.LastError
+	stored.ResponseCode = delivery.ResponseCode
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.DeliveredAt = copyTime(delivery.DeliveredAt)
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for _, delivery := range s.deliveries {
+		if !visible(ctx, delivery.Tenant) {
+			continue
+		}
+		if filter.ExecutionID != uuid.Nil && delivery.ExecutionID != filter.ExecutionID {
+			continue
+		}
+		if filter.SubscriptionID != uuid.Nil && (delivery.SubscriptionID == nil || *delivery.SubscriptionID != filter.SubscriptionID) {
+			continue
+		}
+		if filter.Status != "" && delivery.Status != filter.Status {
+			continue
+		}
+		deliveries = append(deliveries, *copyDelivery(delivery))
+	}
+
+	// Newest first like ORDER BY created_at DESC
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries pushes next_attempt_at of the claimed deliveries forward by the lease under
+// the same lock that selects them, so concurrent dispatchers never send a delivery twice
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	due := make([]*domain.WebhookDelivery, 0)
+	for _, delivery := range s.deliveries {
+		if delivery.Status == domain.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]domain.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		delivery.NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, *copyDelivery(delivery))
+	}
+	return claimed, nil
+}
+
+// copyDelivery returns a detached copy so callers can't mutate stored state
+func copyDelivery(delivery *domain.WebhookDelivery) *domain.WebhookDelivery {
+	c := *delivery
+	if delivery.SubscriptionID != nil {
+		subscriptionID := *delivery.SubscriptionID
+		c.SubscriptionID = &subscriptionID
+	}
+	c.DeliveredAt = copyTime(delivery.DeliveredAt)
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"go-tempo/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type workflowEventRepository struct {
+	store *Store
+}
+
+// NewWorkflowEventRepository creates a WorkflowEventRepository backed by the store
+func NewWorkflowEventRepository(store *Store) *workflowEventRepository {
+	return &workflowEventRepository{store: store}
+}
+
+// Append assigns the next ID to the record, like the auto-increment column
+func (r *workflowEventRepository) Append(ctx context.Context, record *domain.WorkflowEventRecord) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.ID = int64(len(s.events)) + 1
+	stored := *record
+	stored.TaskID = copyUUID(record.TaskID)
+	s.events = append(s.events, stored)
+	return nil
+}
+
+func (r *workflowEventRepository) ListByExecution(ctx context.Context, executionID uuid.UUID) ([]domain.WorkflowEventRecord, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]domain.WorkflowEventRecord, 0)
+	// Events carry no tenant of their own; they belong to their execution's
+	if _, scopedToTenant := domain.TenantFromContext(ctx); scopedToTenant {
+		execution, ok := s.executions[executionID]
+		if !ok || !visible(ctx, execution.Tenant) {
+			return records, nil
+		}
+	}
+	for _, record := range s.events {
+		if record.ExecutionID == executionID {
+			record.TaskID = copyUUID(record.TaskID)
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func copyUUID(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-tempo/internal/domain"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type workflowRepository struct {
+	store *Store
+}
+
+// NewWorkflowRepository creates a WorkflowRepository backed by the store
+func NewWorkflowRepository(store *Store) *workflowRepository {
+	return &workflowRepository{store: store}
+}
+
+func (r *workflowRepository) Create(ctx context.Context, execution *domain.WorkflowExecution) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.executions[execution.ID]; exists {
+		return fmt.Errorf("duplicate workflow execution %s", execution.ID)
+	}
+	if s.idempotencyKeyTaken(execution) {
+		return fmt.Errorf("duplicate idempotency key %s", *execution.IdempotencyKey)
+	}
+	applyWorkflowDefaults(execution, time.Now())
+	s.executions[execution.ID] = copyExecution(execution)
+	return nil
+}
+
+func (r *workflowRepository) GetByID(ctx context.Context, executionID uuid.UUID) (*domain.WorkflowExecution, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	execution, ok := s.executions[executionID]
+	if !ok || !visible(ctx, execution.Tenant) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return copyExecution(execution), nil
+}
+
+func (r *workflowRepository) GetByIdempotencyKey(ctx context.Context, key string) (*domain.WorkflowExecution, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, execution := range s.executions {
+		if execution.IdempotencyKey != nil && *execution.IdempotencyKey == key && visible(ctx, execution.Tenant) {
+			return copyExecution(execution), nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// UpdateStatus has the same guard as the Postgres repository: the update is a no-op when the
+// status is unchanged, and a COMPLETED, FAILED or CANCELLED workflow is never overwritten.
+func (r *workflowRepository) UpdateStatus(ctx context.Context, executionID uuid.UUID, status string) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	execution, ok := s.executions[executionID]
+	if !ok || !visible(ctx, execution.Tenant) || string(execution.Status) == status || execution.IsFinished() {
+		return false, nil
+	}
+	execution.Status = domain.WorkflowStatus(status)
+	execution.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *workflowRepository) MarkFinished(ctx context.Context, executionID uuid.UUID) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	execution, ok := s.executions[executionID]
+	if !ok || !visible(ctx, execution.Tenant) || execution.FinishedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	execution.FinishedAt = &now
+	execution.UpdatedAt = now
+	return true, nil
+}
+
+func (r *workflowRepository) List(ctx context.Context, filter domain.WorkflowFilter) ([]domain.WorkflowExecution, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	executions := make([]domain.WorkflowExecution, 0)
+	for _, execution := range s.executions {
+		if !s.matchesFilter(ctx, execution, filter) {
+			continue
+		}
+		if filter.Status != "" && execution.Status != filter.Status {
+			continue
+		}
+		if filter.After != nil && !before(execution, filter.After) {
+			continue
+		}
+		executions = append(executions, *copyExecution(execution))
+	}
+
+	// Newest first, ties broken by ID like ORDER BY created_at DESC, id DESC
+	sort.Slice(executions, func(i, j int) bool {
+		return before(&executions[j], &domain.WorkflowCursor{CreatedAt: executions[i].CreatedAt, ID: executions[i].ID})
+	})
+	if filter.Limit > 0 && len(executions) > filter.Limit {
+		executions = executions[:filter.Limit]
+	}
+	return executions, nil
+}
+
+func (r *workflowRepository) CountByStatus(ctx context.Context, filter domain.WorkflowFilter) (map[domain.WorkflowStatus]int64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[domain.WorkflowStatus]int64)
+	for _, execution := range s.executions {
+		if s.matchesFilter(ctx, execution, filter) {
+			counts[execution.Status]++
+		}
+	}
+	return counts, nil
+}
+
+// matchesFilter applies the conditions shared by List and CountByStatus. Callers hold s.mu.
+func (s *Store) matchesFilter(ctx context.Context, execution *domain.WorkflowExecution, filter domain.WorkflowFilter) bool {
+	if !visible(ctx, execution.Tenant) {
+		return false
+	}
+	if filter.UserID != uuid.Nil && execution.UserID != filter.UserID {
+		return false
+	}
+	if filter.WorkflowType != "" && execution.WorkflowType != filter.WorkflowType {
+		return false
+	}
+	if !filter.CreatedAfter.IsZero() && execution.CreatedAt.Before(filter.CreatedAfter) {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && !execution.CreatedAt.Before(filter.CreatedBefore) {
+		return false
+	}
+	if !filter.UpdatedAfter.IsZero() && execution.UpdatedAt.Before(filter.UpdatedAfter) {
+		return false
+	}
+	if !filter.UpdatedBefore.IsZero() && !execution.UpdatedAt.Before(filter.UpdatedBefore) {
+		return false
+	}
+	if filter.FailingAction != "" {
+		failing := false
+		for _, task := range s.executionTasks(execution.ID) {
+			if task.Action == filter.FailingAction && task.Status == domain.StatusFailed {
+				failing = true
+				break
+			}
+		}
+		if !failing {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyKeyTaken mirrors the unique (tenant, idempotency_key) index
+func (s *Store) idempotencyKeyTaken(execution *domain.WorkflowExecution) bool {
+	if execution.IdempotencyKey == nil {
+		return false
+	}
+	for _, existing := range s.executions {
+		if existing.IdempotencyKey != nil && *existing.IdempotencyKey == *execution.IdempotencyKey &&
+			existing.Tenant == execution.Tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// visible reports whether a row of the tenant can be seen with ctx, like the tenant condition
+// the Postgres repositories add
+func visible(ctx context.Context, tenant string) bool {
+	scope, ok := domain.TenantFromContext(ctx)
+	return !ok || scope == tenant
+}
+
+// before reports whether (created_at, id) of the execution sorts below the cursor
+func before(execution *domain.WorkflowExecution, cursor *domain.WorkflowCursor) bool {
+	if !execution.CreatedAt.Equal(cursor.CreatedAt) {
+		return execution.CreatedAt.Before(cursor.CreatedAt)
+	}
+	return bytes.Compare(execution.ID[:], cursor.ID[:]) < 0
+}
+
+// applyWorkflowDefaults fills zero values with the column defaults Postgres would apply
+func applyWorkflowDefaults(execution *domain.WorkflowExecution, now time.Time) {
+	if execution.Status == "" {
+		execution.Status = domain.WorkflowRunning
+	}
+	if execution.Tenant == "" {
+		execution.Tenant = domain.DefaultTenant
+	}
+	if execution.CreatedAt.IsZero() {
+		execution.CreatedAt = now
+	}
+	execution.UpdatedAt = now
+}
@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/metrics"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript checks both limits before taking anything, so a throttled task holds neither
+// a slot nor a token. Slots are members of a sorted set scored by lease expiry; the rate is a
+// token bucket of Rate tokens refilled over RatePeriod. Server time keeps workers consistent.
+var acquireScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local lease = tonumber(ARGV[1])
+local max = tonumber(ARGV[2])
+local rate = tonumber(ARGV[3])
+local period = tonumber(ARGV[4])
+
+if max > 0 then
+	redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+	if not redis.call('ZSCORE', KEYS[1], ARGV[5]) and redis.call('ZCARD', KEYS[1]) >= max then
+		return {'concurrency', 0}
+	end
+end
+
+if rate > 0 then
+	local state = redis.call('HMGET', KEYS[2], 'tokens', 'ts')
+	local tokens = tonumber(state[1]) or rate
+	local ts = tonumber(state[2]) or now
+	tokens = math.min(rate, tokens + (now - ts) * rate / period)
+	if tokens < 1 then
+		return {'rate', math.ceil((1 - tokens) * period / rate)}
+	end
+	redis.call('HSET', KEYS[2], 'tokens', tokens - 1, 'ts', now)
+	redis.call('PEXPIRE', KEYS[2], period * 2)
+end
+
+if max > 0 then
+	redis.call('ZADD', KEYS[1], now + lease, ARGV[5])
+	redis.call('PEXPIRE', KEYS[1], lease * 2)
+end
+return {'', 0}
+`)
+
+// renewScript extends the lease of a slot that is still held
+var renewScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local lease = tonumber(ARGV[1])
+redis.call('ZADD', KEYS[1], 'XX', now + lease, ARGV[2])
+redis.call('PEXPIRE', KEYS[1], lease * 2)
+return 1
+`)
+
+// RedisActionLimiter is a cluster-wide ActionLimiter: a semaphore and a token bucket per action
+type RedisActionLimiter struct {
+	client   *redis.Client
+	prefix   string
+	limits   map[string]domain.ActionLimit
+	leaseTTL time.Duration
+}
+
+func NewRedisActionLimiter(client *redis.Client, limits map[string]domain.ActionLimit, leaseTTL time.Duration) *RedisActionLimiter {
+	return &RedisActionLimiter{
+		client:   client,
+		prefix:   "workflow:limits:",
+		limits:   limits,
+		leaseTTL: leaseTTL,
+	}
+}
+
+// Acquire takes a slot and a token for actions that have limits; others are never throttled
+func (l *RedisActionLimiter) Acquire(ctx context.Context, action string, taskID string) (string, time.Duration, error) {
+	limit, ok := l.limits[action]
+	if !ok {
+		return "", 0, nil
+	}
+
+	keys := []string{l.prefix + action + ":slots", l.prefix + action + ":tokens"}
+	result, err := acquireScript.Run(ctx, l.client, keys,
+		l.leaseTTL.Milliseconds(), limit.MaxConcurrent, limit.Rate, limit.RatePeriod.Milliseconds(), taskID,
+	).Slice()
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("limit_acquire").Inc()
+		return "", 0, err
+	}
+	if len(result) != 2 {
+		return "", 0, fmt.Errorf("unexpected limiter reply %v", result)
+	}
+
+	throttledBy, _ := result[0].(string)
+	retryMillis, _ := result[1].(int64)
+	return throttledBy, time.Duration(retryMillis) * time.Millisecond, nil
+}
+
+func (l *RedisActionLimiter) Renew(ctx context.Context, action string, taskID string) error {
+	if l.limits[action].MaxConcurrent <= 0 {
+		return nil
+	}
+
+	err := renewScript.Run(ctx, l.client, []string{l.prefix + action + ":slots"}, l.leaseTTL.Milliseconds(), taskID).Err()
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("limit_renew").Inc()
+	}
+	return err
+}
+
+func (l *RedisActionLimiter) Release(ctx context.Context, action string, taskID string) error {
+	if l.limits[action].MaxConcurrent <= 0 {
+		return nil
+	}
+
+	err := l.client.ZRem(ctx, l.prefix+action+":slots", taskID).Err()
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("limit_release").Inc()
+	}
+	return err
+}
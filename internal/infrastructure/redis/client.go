@@ -2,14 +2,31 @@ package redis
 
 import (
 	"context"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-func NewRedisClient(address string) *redis.Client {
+// ClientOptions configures the Redis connection pool
+type ClientOptions struct {
+	Addr         string // e.g., "localhost:6379"
+	Password     string
+	DB           int
+	PoolSize     int // Maximum number of socket connections
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+func NewRedisClient(opts ClientOptions) *redis.Client {
 	client := redis.NewClient(&redis.Options{
-		Addr:     address, // e.g., "localhost:6379"
-		PoolSize: 100,     // Maximum number of socket connections
+		Addr:         opts.Addr,
+		Password:     opts.Password,
+		DB:           opts.DB,
+		PoolSize:     opts.PoolSize,
+		DialTimeout:  opts.DialTimeout,
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
 	})
 	
 	// Ping to test connection on startup
@@ -18,4 +35,4 @@ func NewRedisClient(address string) *redis.Client {
 	}
 	
 	return client
-}
\ No newline at end of file
+}
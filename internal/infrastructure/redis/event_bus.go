@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"go-tempo/internal/domain" // Update with your actual module path
 	"go-tempo/internal/metrics"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -13,13 +15,23 @@ type RedisEventBus struct {
 	client              *redis.Client
 	channel             string
 	terminationChannel  string
+	lifecyclePrefix     string // Pub/Sub channel prefix, one channel per execution
+	historyPrefix       string // Stream key prefix, one stream per execution
+	historyTTL          time.Duration
+	historyMaxLen       int64
 }
 
-func NewRedisEventBus(client *redis.Client) *RedisEventBus {
+// NewRedisEventBus creates the event bus. Lifecycle event history is kept per execution
+// for historyTTL and trimmed to roughly historyMaxLen entries.
+func NewRedisEventBus(client *redis.Client, historyTTL time.Duration, historyMaxLen int64) *RedisEventBus {
 	return &RedisEventBus{
 		client:              client,
 		channel:             "workflow:events:completed",
 		terminationChannel:  "workflow:events:terminated",
+		lifecyclePrefix:     "workflow:events:lifecycle:",
+		historyPrefix:       "workflow:events:history:",
+		historyTTL:          historyTTL,
+		historyMaxLen:       historyMaxLen,
 	}
 }
 
@@ -79,7 +91,10 @@ func (b *RedisEventBus) SubscribeToEvents(ctx context.Context) (<-chan domain.Ta
 					var event domain.TaskCompletedEvent
 					if err := json.Unmarshal([]byte(msg.Payload), &event); err == nil {
 						metrics.RedisPubSubMessagesReceivedTotal.WithLabelValues("completed").Inc()
-						msgChan <- event
+						select {
+						case msgChan <- event:
+						case <-ctx.Done(): // The subscriber stopped reading
+						}
 					}
 				} else {
 					metrics.RedisConnectionErrorsTotal.WithLabelValues("subscribe").Inc()
@@ -110,7 +125,10 @@ func (b *RedisEventBus) SubscribeToTerminationEvents(ctx context.Context) (<-cha
 					var event domain.TaskTerminatedEvent
 					if err := json.Unmarshal([]byte(msg.Payload), &event); err == nil {
 						metrics.RedisPubSubMessagesReceivedTotal.WithLabelValues("terminated").Inc()
-						msgChan <- event
+						select {
+						case msgChan <- event:
+						case <-ctx.Done(): // The subscriber stopped reading
+						}
 					}
 				} else {
 					metrics.RedisConnectionErrorsTotal.WithLabelValues("subscribe").Inc()
@@ -120,4 +138,115 @@ func (b *RedisEventBus) SubscribeToTerminationEvents(ctx context.Context) (<-cha
 	}()
 
 	return msgChan, nil
-}
\ No newline at end of file
+}
+
+// PublishWorkflowEvent appends the event to the execution's history stream and broadcasts it.
+// The stream entry ID becomes the event ID, so clients can resume from it.
+func (b *RedisEventBus) PublishWorkflowEvent(ctx context.Context, event domain.WorkflowEvent) (string, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	historyKey := b.historyPrefix + event.ExecutionID.String()
+	id, err := b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: historyKey,
+		MaxLen: b.historyMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": payload},
+	}).Result()
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("xadd").Inc()
+		return "", err
+	}
+	b.client.Expire(ctx, historyKey, b.historyTTL)
+
+	// Re-marshal with the assigned ID for live subscribers
+	event.ID = id
+	payload, err = json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	err = b.client.Publish(ctx, b.lifecyclePrefix+event.ExecutionID.String(), payload).Err()
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("publish").Inc()
+		return "", err
+	}
+
+	metrics.RedisPubSubMessagesPublishedTotal.WithLabelValues("lifecycle").Inc()
+	return id, nil
+}
+
+// SubscribeToWorkflowEvents opens a stream of lifecycle events for one execution
+func (b *RedisEventBus) SubscribeToWorkflowEvents(ctx context.Context, executionID uuid.UUID) (<-chan domain.WorkflowEvent, error) {
+	pubsub := b.client.Subscribe(ctx, b.lifecyclePrefix+executionID.String())
+
+	// Wait for the subscription to be confirmed so no event published afterwards is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("subscribe").Inc()
+		return nil, err
+	}
+
+	msgChan := make(chan domain.WorkflowEvent)
+
+	go func() {
+		defer close(msgChan)
+		defer pubsub.Close()
+		for {
+			msg, err := pubsub.ReceiveMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				metrics.RedisConnectionErrorsTotal.WithLabelValues("subscribe").Inc()
+				continue
+			}
+
+			var event domain.WorkflowEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				continue
+			}
+			metrics.RedisPubSubMessagesReceivedTotal.WithLabelValues("lifecycle").Inc()
+
+			select {
+			case msgChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgChan, nil
+}
+
+// GetWorkflowEventsSince reads the execution's history stream after lastEventID
+func (b *RedisEventBus) GetWorkflowEventsSince(ctx context.Context, executionID uuid.UUID, lastEventID string) ([]domain.WorkflowEvent, error) {
+	start := "-"
+	if lastEventID != "" {
+		start = "(" + lastEventID // Exclusive range start
+	}
+
+	entries, err := b.client.XRange(ctx, b.historyPrefix+executionID.String(), start, "+").Result()
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("xrange").Inc()
+		return nil, err
+	}
+
+	events := make([]domain.WorkflowEvent, 0, len(entries))
+	for _, entry := range entries {
+		payload, ok := entry.Values["event"].(string)
+		if !ok {
+			continue
+		}
+		var event domain.WorkflowEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			continue
+		}
+		event.ID = entry.ID
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -2,27 +2,159 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"go-tempo/internal/domain"
 	"go-tempo/internal/metrics"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// popWaitTimeout bounds each wait for a push so Pop notices a canceled context
+const popWaitTimeout = 2 * time.Second
+
+// wakeBacklog caps the wake-up tokens left behind when no popper is waiting
+const wakeBacklog = 64
+
+// enqueueLua defines enqueue, which adds the member to its tenant's sorted set once and counts
+// it in the per-priority depth hash. A tenant that had nothing queued rejoins at the queue's
+// virtual time, so it can't bank the turns it missed while idle.
+const enqueueLua = `
+local function enqueue(tenantKey, tenantsKey, clockKey, depthKey, wakeKey, score, member, priority, tenant, backlog)
+	local added = redis.call('ZADD', tenantKey, 'NX', score, member)
+	if added == 1 then
+		redis.call('HINCRBY', depthKey, priority, 1)
+		if not redis.call('ZSCORE', tenantsKey, tenant) then
+			local now = tonumber(redis.call('HGET', clockKey, '') or '0')
+			local last = tonumber(redis.call('HGET', clockKey, tenant) or '0')
+			redis.call('ZADD', tenantsKey, math.max(now, last), tenant)
+		end
+		redis.call('RPUSH', wakeKey, '1')
+		redis.call('LTRIM', wakeKey, -tonumber(backlog), -1)
+	end
+	return added
+end
+`
+
+// pushScript enqueues the member of a task that is due
+var pushScript = redis.NewScript(enqueueLua + `
+return enqueue(KEYS[1], KEYS[2], KEYS[3], KEYS[4], KEYS[5], ARGV[1], ARGV[2], ARGV[3], ARGV[4], ARGV[5])
+`)
+
+// promoteScript enqueues the delayed members that are due by ARGV[1], in milliseconds, as if
+// pushed then. Delayed members are "<tenant>|<priority>:<taskID>", scored by when they are due.
+// ARGV[2] prefixes tenant keys.
+var promoteScript = redis.NewScript(enqueueLua + `
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, 100)
+for i = 1, #due, 2 do
+	local tenant, member = string.match(due[i], '^([^|]*)|(.*)$')
+	local priority = string.match(member, '^(-?%d+):')
+	local score = tonumber(due[i + 1]) - tonumber(priority) * tonumber(ARGV[3])
+	redis.call('ZREM', KEYS[1], due[i])
+	enqueue(ARGV[2] .. tenant, KEYS[2], KEYS[3], KEYS[4], KEYS[5], score, member, priority, tenant, ARGV[4])
+end
+return #due / 2
+`)
+
+// popScript serves the tenant with the lowest virtual time, advances it by 1/weight and moves
+// the queue's virtual time up to the turn just served. ARGV[1] prefixes tenant keys; the rest
+// are tenant, weight pairs.
+var popScript = redis.NewScript(`
+for _ = 1, 16 do
+	local head = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	if #head == 0 then
+		return false
+	end
+	local tenant, vt = head[1], tonumber(head[2])
+	local key = ARGV[1] .. tenant
+	local item = redis.call('ZPOPMIN', key)
+	if #item > 0 then
+		local weight = 1
+		for i = 2, #ARGV - 1, 2 do
+			if ARGV[i] == tenant then
+				weight = tonumber(ARGV[i + 1])
+			end
+		end
+		local nextVt = vt + 1 / weight
+		redis.call('HSET', KEYS[2], '', vt, tenant, nextVt)
+		if redis.call('EXISTS', key) == 1 then
+			redis.call('ZADD', KEYS[1], nextVt, tenant)
+		else
+			redis.call('ZREM', KEYS[1], tenant)
+		end
+		redis.call('HINCRBY', KEYS[3], string.match(item[1], '^(-?%d+):'), -1)
+		return item[1]
+	end
+	redis.call('ZREM', KEYS[1], tenant)
+end
+return false
+`)
+
+// RedisQueue keeps a sorted set per tenant, scored by enqueue time minus priority ×
+// agingInterval, so within a tenant higher priorities pop first and a waiting task gains one
+// level per aging interval. Members are "<priority>:<taskID>".
+//
+// Tenants take turns by start-time fair queueing: queueName:tenants holds every tenant with
+// tasks queued, scored by its virtual time, and Pop serves the lowest. Each turn costs a tenant
+// 1/weight, so a tenant of weight 2 is served twice as often as one of weight 1 while both have
+// work, and one tenant's burst only delays the others by their share. queueName:clock remembers
+// virtual times, queueName:depth counts members per priority for the metrics and
+// queueName:wake wakes blocked poppers.
+//
+// A task pushed with a NextAttemptAt in the future waits in queueName:delayed, scored by when it
+// is due, and Pop moves due tasks to their tenant's set first. Nothing wakes poppers when a
+// delayed task falls due, so an idle queue hands it out within popWaitTimeout.
 type RedisQueue struct {
-	client    *redis.Client
-	queueName string
+	client        *redis.Client
+	queueName     string
+	delayedKey    string
+	tenantsKey    string
+	clockKey      string
+	depthKey      string
+	wakeKey       string
+	agingInterval time.Duration
+	weightArgs    []interface{}
 }
 
-func NewRedisQueue(client *redis.Client, queueName string) *RedisQueue {
+func NewRedisQueue(client *redis.Client, queueName string, agingInterval time.Duration, weights domain.TenantWeights) *RedisQueue {
+	weightArgs := make([]interface{}, 0, 2*len(weights))
+	for tenant := range weights {
+		weightArgs = append(weightArgs, tenant, weights.Of(tenant))
+	}
+
 	return &RedisQueue{
-		client:    client,
-		queueName: queueName,
+		client:        client,
+		queueName:     queueName,
+		delayedKey:    queueName + ":delayed",
+		tenantsKey:    queueName + ":tenants",
+		clockKey:      queueName + ":clock",
+		depthKey:      queueName + ":depth",
+		wakeKey:       queueName + ":wake",
+		agingInterval: agingInterval,
+		weightArgs:    weightArgs,
 	}
 }
 
-// Push adds a task ID to the end of the list
-func (q *RedisQueue) Push(ctx context.Context, taskID string) error {
-	err := q.client.RPush(ctx, q.queueName, taskID).Err()
+// Push adds a task ID to its tenant's set at the task's priority, or to the delayed set until
+// its NextAttemptAt
+func (q *RedisQueue) Push(ctx context.Context, task *domain.Task) error {
+	member := strconv.Itoa(task.Priority) + ":" + task.ID.String()
+	tenant := taskTenant(task)
+
+	var err error
+	if task.NextAttemptAt != nil && task.NextAttemptAt.After(time.Now()) {
+		err = q.client.ZAdd(ctx, q.delayedKey, redis.Z{
+			Score:  float64(task.NextAttemptAt.UnixMilli()),
+			Member: tenant + "|" + member,
+		}).Err()
+	} else {
+		score := time.Now().UnixMilli() - int64(task.Priority)*q.agingInterval.Milliseconds()
+		keys := []string{q.tenantKey(tenant), q.tenantsKey, q.clockKey, q.depthKey, q.wakeKey}
+		err = pushScript.Run(ctx, q.client, keys, score, member, task.Priority, tenant, wakeBacklog).Err()
+	}
 	if err != nil {
 		metrics.RedisQueuePushTotal.WithLabelValues("error").Inc()
 		metrics.RedisConnectionErrorsTotal.WithLabelValues("push").Inc()
@@ -32,19 +164,82 @@ func (q *RedisQueue) Push(ctx context.Context, taskID string) error {
 	return nil
 }
 
-// Pop waits for a task ID and removes it from the front of the list
+// Pop waits for a task ID and removes it: the lowest score of the tenant whose turn it is
 func (q *RedisQueue) Pop(ctx context.Context) (string, error) {
 	start := time.Now()
 	defer func() {
 		metrics.RedisQueuePopDuration.Observe(time.Since(start).Seconds())
 	}()
-	
-	// 0 means "Wait forever until an item appears"
-	result, err := q.client.BLPop(ctx, 0*time.Second, q.queueName).Result()
+
+	keys := []string{q.tenantsKey, q.clockKey, q.depthKey}
+	args := append([]interface{}{q.tenantKey("")}, q.weightArgs...)
+	promoteKeys := []string{q.delayedKey, q.tenantsKey, q.clockKey, q.depthKey, q.wakeKey}
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		err := promoteScript.Run(ctx, q.client, promoteKeys,
+			time.Now().UnixMilli(), q.tenantKey(""), q.agingInterval.Milliseconds(), wakeBacklog).Err()
+		if err != nil {
+			metrics.RedisConnectionErrorsTotal.WithLabelValues("pop").Inc()
+			return "", err
+		}
+
+		member, err := popScript.Run(ctx, q.client, keys, args...).Text()
+		if errors.Is(err, redis.Nil) {
+			// Queue empty; wait for a push, timing out to recheck ctx
+			err = q.client.BLPop(ctx, popWaitTimeout, q.wakeKey).Err()
+			if err != nil && !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+				metrics.RedisConnectionErrorsTotal.WithLabelValues("pop").Inc()
+				return "", err
+			}
+			continue
+		}
+		if err != nil {
+			metrics.RedisConnectionErrorsTotal.WithLabelValues("pop").Inc()
+			return "", err
+		}
+
+		_, taskID, ok := strings.Cut(member, ":")
+		if !ok {
+			return "", fmt.Errorf("malformed queue member %q", member)
+		}
+		return taskID, nil
+	}
+}
+
+// DepthByPriority reads the per-priority member counts
+func (q *RedisQueue) DepthByPriority(ctx context.Context) (map[int]int64, error) {
+	counts, err := q.client.HGetAll(ctx, q.depthKey).Result()
 	if err != nil {
-		metrics.RedisConnectionErrorsTotal.WithLabelValues("pop").Inc()
-		return "", err
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("depth").Inc()
+		return nil, err
+	}
+
+	depth := make(map[int]int64, len(counts))
+	for field, value := range counts {
+		priority, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		depth[priority] = count
 	}
-	// BLPop returns a slice: [QueueName, Element]
-	return result[1], nil 
-}
\ No newline at end of file
+	return depth, nil
+}
+
+func (q *RedisQueue) tenantKey(tenant string) string {
+	return q.queueName + ":tenant:" + tenant
+}
+
+// taskTenant returns the tenant of a task, treating rows from before tenants as the default one
+func taskTenant(task *domain.Task) string {
+	if task.Tenant == "" {
+		return domain.DefaultTenant
+	}
+	return task.Tenant
+}
@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"go-tempo/internal/metrics"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRateLimiter is a cluster-wide RateLimiter. Without a concurrency limit acquireScript
+// only runs its token bucket, so it is shared with the action limiter.
+type RedisRateLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisRateLimiter(client *redis.Client) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client: client,
+		prefix: "workflow:rate:",
+	}
+}
+
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, rate int, period time.Duration) (bool, time.Duration, error) {
+	keys := []string{l.prefix + key + ":slots", l.prefix + key + ":tokens"}
+	result, err := acquireScript.Run(ctx, l.client, keys, 0, 0, rate, period.Milliseconds(), "").Slice()
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("rate_limit").Inc()
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected limiter reply %v", result)
+	}
+
+	throttledBy, _ := result[0].(string)
+	retryMillis, _ := result[1].(int64)
+	return throttledBy == "", time.Duration(retryMillis) * time.Millisecond, nil
+}
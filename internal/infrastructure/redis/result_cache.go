@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"go-tempo/internal/metrics"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisResultCache struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func NewRedisResultCache(client *redis.Client, ttl time.Duration) *RedisResultCache {
+	return &RedisResultCache{
+		client: client,
+		prefix: "workflow:results:",
+		ttl:    ttl,
+	}
+}
+
+// Get returns the cached output stored under the idempotency key
+func (c *RedisResultCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	output, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("result_get").Inc()
+		return nil, false, err
+	}
+	return output, true, nil
+}
+
+// Put stores the output under the idempotency key until the TTL expires
+func (c *RedisResultCache) Put(ctx context.Context, key string, output []byte) error {
+	err := c.client.Set(ctx, c.prefix+key, output, c.ttl).Err()
+	if err != nil {
+		metrics.RedisConnectionErrorsTotal.WithLabelValues("result_put").Inc()
+	}
+	return err
+}
@@ -0,0 +1,173 @@
+package mapper
+
+import (
+	"encoding/json"
+	"go-tempo/internal/api/dto"
+	"go-tempo/internal/api/rpc/tempov1"
+	"go-tempo/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FromSubmitWorkflowProto converts a gRPC submission to the DTO validated by the REST API
+func FromSubmitWorkflowProto(req *tempov1.SubmitWorkflowRequest) (dto.CreateWorkflowRequest, error) {
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return dto.CreateWorkflowRequest{}, err
+	}
+
+	tasks := make([]dto.TaskDTO, 0, len(req.GetTasks()))
+	for _, spec := range req.GetTasks() {
+		task := dto.TaskDTO{
+			RefID:        spec.GetRefId(),
+			Action:       spec.GetAction(),
+			Dependencies: spec.GetDependencies(),
+			TaskQueue:    spec.GetTaskQueue(),
+		}
+		if spec.Input != nil {
+			task.Input = spec.Input.AsMap()
+		}
+		if spec.Priority != nil {
+			priority := int(spec.GetPriority())
+			task.Priority = &priority
+		}
+		tasks = append(tasks, task)
+	}
+
+	resp := dto.CreateWorkflowRequest{
+		Type:           req.GetType(),
+		UserID:         userID,
+		Tasks:          tasks,
+		CallbackURL:    req.GetCallbackUrl(),
+		CallbackEvents: req.GetCallbackEvents(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+	}
+	if req.Priority != nil {
+		priority := int(req.GetPriority())
+		resp.Priority = &priority
+	}
+	return resp, nil
+}
+
+// FromListWorkflowsProto converts a gRPC search to the query parameters of the REST API
+func FromListWorkflowsProto(req *tempov1.ListWorkflowsRequest) dto.ListWorkflowsQuery {
+	return dto.ListWorkflowsQuery{
+		UserID:        req.GetUserId(),
+		Type:          req.GetType(),
+		Status:        req.GetStatus(),
+		CreatedAfter:  fromTimestamp(req.GetCreatedAfter()),
+		CreatedBefore: fromTimestamp(req.GetCreatedBefore()),
+		UpdatedAfter:  fromTimestamp(req.GetUpdatedAfter()),
+		UpdatedBefore: fromTimestamp(req.GetUpdatedBefore()),
+		FailingAction: req.GetFailingAction(),
+		Cursor:        req.GetCursor(),
+		Limit:         int(req.GetLimit()),
+	}
+}
+
+// ToWorkflowProto converts an execution, with its tasks when they are loaded
+func ToWorkflowProto(execution domain.WorkflowExecution) *tempov1.Workflow {
+	workflow := &tempov1.Workflow{
+		ExecutionId: execution.ID.String(),
+		UserId:      execution.UserID.String(),
+		Tenant:      execution.Tenant,
+		Type:        execution.WorkflowType,
+		Status:      string(execution.Status),
+		Priority:    int32(execution.Priority),
+		CreatedAt:   timestamppb.New(execution.CreatedAt),
+		UpdatedAt:   timestamppb.New(execution.UpdatedAt),
+	}
+	for _, task := range execution.Tasks {
+		workflow.Tasks = append(workflow.Tasks, ToTaskProto(task))
+	}
+	return workflow
+}
+
+// ToTaskProto converts a task, omitting its input
+func ToTaskProto(task domain.Task) *tempov1.Task {
+	resp := ToTaskResponse(task)
+	proto := &tempov1.Task{
+		TaskId:       resp.ID.String(),
+		RefId:        resp.RefID,
+		Action:       resp.Action,
+		Dependencies: resp.Dependencies,
+		TaskQueue:    resp.TaskQueue,
+		Priority:     int32(resp.Priority),
+		Status:       resp.Status,
+		Attempts:     int32(resp.Attempts),
+		MaxRetries:   int32(resp.MaxRetries),
+		WorkerId:     resp.WorkerID,
+		LastError:    resp.LastError,
+		UpdatedAt:    timestamppb.New(resp.UpdatedAt),
+	}
+	if len(resp.Output) > 0 {
+		var output any
+		if err := json.Unmarshal(resp.Output, &output); err == nil {
+			proto.Output, _ = structpb.NewValue(output)
+		}
+	}
+	proto.Progress = toTaskProgressProto(task.Progress())
+	return proto
+}
+
+func toTaskProgressProto(progress *domain.TaskProgress) *tempov1.TaskProgress {
+	if progress == nil {
+		return nil
+	}
+	proto := &tempov1.TaskProgress{
+		Percent:   int32(progress.Percent),
+		Message:   progress.Message,
+		UpdatedAt: timestamppb.New(progress.UpdatedAt),
+	}
+	if len(progress.Details) > 0 {
+		var details any
+		if err := json.Unmarshal(progress.Details, &details); err == nil {
+			proto.Details, _ = structpb.NewValue(details)
+		}
+	}
+	return proto
+}
+
+// ToListWorkflowsProto converts a page of executions to its gRPC representation
+func ToListWorkflowsProto(page *domain.WorkflowPage) *tempov1.ListWorkflowsResponse {
+	resp := &tempov1.ListWorkflowsResponse{}
+	for _, execution := range page.Workflows {
+		resp.Workflows = append(resp.Workflows, ToWorkflowProto(execution))
+	}
+	if page.NextCursor != nil {
+		resp.NextCursor = EncodeCursor(*page.NextCursor)
+	}
+	return resp
+}
+
+// ToWorkflowEventProto converts a lifecycle event to its gRPC representation
+func ToWorkflowEventProto(event domain.WorkflowEvent) *tempov1.WorkflowEvent {
+	proto := &tempov1.WorkflowEvent{
+		Id:          event.ID,
+		ExecutionId: event.ExecutionID.String(),
+		Type:        string(event.Type),
+		RefId:       event.RefID,
+		Action:      event.Action,
+		Attempt:     int32(event.Attempt),
+		WorkerId:    event.WorkerID,
+		Error:       event.Error,
+		Status:      event.Status,
+		Timestamp:   timestamppb.New(event.Timestamp),
+		Progress:    toTaskProgressProto(event.Progress),
+	}
+	if event.TaskID != uuid.Nil {
+		proto.TaskId = event.TaskID.String()
+	}
+	return proto
+}
+
+// fromTimestamp converts an unset timestamp to the zero time, which filters nothing
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
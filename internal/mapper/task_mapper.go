@@ -0,0 +1,24 @@
+package mapper
+
+import (
+	"encoding/json"
+	"go-tempo/internal/api/dto"
+	"go-tempo/internal/domain"
+	"time"
+)
+
+// ToLeasedTaskResponse converts a task leased to an external worker to its API representation
+func ToLeasedTaskResponse(task *domain.Task, leaseToken string, leaseExpiresAt time.Time) dto.LeasedTaskResponse {
+	return dto.LeasedTaskResponse{
+		TaskID:         task.ID,
+		ExecutionID:    task.ExecutionID,
+		Tenant:         task.Tenant,
+		RefID:          task.RefID,
+		Action:         task.Action,
+		Input:          json.RawMessage(task.Input),
+		Attempt:        task.RetryCount + 1,
+		MaxRetries:     task.MaxRetries,
+		LeaseToken:     leaseToken,
+		LeaseExpiresAt: leaseExpiresAt,
+	}
+}
@@ -0,0 +1,40 @@
+package mapper
+
+import (
+	"encoding/json"
+	"go-tempo/internal/api/dto"
+	"go-tempo/internal/domain"
+)
+
+// ToWebhookResponse converts a subscription to its API representation without the secret
+func ToWebhookResponse(sub domain.WebhookSubscription) dto.WebhookResponse {
+	events := make([]string, 0)
+	if len(sub.Events) > 0 {
+		json.Unmarshal(sub.Events, &events)
+	}
+
+	return dto.WebhookResponse{
+		ID:        sub.ID,
+		URL:       sub.URL,
+		Events:    events,
+		CreatedAt: sub.CreatedAt,
+	}
+}
+
+// ToWebhookDeliveryResponse converts a delivery log entry to its API representation
+func ToWebhookDeliveryResponse(delivery domain.WebhookDelivery) dto.WebhookDeliveryResponse {
+	return dto.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		ExecutionID:    delivery.ExecutionID,
+		SubscriptionID: delivery.SubscriptionID,
+		URL:            delivery.URL,
+		EventType:      string(delivery.EventType),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseCode:   delivery.ResponseCode,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
@@ -1,7 +1,9 @@
 package mapper
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"go-tempo/internal/api/dto"
 	"go-tempo/internal/domain"
 
@@ -12,10 +14,25 @@ import (
 // Returns the workflow execution and all tasks
 func ToWorkflowExecution(req dto.CreateWorkflowRequest) (*domain.WorkflowExecution, []domain.Task) {
 	execution := domain.NewWorkflow(req.UserID, req.Type)
+	execution.CallbackURL = req.CallbackURL
+	if req.Priority != nil {
+		execution.Priority = *req.Priority
+	}
+	if len(req.CallbackEvents) > 0 {
+		eventsJSON, _ := json.Marshal(req.CallbackEvents)
+		execution.CallbackEvents = eventsJSON
+	}
+	if req.IdempotencyKey != "" {
+		key := req.IdempotencyKey
+		execution.IdempotencyKey = &key
+	}
 	
 	tasks := make([]domain.Task, 0, len(req.Tasks))
 	for _, taskDTO := range req.Tasks {
 		task := ToTask(execution.ID, taskDTO)
+		if taskDTO.Priority == nil {
+			task.Priority = execution.Priority
+		}
 		tasks = append(tasks, *task)
 	}
 	
@@ -34,6 +51,12 @@ func ToTask(workflowID uuid.UUID, taskDTO dto.TaskDTO) *domain.Task {
 	// Marshal input to JSON
 	inputJSON, _ := json.Marshal(taskDTO.Input)
 	task.Input = inputJSON
+
+	if taskDTO.Priority != nil {
+		task.Priority = *taskDTO.Priority
+	}
+	// Left empty, the service routes the task by its action
+	task.TaskQueue = taskDTO.TaskQueue
 	
 	// Set initial status based on dependencies
 	if task.InDegree == 0 {
@@ -44,3 +67,175 @@ func ToTask(workflowID uuid.UUID, taskDTO dto.TaskDTO) *domain.Task {
 	
 	return task
 }
+
+// ToWorkflowHistoryResponse converts the persisted history of an execution to its API representation
+func ToWorkflowHistoryResponse(executionID uuid.UUID, records []domain.WorkflowEventRecord) dto.WorkflowHistoryResponse {
+	events := make([]dto.WorkflowHistoryEntryResponse, 0, len(records))
+	for _, record := range records {
+		events = append(events, dto.WorkflowHistoryEntryResponse{
+			Type:       string(record.Type),
+			TaskID:     record.TaskID,
+			RefID:      record.RefID,
+			Action:     record.Action,
+			Attempt:    record.Attempt,
+			WorkerID:   record.WorkerID,
+			Error:      record.Error,
+			Status:     record.Status,
+			OccurredAt: record.OccurredAt,
+		})
+	}
+
+	return dto.WorkflowHistoryResponse{
+		ExecutionID: executionID,
+		Events:      events,
+	}
+}
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ToWorkflowFilter converts list query parameters to a domain filter
+func ToWorkflowFilter(query dto.ListWorkflowsQuery) (domain.WorkflowFilter, error) {
+	filter := domain.WorkflowFilter{
+		WorkflowType:  query.Type,
+		Status:        domain.WorkflowStatus(query.Status),
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+		UpdatedAfter:  query.UpdatedAfter,
+		UpdatedBefore: query.UpdatedBefore,
+		FailingAction: query.FailingAction,
+		Limit:         query.Limit,
+	}
+
+	if query.UserID != "" {
+		userID, err := uuid.Parse(query.UserID)
+		if err != nil {
+			return filter, err
+		}
+		filter.UserID = userID
+	}
+
+	if query.Cursor != "" {
+		cursor, err := DecodeCursor(query.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
+
+// ToListWorkflowsResponse converts a page of executions to its API representation
+func ToListWorkflowsResponse(page *domain.WorkflowPage) dto.ListWorkflowsResponse {
+	workflows := make([]dto.WorkflowSummaryResponse, 0, len(page.Workflows))
+	for _, execution := range page.Workflows {
+		workflows = append(workflows, ToWorkflowSummaryResponse(execution))
+	}
+
+	resp := dto.ListWorkflowsResponse{Workflows: workflows}
+	if page.NextCursor != nil {
+		resp.NextCursor = EncodeCursor(*page.NextCursor)
+	}
+	return resp
+}
+
+// ToWorkflowSummaryResponse converts an execution without its tasks
+func ToWorkflowSummaryResponse(execution domain.WorkflowExecution) dto.WorkflowSummaryResponse {
+	return dto.WorkflowSummaryResponse{
+		ID:        execution.ID,
+		UserID:    execution.UserID,
+		Tenant:    execution.Tenant,
+		Type:      execution.WorkflowType,
+		Status:    string(execution.Status),
+		Priority:  execution.Priority,
+		CreatedAt: execution.CreatedAt,
+		UpdatedAt: execution.UpdatedAt,
+	}
+}
+
+// ToWorkflowDetailResponse converts an execution loaded with its tasks
+func ToWorkflowDetailResponse(execution domain.WorkflowExecution) dto.WorkflowDetailResponse {
+	tasks := make([]dto.TaskResponse, 0, len(execution.Tasks))
+	for _, task := range execution.Tasks {
+		tasks = append(tasks, ToTaskResponse(task))
+	}
+	return dto.WorkflowDetailResponse{
+		WorkflowSummaryResponse: ToWorkflowSummaryResponse(execution),
+		Tasks:                   tasks,
+	}
+}
+
+// ToTaskResponse converts a task, omitting its input
+func ToTaskResponse(task domain.Task) dto.TaskResponse {
+	var dependencies []string
+	if len(task.Dependencies) > 0 {
+		json.Unmarshal(task.Dependencies, &dependencies)
+	}
+	if dependencies == nil {
+		dependencies = make([]string, 0) // Root tasks store a JSON null
+	}
+
+	resp := dto.TaskResponse{
+		ID:           task.ID,
+		RefID:        task.RefID,
+		Action:       task.Action,
+		Dependencies: dependencies,
+		TaskQueue:    task.TaskQueue,
+		Priority:     task.Priority,
+		Status:       string(task.Status),
+		Attempts:     task.RetryCount,
+		MaxRetries:   task.MaxRetries,
+		StartedAt:    task.StartedAt,
+		LastError:    task.LastError,
+		Output:       json.RawMessage(task.Output),
+		UpdatedAt:    task.UpdatedAt,
+	}
+	if task.WorkerID != nil {
+		resp.WorkerID = *task.WorkerID
+	}
+	if progress := task.Progress(); progress != nil {
+		resp.Progress = &dto.TaskProgressResponse{
+			Percent:   progress.Percent,
+			Message:   progress.Message,
+			Details:   progress.Details,
+			UpdatedAt: progress.UpdatedAt,
+		}
+	}
+	return resp
+}
+
+// ToWorkflowStatsResponse converts per-status counts, including zero counts for every status
+func ToWorkflowStatsResponse(counts map[domain.WorkflowStatus]int64) dto.WorkflowStatsResponse {
+	resp := dto.WorkflowStatsResponse{Counts: make(map[string]int64)}
+	for _, status := range []domain.WorkflowStatus{
+		domain.WorkflowRunning, domain.WorkflowCompleted, domain.WorkflowFailed, domain.WorkflowPaused,
+		domain.WorkflowCancelled,
+	} {
+		resp.Counts[string(status)] = 0
+	}
+	for status, count := range counts {
+		resp.Counts[string(status)] = count
+		resp.Total += count
+	}
+	return resp
+}
+
+// EncodeCursor serialises a cursor into an opaque URL-safe token
+func EncodeCursor(cursor domain.WorkflowCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor
+func DecodeCursor(token string) (*domain.WorkflowCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor domain.WorkflowCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -3,10 +3,10 @@ package metrics
 import (
 	"context"
 	"database/sql"
+	"go-tempo/internal/domain"
 	"log"
+	"strconv"
 	"time"
-
-	"github.com/redis/go-redis/v9"
 )
 
 // StartDBPoolCollector starts a background goroutine that collects DB connection pool stats
@@ -23,21 +23,23 @@ func StartDBPoolCollector(sqlDB *sql.DB, interval time.Duration) {
 	}()
 }
 
-// StartRedisQueueDepthCollector starts a background goroutine that monitors Redis queue depth
-func StartRedisQueueDepthCollector(redisClient *redis.Client, queueKey string, interval time.Duration) {
+// StartQueueDepthCollector starts a background goroutine that reports the depth of a task queue
+// for every priority level, including empty ones
+func StartQueueDepthCollector(queueName string, depthByPriority func(ctx context.Context) (map[int]int64, error), interval time.Duration) {
 	go func() {
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 		ctx := context.Background()
 
 		for range ticker.C {
-			length, err := redisClient.LLen(ctx, queueKey).Result()
+			depth, err := depthByPriority(ctx)
 			if err != nil {
-				log.Printf("Failed to get queue depth: %v", err)
-				RedisConnectionErrorsTotal.WithLabelValues("llen").Inc()
+				log.Printf("Failed to get depth of queue %s: %v", queueName, err)
 				continue
 			}
-			RedisQueueDepth.Set(float64(length))
+			for priority := domain.MinPriority; priority <= domain.MaxPriority; priority++ {
+				QueueDepth.WithLabelValues(queueName, strconv.Itoa(priority)).Set(float64(depth[priority]))
+			}
 		}
 	}()
 }
@@ -54,6 +54,15 @@ var (
 		},
 		[]string{"workflow_type"},
 	)
+
+	// TenantQuotaRejectionsTotal tracks submissions refused because a tenant quota was reached
+	TenantQuotaRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tenant_quota_rejections_total",
+			Help: "Total number of workflow submissions refused because a tenant quota was reached",
+		},
+		[]string{"tenant", "quota"}, // quota: running_workflows, queued_tasks, submission_rate
+	)
 )
 
 // Worker Metrics
@@ -64,7 +73,7 @@ var (
 			Name: "worker_tasks_processed_total",
 			Help: "Total number of tasks processed by workers",
 		},
-		[]string{"action", "status"}, // status: success, failed, skipped
+		[]string{"action", "status"}, // status: success, failed, skipped, released
 	)
 
 	// WorkerTaskDuration tracks task execution duration
@@ -120,6 +129,51 @@ var (
 		},
 		[]string{"action"},
 	)
+
+	// WorkerResultCacheHitsTotal tracks tasks completed from the result cache instead of executing
+	WorkerResultCacheHitsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_result_cache_hits_total",
+			Help: "Total number of tasks completed from a cached result without re-execution",
+		},
+		[]string{"action"},
+	)
+
+	// WorkerTasksThrottledTotal tracks tasks requeued because an action limit was reached
+	WorkerTasksThrottledTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_tasks_throttled_total",
+			Help: "Total number of tasks requeued instead of executed because an action limit was reached",
+		},
+		[]string{"action", "limit"}, // limit: concurrency, rate
+	)
+
+	// WorkerLeasesExpiredTotal tracks tasks of external workers retried because no heartbeat came
+	WorkerLeasesExpiredTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_leases_expired_total",
+			Help: "Total number of tasks polled by external workers whose lease expired before they reported",
+		},
+		[]string{"action"},
+	)
+
+	// WorkerHandlerOutcomesTotal tracks handler runs by the kind of error they returned
+	WorkerHandlerOutcomesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_handler_outcomes_total",
+			Help: "Total number of task handler runs by outcome",
+		},
+		[]string{"action", "outcome"}, // outcome: success, retryable, non_retryable, skip, cancelled
+	)
+
+	// WorkerProgressReportsTotal tracks progress reported by handlers, and how much throttling absorbed
+	WorkerProgressReportsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_progress_reports_total",
+			Help: "Total number of progress reports by handlers, written to the task or coalesced into a later write",
+		},
+		[]string{"action", "result"}, // result: written, coalesced
+	)
 )
 
 // Coordinator Metrics
@@ -156,7 +210,15 @@ var (
 			Name: "coordinator_workflow_completions_total",
 			Help: "Total number of completed workflows",
 		},
-		[]string{"status"}, // status: completed, failed
+		[]string{"status"}, // status: completed, failed, cancelled
+	)
+
+	// CoordinatorLeader is 1 while this coordinator holds the leader lock and handles task events
+	CoordinatorLeader = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "coordinator_leader",
+			Help: "Whether this coordinator is the leader handling task events (1) or a standby (0)",
+		},
 	)
 
 	// CoordinatorSkipPropagationsTotal tracks skip hint propagations
@@ -237,14 +299,6 @@ var (
 		},
 	)
 
-	// RedisQueueDepth tracks current queue length
-	RedisQueueDepth = promauto.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "redis_queue_depth",
-			Help: "Current number of tasks in Redis queue",
-		},
-	)
-
 	// RedisPubSubMessagesPublishedTotal tracks published messages
 	RedisPubSubMessagesPublishedTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -273,6 +327,27 @@ var (
 	)
 )
 
+// Webhook Metrics
+var (
+	// WebhookDeliveriesTotal tracks webhook delivery attempts by outcome
+	WebhookDeliveriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_deliveries_total",
+			Help: "Total number of webhook delivery attempts",
+		},
+		[]string{"status"}, // status: delivered, retried, dead
+	)
+
+	// WebhookDeliveryDuration tracks how long receivers take to respond
+	WebhookDeliveryDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "webhook_delivery_duration_seconds",
+			Help:    "Duration of webhook delivery requests in seconds",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10}, // 10ms to 10s
+		},
+	)
+)
+
 // Business Metrics
 var (
 	// WorkflowsInProgress tracks active workflows
@@ -293,6 +368,15 @@ var (
 		[]string{"status"}, // status: pending, queued, running, completed, failed, skipped
 	)
 
+	// QueueDepth tracks the number of queued tasks per queue and priority
+	QueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "task_queue_depth",
+			Help: "Current number of tasks waiting in a task queue, by priority",
+		},
+		[]string{"queue", "priority"},
+	)
+
 	// WorkflowExecutionDuration tracks end-to-end workflow time
 	WorkflowExecutionDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
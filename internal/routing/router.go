@@ -0,0 +1,82 @@
+package routing
+
+import (
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"sort"
+	"sync"
+)
+
+// Router maps named task queues to their main and retry queues. The default task queue uses
+// the configured queue names; a named one appends ":<name>" to them, so "email" is served by
+// "workflow:queue:pending:email" and "workflow:queue:retry:email". Queues are opened on first use.
+type Router struct {
+	pending string
+	retry   string
+	routes  map[string]string // action → task queue
+	open    func(name string) ports.TaskQueue
+
+	mu     sync.Mutex
+	queues map[string]ports.TaskQueue // By queue name
+}
+
+func NewRouter(pending, retry string, routes map[string]string, open func(name string) ports.TaskQueue) *Router {
+	return &Router{
+		pending: pending,
+		retry:   retry,
+		routes:  routes,
+		open:    open,
+		queues:  make(map[string]ports.TaskQueue),
+	}
+}
+
+func (r *Router) Route(action string) string {
+	if taskQueue, ok := r.routes[action]; ok {
+		return taskQueue
+	}
+	return domain.DefaultTaskQueue
+}
+
+func (r *Router) Main(taskQueue string) ports.TaskQueue {
+	name, _ := r.QueueNames(taskQueue)
+	return r.queue(name)
+}
+
+func (r *Router) Retry(taskQueue string) ports.TaskQueue {
+	_, name := r.QueueNames(taskQueue)
+	return r.queue(name)
+}
+
+// QueueNames returns the main and retry queue names of a task queue
+func (r *Router) QueueNames(taskQueue string) (string, string) {
+	if taskQueue == "" || taskQueue == domain.DefaultTaskQueue {
+		return r.pending, r.retry
+	}
+	return r.pending + ":" + taskQueue, r.retry + ":" + taskQueue
+}
+
+// TaskQueues returns the default task queue and every routed one, sorted
+func (r *Router) TaskQueues() []string {
+	seen := map[string]bool{domain.DefaultTaskQueue: true}
+	taskQueues := []string{domain.DefaultTaskQueue}
+	for _, taskQueue := range r.routes {
+		if !seen[taskQueue] {
+			seen[taskQueue] = true
+			taskQueues = append(taskQueues, taskQueue)
+		}
+	}
+	sort.Strings(taskQueues)
+	return taskQueues
+}
+
+func (r *Router) queue(name string) ports.TaskQueue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q, ok := r.queues[name]
+	if !ok {
+		q = r.open(name)
+		r.queues[name] = q
+	}
+	return q
+}
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/metrics"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrLeaseLost is returned when a task is no longer leased with the given token, because it
+// was already reported or its lease expired and it was retried
+var ErrLeaseLost = errors.New("task is not leased with this token")
+
+// reapBatchSize bounds the expired leases handled per reaper tick
+const reapBatchSize = 100
+
+// LeasedTask is a task handed to an external worker until its lease expires
+type LeasedTask struct {
+	Task           *domain.Task
+	LeaseToken     string
+	LeaseExpiresAt time.Time
+}
+
+// TaskService serves tasks to external workers over the API. Tasks are claimed from the same
+// queues as in-process workers and their outcomes feed the coordinator the same way.
+type TaskService struct {
+	repo         ports.TaskRepository
+	workflowRepo ports.WorkflowRepository
+	queues       ports.TaskQueues
+	eventBus     ports.EventBus
+	leaseTTL     time.Duration
+}
+
+func NewTaskService(repo ports.TaskRepository, workflowRepo ports.WorkflowRepository, queues ports.TaskQueues, bus ports.EventBus, leaseTTL time.Duration) *TaskService {
+	return &TaskService{
+		repo:         repo,
+		workflowRepo: workflowRepo,
+		queues:       queues,
+		eventBus:     bus,
+		leaseTTL:     leaseTTL,
+	}
+}
+
+// popped is a task ID taken from one of the queues a poll waits on
+type popped struct {
+	taskID string
+	queue  ports.TaskQueue
+	stop   context.CancelFunc // Stops waiting on the queue
+}
+
+// PollTask waits up to wait for a task of one of the actions, claims it for workerID and leases
+// it. It waits on the given task queues, or on those the actions are routed to when none are
+// given. It returns nil when no task arrived in time.
+func (s *TaskService) PollTask(ctx context.Context, workerID string, actions, taskQueues []string, wait time.Duration) (*LeasedTask, error) {
+	supported := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		supported[action] = true
+		if len(taskQueues) == 0 {
+			taskQueues = append(taskQueues, s.queues.Route(action))
+		}
+	}
+	var sources []ports.TaskQueue
+	served := make(map[string]bool)
+	for _, taskQueue := range taskQueues {
+		if !served[taskQueue] {
+			served[taskQueue] = true
+			sources = append(sources, s.queues.Main(taskQueue), s.queues.Retry(taskQueue))
+		}
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	// Every queue is waited on at once; IDs popped after the poll is over go back to their queue
+	results := make(chan popped)
+	for _, q := range sources {
+		queueCtx, stop := context.WithCancel(pollCtx)
+		go s.waitOnQueue(queueCtx, q, stop, results)
+	}
+
+	for {
+		var p popped
+		select {
+		case p = <-results:
+		case <-pollCtx.Done():
+			return nil, nil
+		}
+
+		task, err := s.repo.FindTaskByID(ctx, uuid.MustParse(p.taskID))
+		if err != nil {
+			log.Printf("External worker %s failed to find task %s: %v", workerID, p.taskID, err)
+			continue
+		}
+
+		if task.SkipHint {
+			s.skipTask(ctx, task)
+			continue
+		}
+
+		// A task queue shared with actions this worker doesn't handle isn't waited on again
+		if !supported[task.Action] {
+			p.stop()
+			s.returnToQueue(context.WithoutCancel(ctx), p.queue, task)
+			continue
+		}
+
+		if err := s.repo.ClaimTask(ctx, task.ID, workerID, task.Version); err != nil {
+			log.Printf("External worker %s failed to claim task %s (already claimed by another worker): %v", workerID, task.RefID, err)
+			metrics.WorkerClaimFailuresTotal.Inc()
+			continue
+		}
+		task.Version++ // Version was incremented in DB by the claim
+		cancel()
+
+		// The task is claimed now, so a disconnecting worker must not abandon its bookkeeping
+		return s.leaseTask(context.WithoutCancel(ctx), p.queue, task, workerID)
+	}
+}
+
+// waitOnQueue pops task IDs from q and hands them to the poll until ctx is done
+func (s *TaskService) waitOnQueue(ctx context.Context, q ports.TaskQueue, stop context.CancelFunc, results chan<- popped) {
+	defer stop()
+	for {
+		taskID, err := q.Pop(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("External worker poll failed popping from queue: %v", err)
+			}
+			return
+		}
+		if _, err := uuid.Parse(taskID); err != nil {
+			log.Printf("External worker poll failed to parse task ID %s: %v", taskID, err)
+			continue
+		}
+
+		select {
+		case results <- popped{taskID: taskID, queue: q, stop: stop}:
+		case <-ctx.Done():
+			// Popped as the poll ended; the task was never claimed, so it is still QUEUED
+			bgCtx := context.WithoutCancel(ctx)
+			task, err := s.repo.FindTaskByID(bgCtx, uuid.MustParse(taskID))
+			if err != nil {
+				log.Printf("External worker poll failed to find task %s: %v", taskID, err)
+				return
+			}
+			s.returnToQueue(bgCtx, q, task)
+			return
+		}
+	}
+}
+
+// returnToQueue pushes a popped but unclaimed task back to the queue it came from
+func (s *TaskService) returnToQueue(ctx context.Context, q ports.TaskQueue, task *domain.Task) {
+	if err := q.Push(ctx, task); err != nil {
+		log.Printf("External worker poll failed to return task %s to its queue: %v", task.RefID, err)
+	}
+}
+
+// leaseTask issues the lease token of a claimed task and announces that it started. If the lease
+// can't be stored, the task is handed back to its queue.
+func (s *TaskService) leaseTask(ctx context.Context, q ports.TaskQueue, task *domain.Task, workerID string) (*LeasedTask, error) {
+	token, err := generateLeaseToken()
+	if err != nil {
+		s.releaseTask(ctx, q, task)
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.leaseTTL)
+	if err := s.repo.SetLease(ctx, task.ID, task.Version, hashLeaseToken(token), expiresAt); err != nil {
+		s.releaseTask(ctx, q, task)
+		return nil, err
+	}
+
+	metrics.WorkerQueueWaitTime.Observe(time.Since(task.CreatedAt).Seconds())
+	s.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskStarted, task, ""), workerID)
+	log.Printf("External worker %s leased task %s until %s", workerID, task.RefID, expiresAt.Format(time.RFC3339))
+
+	return &LeasedTask{Task: task, LeaseToken: token, LeaseExpiresAt: expiresAt}, nil
+}
+
+// releaseTask moves a claimed task back to QUEUED without counting the attempt
+func (s *TaskService) releaseTask(ctx context.Context, q ports.TaskQueue, task *domain.Task) {
+	if err := s.repo.ReleaseTask(ctx, task.ID, task.Version); err != nil {
+		log.Printf("External worker poll failed to release task %s: %v", task.RefID, err)
+		return
+	}
+	s.returnToQueue(ctx, q, task)
+}
+
+// Heartbeat extends the lease of a task and returns when it now expires
+func (s *TaskService) Heartbeat(ctx context.Context, taskID uuid.UUID, token string) (time.Time, error) {
+	expiresAt := time.Now().Add(s.leaseTTL)
+	if err := s.repo.RenewLease(ctx, taskID, hashLeaseToken(token), expiresAt); err != nil {
+		return time.Time{}, leaseError(err)
+	}
+	return expiresAt, nil
+}
+
+// CompleteTask records the output of a leased task and lets the coordinator unblock its children
+func (s *TaskService) CompleteTask(ctx context.Context, taskID uuid.UUID, token string, output []byte) error {
+	task, err := s.endLease(ctx, taskID, hashLeaseToken(token))
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.MarkCompleted(ctx, task.ID, output); err != nil {
+		return err
+	}
+	metrics.WorkerTasksProcessedTotal.WithLabelValues(task.Action, "success").Inc()
+
+	event := domain.TaskCompletedEvent{
+		ExecutionID: task.ExecutionID,
+		TaskID:      task.ID,
+		RefID:       task.RefID,
+	}
+	s.eventBus.PublishTaskCompleted(ctx, event)
+	s.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskCompleted, task, ""), workerIDOf(task))
+
+	log.Printf("External worker successfully finished %s", task.RefID)
+	return nil
+}
+
+// FailTask records a failed attempt of a leased task. It is retried unless its retries are
+// exhausted or the worker reported the error as not retryable.
+func (s *TaskService) FailTask(ctx context.Context, taskID uuid.UUID, token string, errMsg string, retryable bool) error {
+	task, err := s.endLease(ctx, taskID, hashLeaseToken(token))
+	if err != nil {
+		return err
+	}
+	s.handleFailure(ctx, task, errMsg, retryable)
+	return nil
+}
+
+// endLease ends the lease held with tokenHash and returns the task as it is now
+func (s *TaskService) endLease(ctx context.Context, taskID uuid.UUID, tokenHash string) (*domain.Task, error) {
+	if err := s.repo.EndLease(ctx, taskID, tokenHash); err != nil {
+		return nil, leaseError(err)
+	}
+	return s.repo.FindTaskByID(ctx, taskID)
+}
+
+// handleFailure retries the task through the retry queue of its task queue, or fails it for good
+func (s *TaskService) handleFailure(ctx context.Context, task *domain.Task, errMsg string, retryable bool) {
+	workerID := workerIDOf(task)
+	log.Printf("External worker task %s failed: %s", task.RefID, errMsg)
+	s.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskAttemptFailed, task, errMsg), workerID)
+
+	if retryable && task.CanRetry(task.MaxRetries) {
+		log.Printf("External worker retrying task %s (retry %d/%d)", task.RefID, task.RetryCount+1, task.MaxRetries)
+		metrics.WorkerRetriesTotal.WithLabelValues(task.Action, strconv.Itoa(task.RetryCount+1)).Inc()
+
+		if err := s.repo.IncrementRetryCount(ctx, task.ID, task.Version, nil); err != nil {
+			log.Printf("External worker failed to increment retry count for task %s: %v", task.RefID, err)
+			return
+		}
+		if err := s.queues.Retry(task.TaskQueue).Push(ctx, task); err != nil {
+			log.Printf("External worker failed to push task %s to retry queue: %v", task.RefID, err)
+			return
+		}
+		s.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskRetried, task, errMsg), workerID)
+		return
+	}
+
+	log.Printf("External worker task %s won't be retried, marking as failed", task.RefID)
+	s.repo.MarkFailed(ctx, task.ID, errMsg, nil)
+
+	if retryable {
+		metrics.TaskRetryExhaustionTotal.WithLabelValues(task.Action).Inc()
+	}
+	metrics.WorkerTasksProcessedTotal.WithLabelValues(task.Action, "failed").Inc()
+
+	s.workflowRepo.UpdateStatus(ctx, task.ExecutionID, string(domain.WorkflowFailed))
+
+	// Publish termination event to propagate skip hint to children
+	terminationEvent := domain.NewTaskTerminatedEvent(
+		task.ExecutionID,
+		task.ID,
+		task.RefID,
+		domain.TaskTerminationFailed,
+		errMsg,
+	)
+	s.eventBus.PublishTaskTerminated(ctx, terminationEvent)
+	s.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskFailed, task, errMsg), workerID)
+}
+
+// skipTask marks a task whose parent failed or whose workflow was cancelled as skipped, like
+// in-process workers do
+func (s *TaskService) skipTask(ctx context.Context, task *domain.Task) {
+	reason := domain.SkipReasonParentFailed
+	if execution, err := s.workflowRepo.GetByID(ctx, task.ExecutionID); err == nil {
+		reason = domain.SkipReason(execution.Status)
+	}
+	if err := s.repo.MarkSkipped(ctx, task.ID, reason); err != nil {
+		log.Printf("External worker poll failed to mark task %s as skipped: %v", task.RefID, err)
+		return
+	}
+
+	terminationEvent := domain.NewTaskTerminatedEvent(
+		task.ExecutionID,
+		task.ID,
+		task.RefID,
+		domain.TaskTerminationSkipped,
+		"skipped: "+reason,
+	)
+	s.eventBus.PublishTaskTerminated(ctx, terminationEvent)
+	s.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskSkipped, task, reason), "")
+
+	metrics.WorkerTasksProcessedTotal.WithLabelValues(task.Action, "skipped").Inc()
+}
+
+// Start retries the tasks of external workers that stopped heartbeating, every interval until
+// ctx is canceled. Each expired lease is ended before the retry, so concurrent API servers
+// retry a task only once.
+func (s *TaskService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reapExpiredLeases(ctx)
+		}
+	}
+}
+
+func (s *TaskService) reapExpiredLeases(ctx context.Context) {
+	tasks, err := s.repo.FindExpiredLeases(ctx, time.Now(), reapBatchSize)
+	if err != nil {
+		log.Printf("External worker reaper failed to find expired leases: %v", err)
+		return
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		if task.LeaseTokenHash == nil {
+			continue
+		}
+		expired, err := s.endLease(ctx, task.ID, *task.LeaseTokenHash)
+		if err != nil {
+			continue // Reported or reaped meanwhile
+		}
+
+		log.Printf("External worker lease of task %s expired", task.RefID)
+		metrics.WorkerLeasesExpiredTotal.WithLabelValues(task.Action).Inc()
+		s.handleFailure(ctx, expired, "lease expired", true)
+	}
+}
+
+// publishWorkflowEvent records a lifecycle event for event stream subscribers.
+// Failures are logged only; the event stream is not part of task state.
+func (s *TaskService) publishWorkflowEvent(ctx context.Context, event domain.WorkflowEvent, workerID string) {
+	event.WorkerID = workerID
+	if _, err := s.eventBus.PublishWorkflowEvent(ctx, event); err != nil {
+		log.Printf("External worker failed to publish %s event for task %s: %v", event.Type, event.RefID, err)
+	}
+}
+
+// leaseError maps a lease update that matched no task to ErrLeaseLost
+func leaseError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+func workerIDOf(task *domain.Task) string {
+	if task.WorkerID == nil {
+		return ""
+	}
+	return *task.WorkerID
+}
+
+// generateLeaseToken returns a random token; only its hash is stored
+func generateLeaseToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashLeaseToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
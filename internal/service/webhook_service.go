@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidEventType is returned when a subscription names an event type that is never delivered
+	ErrInvalidEventType = errors.New("invalid event type")
+
+	// ErrWebhookNotFound is returned when no subscription exists for the given ID
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+)
+
+type WebhookService interface {
+	// RegisterSubscription stores a subscription to every workflow of the request's tenant.
+	// An empty secret is generated.
+	RegisterSubscription(ctx context.Context, url string, events []string, secret string) (*domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+
+	ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repo ports.WebhookRepository
+}
+
+func NewWebhookService(repo ports.WebhookRepository) WebhookService {
+	return &webhookService{repo: repo}
+}
+
+func (s *webhookService) RegisterSubscription(ctx context.Context, url string, events []string, secret string) (*domain.WebhookSubscription, error) {
+	if err := ValidateEventTypes(events); err != nil {
+		return nil, err
+	}
+
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := domain.NewWebhookSubscription(url, eventsJSON, secret)
+	if tenant, ok := domain.TenantFromContext(ctx); ok {
+		sub.Tenant = tenant
+	}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.DeleteSubscription(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// ListDeliveries returns at most MaxPageSize deliveries, DefaultPageSize when no limit is given
+func (s *webhookService) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+	return s.repo.ListDeliveries(ctx, filter)
+}
+
+// ValidateEventTypes checks that every name is a lifecycle event type delivered to webhooks
+func ValidateEventTypes(events []string) error {
+	for _, event := range events {
+		if !domain.IsWebhookEventType(domain.WorkflowEventType(event)) {
+			return fmt.Errorf("%w: %q", ErrInvalidEventType, event)
+		}
+	}
+	return nil
+}
+
+// generateSecret returns a random 32-byte hex signing secret
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
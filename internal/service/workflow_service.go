@@ -2,57 +2,217 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-tempo/internal/core/ports"
 	"go-tempo/internal/domain"
+	"go-tempo/internal/metrics"
+	"log"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrWorkflowNotFound is returned when no workflow execution exists for the given ID
+	ErrWorkflowNotFound = errors.New("workflow not found")
+
+	// ErrWorkflowNotRunning is returned when cancelling a workflow that already finished
+	ErrWorkflowNotRunning = errors.New("workflow is not running")
+)
+
+// QuotaExceededError is returned when a submission would take its tenant past a quota
+type QuotaExceededError struct {
+	Tenant     string
+	Quota      string        // One of the domain.Quota* names
+	Limit      int
+	RetryAfter time.Duration // When the submission rate frees up; 0 for the other quotas
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("tenant %s exceeded its %s quota of %d", e.Tenant, e.Quota, e.Limit)
+}
+
 type WorkflowService interface {
+	// SubmitWorkflow persists and starts the workflow. If the tenant already submitted one with
+	// the execution's idempotency key, that workflow's ID is returned instead.
 	SubmitWorkflow(ctx context.Context, execution *domain.WorkflowExecution, tasks []domain.Task) (uuid.UUID, error)
+
+	// GetWorkflow returns the execution with its tasks
+	GetWorkflow(ctx context.Context, executionID uuid.UUID) (*domain.WorkflowExecution, error)
+
+	// CancelWorkflow stops a running workflow: tasks not yet started are skipped, running tasks
+	// finish, and the workflow ends CANCELLED once the last of them does
+	CancelWorkflow(ctx context.Context, executionID uuid.UUID) error
+
+	// WatchWorkflow replays the events recorded after lastEventID, then streams live events.
+	// The channel is closed after the final workflow status event or when ctx is cancelled.
+	WatchWorkflow(ctx context.Context, executionID uuid.UUID, lastEventID string) (<-chan domain.WorkflowEvent, error)
+
+	// GetWorkflowHistory returns every recorded transition of the execution, oldest first
+	GetWorkflowHistory(ctx context.Context, executionID uuid.UUID) ([]domain.WorkflowEventRecord, error)
+
+	// ListWorkflows returns one page of executions matching the filter, newest first
+	ListWorkflows(ctx context.Context, filter domain.WorkflowFilter) (*domain.WorkflowPage, error)
+
+	// CountWorkflowsByStatus aggregates executions matching the filter per status
+	CountWorkflowsByStatus(ctx context.Context, filter domain.WorkflowFilter) (map[domain.WorkflowStatus]int64, error)
 }
 
+// Page size bounds for ListWorkflows
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
 // The Implementation
 type workflowService struct {
-    repo  ports.TaskRepository
-    queue ports.TaskQueue
+    repo         ports.TaskRepository
+    workflowRepo ports.WorkflowRepository
+    queues       ports.TaskQueues
+    eventBus     ports.EventBus
+    historyRepo  ports.WorkflowEventRepository
+    quota        domain.TenantQuota
+    rateLimiter  ports.RateLimiter
 }
 
 // Constructor
-func NewWorkflowService(repo ports.TaskRepository, queue ports.TaskQueue) WorkflowService {
+func NewWorkflowService(repo ports.TaskRepository, workflowRepo ports.WorkflowRepository, queues ports.TaskQueues, bus ports.EventBus, historyRepo ports.WorkflowEventRepository, quota domain.TenantQuota, rateLimiter ports.RateLimiter) WorkflowService {
     return &workflowService{
-        repo:  repo,
-        queue: queue,
+        repo:         repo,
+        workflowRepo: workflowRepo,
+        queues:       queues,
+        eventBus:     bus,
+        historyRepo:  historyRepo,
+        quota:        quota,
+        rateLimiter:  rateLimiter,
     }
 }
 
 func (s *workflowService) SubmitWorkflow(ctx context.Context, execution *domain.WorkflowExecution, tasks []domain.Task) (uuid.UUID, error) {
     
+    // The workflow and its tasks belong to the tenant the request is scoped to
+    tenant, ok := domain.TenantFromContext(ctx)
+    if !ok {
+        tenant = domain.DefaultTenant
+        ctx = domain.WithTenant(ctx, tenant)
+    }
+
+    // Resubmitting with the idempotency key of an earlier submission returns that execution
+    existing, err := s.findIdempotent(ctx, execution)
+    if err != nil {
+        return uuid.Nil, err
+    }
+    if existing != nil {
+        return existing.ID, nil
+    }
+
+    if err := s.checkQuota(ctx, tenant, len(s.getRootTasks(tasks))); err != nil {
+        return uuid.Nil, err
+    }
+    execution.Tenant = tenant
+
+    // Tasks that don't name a task queue go to the one routed for their action
+    for i := range tasks {
+        tasks[i].Tenant = tenant
+        if tasks[i].TaskQueue == "" {
+            tasks[i].TaskQueue = s.queues.Route(tasks[i].Action)
+        }
+    }
+
     // Persist workflow and tasks atomically
     if err := s.persistWorkflow(ctx, execution, tasks); err != nil {
+        // A concurrent submission with the same idempotency key won the insert
+        if existing, findErr := s.findIdempotent(ctx, execution); existing != nil && findErr == nil {
+            return existing.ID, nil
+        }
         return uuid.Nil, err
     }
     
     // Identify root tasks for enqueueing
     rootTasks := s.getRootTasks(tasks)
     
-    // Enqueue root tasks for immediate processing
+    // Enqueue root tasks for immediate processing. A workflow whose roots aren't all queued would
+    // never run, and a retry with its idempotency key would return it, so it is deleted instead
     if err := s.enqueueRootTasks(ctx, rootTasks); err != nil {
+        if deleteErr := s.repo.DeleteExecution(ctx, execution.ID); deleteErr != nil {
+            log.Printf("Failed to delete workflow %s after queueing its root tasks failed: %v", execution.ID, deleteErr)
+        }
         return uuid.Nil, err
     }
     
+    s.publishWorkflowEvent(ctx, domain.NewWorkflowSubmittedEvent(execution))
+    for i := range rootTasks {
+        s.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskQueued, &rootTasks[i], ""))
+    }
+    
     return execution.ID, nil
 }
 
+// findIdempotent returns the execution submitted earlier with the idempotency key of execution,
+// or nil if there is none or execution has no key
+func (s *workflowService) findIdempotent(ctx context.Context, execution *domain.WorkflowExecution) (*domain.WorkflowExecution, error) {
+    if execution.IdempotencyKey == nil {
+        return nil, nil
+    }
+    existing, err := s.workflowRepo.GetByIdempotencyKey(ctx, *execution.IdempotencyKey)
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, nil
+    }
+    return existing, err
+}
+
+// checkQuota refuses a submission queueing newTasks root tasks that would take the tenant past a quota.
+// Counts are read before the insert, so concurrent submissions can overshoot slightly.
+func (s *workflowService) checkQuota(ctx context.Context, tenant string, newTasks int) error {
+    exceeded := func(quota string, limit int, retryAfter time.Duration) error {
+        metrics.TenantQuotaRejectionsTotal.WithLabelValues(tenant, quota).Inc()
+        return &QuotaExceededError{Tenant: tenant, Quota: quota, Limit: limit, RetryAfter: retryAfter}
+    }
+
+    if s.quota.SubmissionsPerSecond > 0 {
+        allowed, retryAfter, err := s.rateLimiter.Allow(ctx, "submissions:"+tenant, s.quota.SubmissionsPerSecond, time.Second)
+        if err != nil {
+            return err
+        }
+        if !allowed {
+            return exceeded(domain.QuotaSubmissionRate, s.quota.SubmissionsPerSecond, retryAfter)
+        }
+    }
+
+    if s.quota.MaxRunningWorkflows > 0 {
+        counts, err := s.workflowRepo.CountByStatus(ctx, domain.WorkflowFilter{})
+        if err != nil {
+            return err
+        }
+        if counts[domain.WorkflowRunning] >= int64(s.quota.MaxRunningWorkflows) {
+            return exceeded(domain.QuotaRunningWorkflows, s.quota.MaxRunningWorkflows, 0)
+        }
+    }
+
+    if s.quota.MaxQueuedTasks > 0 {
+        // Tasks blocked on their parents aren't queued yet, so only waiting tasks count
+        queued, err := s.repo.CountWaitingTasks(ctx)
+        if err != nil {
+            return err
+        }
+        if queued+int64(newTasks) > int64(s.quota.MaxQueuedTasks) {
+            return exceeded(domain.QuotaQueuedTasks, s.quota.MaxQueuedTasks, 0)
+        }
+    }
+    return nil
+}
+
 // persistWorkflow saves the workflow and its tasks atomically to the database
 func (s *workflowService) persistWorkflow(ctx context.Context, execution *domain.WorkflowExecution, tasks []domain.Task) error {
     return s.repo.CreateExecution(ctx, execution, tasks)
 }
 
-// enqueueRootTasks pushes root tasks to the Redis queue for immediate processing
+// enqueueRootTasks pushes root tasks to their task queues for immediate processing
 func (s *workflowService) enqueueRootTasks(ctx context.Context, rootTasks []domain.Task) error {
     for _, task := range rootTasks {
-        if err := s.queue.Push(ctx, task.ID.String()); err != nil {
+        if err := s.queues.Main(task.TaskQueue).Push(ctx, &task); err != nil {
             return err
         }
     }
@@ -70,4 +230,164 @@ func (s *workflowService) getRootTasks(tasks []domain.Task) []domain.Task {
     }
     
     return rootTasks
-}
\ No newline at end of file
+}
+
+// publishWorkflowEvent records a lifecycle event; failures don't fail the submission
+func (s *workflowService) publishWorkflowEvent(ctx context.Context, event domain.WorkflowEvent) {
+    if _, err := s.eventBus.PublishWorkflowEvent(ctx, event); err != nil {
+        log.Printf("Failed to publish %s event for workflow %s: %v", event.Type, event.ExecutionID, err)
+    }
+}
+
+func (s *workflowService) WatchWorkflow(ctx context.Context, executionID uuid.UUID, lastEventID string) (<-chan domain.WorkflowEvent, error) {
+    if _, err := s.getWorkflow(ctx, executionID); err != nil {
+        return nil, err
+    }
+
+    // Subscribe before replaying history so no event falls between the two
+    watchCtx, cancel := context.WithCancel(ctx)
+    live, err := s.eventBus.SubscribeToWorkflowEvents(watchCtx, executionID)
+    if err != nil {
+        cancel()
+        return nil, err
+    }
+
+    history, err := s.eventBus.GetWorkflowEventsSince(ctx, executionID, lastEventID)
+    if err != nil {
+        cancel()
+        return nil, err
+    }
+
+    out := make(chan domain.WorkflowEvent)
+    go func() {
+        defer cancel()
+        defer close(out)
+
+        send := func(event domain.WorkflowEvent) bool {
+            select {
+            case out <- event:
+                return true
+            case <-watchCtx.Done():
+                return false
+            }
+        }
+
+        replayed := make(map[string]bool, len(history))
+        for _, event := range history {
+            replayed[event.ID] = true
+            if !send(event) || event.IsFinal() {
+                return
+            }
+        }
+
+        // The history may have expired for a finished workflow; report the stored status instead
+        if status, done := s.finalStatus(watchCtx, executionID); done {
+            send(domain.NewWorkflowStatusEvent(executionID, status))
+            return
+        }
+
+        for event := range live {
+            if replayed[event.ID] {
+                continue
+            }
+            if !send(event) || event.IsFinal() {
+                return
+            }
+        }
+    }()
+
+    return out, nil
+}
+
+// getWorkflow loads the execution, mapping a missing row to ErrWorkflowNotFound
+func (s *workflowService) getWorkflow(ctx context.Context, executionID uuid.UUID) (*domain.WorkflowExecution, error) {
+    execution, err := s.workflowRepo.GetByID(ctx, executionID)
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, ErrWorkflowNotFound
+    }
+    return execution, err
+}
+
+// finalStatus reports the workflow's status once no further events can follow.
+// A FAILED or CANCELLED workflow is only done after its remaining tasks have been skipped.
+func (s *workflowService) finalStatus(ctx context.Context, executionID uuid.UUID) (domain.WorkflowStatus, bool) {
+    execution, err := s.getWorkflow(ctx, executionID)
+    if err != nil || !execution.IsFinished() {
+        return "", false
+    }
+    if execution.Status == domain.WorkflowFailed || execution.Status == domain.WorkflowCancelled {
+        allTerminal, err := s.repo.AreAllTasksTerminal(ctx, executionID)
+        if err != nil || !allTerminal {
+            return "", false
+        }
+    }
+    return execution.Status, true
+}
+
+func (s *workflowService) GetWorkflow(ctx context.Context, executionID uuid.UUID) (*domain.WorkflowExecution, error) {
+    execution, err := s.getWorkflow(ctx, executionID)
+    if err != nil {
+        return nil, err
+    }
+
+    execution.Tasks, err = s.repo.FindTasksByExecution(ctx, executionID)
+    if err != nil {
+        return nil, err
+    }
+    return execution, nil
+}
+
+func (s *workflowService) CancelWorkflow(ctx context.Context, executionID uuid.UUID) error {
+    if _, err := s.getWorkflow(ctx, executionID); err != nil {
+        return err
+    }
+
+    // Queued tasks are skipped when popped, so the coordinator announces the final status
+    // once the tasks still running finish
+    err := s.repo.CancelExecution(ctx, executionID)
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return ErrWorkflowNotRunning
+    }
+    if err != nil {
+        return err
+    }
+
+    log.Printf("Workflow %s cancelled", executionID)
+    return nil
+}
+
+func (s *workflowService) GetWorkflowHistory(ctx context.Context, executionID uuid.UUID) ([]domain.WorkflowEventRecord, error) {
+    if _, err := s.getWorkflow(ctx, executionID); err != nil {
+        return nil, err
+    }
+    return s.historyRepo.ListByExecution(ctx, executionID)
+}
+
+func (s *workflowService) ListWorkflows(ctx context.Context, filter domain.WorkflowFilter) (*domain.WorkflowPage, error) {
+    if filter.Limit <= 0 {
+        filter.Limit = DefaultPageSize
+    }
+    if filter.Limit > MaxPageSize {
+        filter.Limit = MaxPageSize
+    }
+    pageSize := filter.Limit
+
+    // Fetch one extra row to learn whether another page follows
+    filter.Limit = pageSize + 1
+    executions, err := s.workflowRepo.List(ctx, filter)
+    if err != nil {
+        return nil, err
+    }
+
+    page := &domain.WorkflowPage{Workflows: executions}
+    if len(executions) > pageSize {
+        page.Workflows = executions[:pageSize]
+        last := page.Workflows[pageSize-1]
+        page.NextCursor = &domain.WorkflowCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+    }
+    return page, nil
+}
+
+func (s *workflowService) CountWorkflowsByStatus(ctx context.Context, filter domain.WorkflowFilter) (map[domain.WorkflowStatus]int64, error) {
+    return s.workflowRepo.CountByStatus(ctx, filter)
+}
@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/metrics"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Dispatcher records webhook deliveries for lifecycle events and sends them in the background.
+// Deliveries are persisted before sending, so retries survive restarts.
+type Dispatcher struct {
+	repo         ports.WebhookRepository
+	workflowRepo ports.WorkflowRepository
+	client       *http.Client
+	secret       string // Signs per-workflow callbacks, which have no subscription secret
+
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+	batchSize    int
+	lease        time.Duration
+}
+
+// Options tunes delivery retries and polling
+type Options struct {
+	Secret         string // Signs per-workflow callbacks
+	MaxAttempts    int    // Attempts before a delivery is dead-lettered
+	BaseBackoff    time.Duration
+	MaxBackoff     time.Duration
+	PollInterval   time.Duration
+	RequestTimeout time.Duration
+}
+
+func NewDispatcher(repo ports.WebhookRepository, workflowRepo ports.WorkflowRepository, opts Options) *Dispatcher {
+	return &Dispatcher{
+		repo:         repo,
+		workflowRepo: workflowRepo,
+		client:       &http.Client{Timeout: opts.RequestTimeout},
+		secret:       opts.Secret,
+		maxAttempts:  opts.MaxAttempts,
+		baseBackoff:  opts.BaseBackoff,
+		maxBackoff:   opts.MaxBackoff,
+		pollInterval: opts.PollInterval,
+		batchSize:    50,
+		lease:        time.Minute,
+	}
+}
+
+// Notify records a delivery for every receiver interested in the event. Only subscriptions of
+// the workflow's tenant receive it.
+func (d *Dispatcher) Notify(ctx context.Context, event domain.WorkflowEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+
+	// 1. The workflow's own callback
+	execution, err := d.workflowRepo.GetByID(ctx, event.ExecutionID)
+	if err != nil {
+		return err
+	}
+	if execution.CallbackURL != "" && wantsEvent(execution.CallbackEvents, domain.DefaultCallbackEvents, event.Type) {
+		deliveries = append(deliveries, *domain.NewWebhookDelivery(event, execution.CallbackURL, nil, payload))
+	}
+
+	// 2. Subscriptions of the workflow's tenant
+	subs, err := d.repo.ListSubscriptions(domain.WithTenant(ctx, execution.Tenant))
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if wantsEvent(sub.Events, nil, event.Type) {
+			subID := sub.ID
+			deliveries = append(deliveries, *domain.NewWebhookDelivery(event, sub.URL, &subID, payload))
+		}
+	}
+	for i := range deliveries {
+		deliveries[i].Tenant = execution.Tenant
+	}
+
+	return d.repo.CreateDeliveries(ctx, deliveries)
+}
+
+// wantsEvent checks the event type against a JSON array of types.
+// An empty list falls back to defaults; nil defaults match every type.
+func wantsEvent(filter datatypes.JSON, defaults []domain.WorkflowEventType, eventType domain.WorkflowEventType) bool {
+	var types []domain.WorkflowEventType
+	if len(filter) > 0 {
+		if err := json.Unmarshal(filter, &types); err != nil {
+			return false
+		}
+	}
+	if len(types) == 0 {
+		if defaults == nil {
+			return true
+		}
+		types = defaults
+	}
+	for _, t := range types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Start polls for due deliveries until ctx is cancelled. Call this in main.go as a goroutine.
+func (d *Dispatcher) Start(ctx context.Context) {
+	log.Println("Webhook dispatcher started")
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook dispatcher shutting down...")
+			return
+		case <-ticker.C:
+			d.dispatchDue(ctx)
+		}
+	}
+}
+
+// dispatchDue sends one batch of due deliveries
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	deliveries, err := d.repo.ClaimDueDeliveries(ctx, d.batchSize, d.lease)
+	if err != nil {
+		log.Printf("Webhook dispatcher failed to claim deliveries: %v", err)
+		return
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	subs, err := d.repo.ListSubscriptions(ctx)
+	if err != nil {
+		log.Printf("Webhook dispatcher failed to load subscriptions: %v", err)
+		return
+	}
+	secrets := make(map[uuid.UUID]string, len(subs))
+	for _, sub := range subs {
+		secrets[sub.ID] = sub.Secret
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		secret := d.secret
+		if delivery.SubscriptionID != nil {
+			subSecret, ok := secrets[*delivery.SubscriptionID]
+			if !ok {
+				d.deadLetter(ctx, delivery, "subscription deleted")
+				continue
+			}
+			secret = subSecret
+		}
+
+		d.deliver(ctx, delivery, secret)
+	}
+}
+
+// deliver sends one signed request and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery *domain.WebhookDelivery, secret string) {
+	start := time.Now()
+	statusCode, err := d.send(ctx, delivery, secret)
+	metrics.WebhookDeliveryDuration.Observe(time.Since(start).Seconds())
+
+	delivery.Attempts++
+	delivery.ResponseCode = statusCode
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = domain.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		metrics.WebhookDeliveriesTotal.WithLabelValues("delivered").Inc()
+		d.update(ctx, delivery)
+		return
+	}
+
+	if delivery.Attempts >= d.maxAttempts {
+		d.deadLetter(ctx, delivery, err.Error())
+		return
+	}
+
+	log.Printf("Webhook delivery %s to %s failed (attempt %d/%d): %v",
+		delivery.ID, delivery.URL, delivery.Attempts, d.maxAttempts, err)
+	delivery.LastError = err.Error()
+	delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	metrics.WebhookDeliveriesTotal.WithLabelValues("retried").Inc()
+	d.update(ctx, delivery)
+}
+
+// send POSTs the payload and treats any non-2xx response as a failure
+func (d *Dispatcher) send(ctx context.Context, delivery *domain.WebhookDelivery, secret string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, delivery.Payload))
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// deadLetter stops retrying a delivery; it stays in the log with status DEAD
+func (d *Dispatcher) deadLetter(ctx context.Context, delivery *domain.WebhookDelivery, reason string) {
+	log.Printf("Webhook delivery %s to %s dead-lettered: %s", delivery.ID, delivery.URL, reason)
+	delivery.Status = domain.DeliveryDead
+	delivery.LastError = reason
+	metrics.WebhookDeliveriesTotal.WithLabelValues("dead").Inc()
+	d.update(ctx, delivery)
+}
+
+func (d *Dispatcher) update(ctx context.Context, delivery *domain.WebhookDelivery) {
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("Webhook dispatcher failed to update delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// backoff doubles the delay after every failed attempt, up to maxBackoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.baseBackoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.maxBackoff {
+		delay = d.maxBackoff
+	}
+	return delay
+}
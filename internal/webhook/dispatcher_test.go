@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-tempo/internal/core/ports"
+	"go-tempo/internal/domain"
+	"go-tempo/internal/infrastructure/memory"
+
+	"github.com/google/uuid"
+)
+
+// onlyDelivery returns the single recorded delivery
+func onlyDelivery(t *testing.T, repo ports.WebhookRepository) domain.WebhookDelivery {
+	t.Helper()
+	deliveries, err := repo.ListDeliveries(context.Background(), domain.WebhookDeliveryFilter{})
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	return deliveries[0]
+}
+
+const testSecret = "callback-secret"
+
+// newTestDispatcher returns a dispatcher and a workflow whose callback_url is receiverURL
+func newTestDispatcher(t *testing.T, receiverURL string, maxAttempts int) (*Dispatcher, ports.WebhookRepository, *domain.WorkflowExecution) {
+	t.Helper()
+	store := memory.NewStore()
+	repo := memory.NewWebhookRepository(store)
+	workflowRepo := memory.NewWorkflowRepository(store)
+
+	execution := domain.NewWorkflow(uuid.New(), "onboarding")
+	execution.CallbackURL = receiverURL
+	if err := workflowRepo.Create(context.Background(), execution); err != nil {
+		t.Fatalf("creating workflow: %v", err)
+	}
+
+	d := NewDispatcher(repo, workflowRepo, Options{
+		Secret:         testSecret,
+		MaxAttempts:    maxAttempts,
+		BaseBackoff:    10 * time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		PollInterval:   10 * time.Millisecond,
+		RequestTimeout: time.Second,
+	})
+	return d, repo, execution
+}
+
+// notifyCompleted records a workflow.completed delivery for the execution
+func notifyCompleted(t *testing.T, d *Dispatcher, execution *domain.WorkflowExecution) {
+	t.Helper()
+	event := domain.NewWorkflowStatusEvent(execution.ID, domain.WorkflowCompleted)
+	if err := d.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+}
+
+// dispatchUntil runs dispatch rounds until done reports true or a second has passed
+func dispatchUntil(t *testing.T, d *Dispatcher, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for deliveries")
+		}
+		d.dispatchDue(context.Background())
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDeliverySignature(t *testing.T) {
+	var verified, eventHeader atomic.Value
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		verified.Store(Verify(testSecret, timestamp, body, r.Header.Get(HeaderSignature)) &&
+			!Verify("other-secret", timestamp, body, r.Header.Get(HeaderSignature)))
+		eventHeader.Store(r.Header.Get(HeaderEvent))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	d, repo, execution := newTestDispatcher(t, receiver.URL, 3)
+	notifyCompleted(t, d, execution)
+	d.dispatchDue(context.Background())
+
+	if ok, _ := verified.Load().(bool); !ok {
+		t.Fatal("receiver could not verify the signature with the callback secret")
+	}
+	if got := eventHeader.Load(); got != string(domain.EventWorkflowCompleted) {
+		t.Errorf("%s = %v, want %s", HeaderEvent, got, domain.EventWorkflowCompleted)
+	}
+
+	delivery := onlyDelivery(t, repo)
+	if delivery.Status != domain.DeliveryDelivered || delivery.Attempts != 1 || delivery.ResponseCode != http.StatusNoContent {
+		t.Errorf("delivery = %s after %d attempts with %d, want DELIVERED after 1 with 204",
+			delivery.Status, delivery.Attempts, delivery.ResponseCode)
+	}
+}
+
+func TestSubscriptionDeliverySignedWithItsSecret(t *testing.T) {
+	var verified atomic.Bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		verified.Store(Verify("subscription-secret", timestamp, body, r.Header.Get(HeaderSignature)))
+	}))
+	defer receiver.Close()
+
+	d, repo, execution := newTestDispatcher(t, "", 3)
+	sub := domain.NewWebhookSubscription(receiver.URL, nil, "subscription-secret")
+	repo.CreateSubscription(context.Background(), sub)
+
+	notifyCompleted(t, d, execution)
+	d.dispatchDue(context.Background())
+
+	if !verified.Load() {
+		t.Fatal("receiver could not verify the signature with the subscription secret")
+	}
+	if delivery := onlyDelivery(t, repo); delivery.Status != domain.DeliveryDelivered {
+		t.Errorf("delivery status = %s, want DELIVERED", delivery.Status)
+	}
+}
+
+func TestDeliveryRetriedAfterFailure(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	d, repo, execution := newTestDispatcher(t, receiver.URL, 5)
+	notifyCompleted(t, d, execution)
+
+	d.dispatchDue(context.Background())
+	delivery := onlyDelivery(t, repo)
+	if delivery.Status != domain.DeliveryPending || delivery.Attempts != 1 || delivery.LastError == "" {
+		t.Fatalf("after a 503 delivery = %s after %d attempts (%q), want PENDING after 1 with an error",
+			delivery.Status, delivery.Attempts, delivery.LastError)
+	}
+	if !delivery.NextAttemptAt.After(time.Now()) {
+		t.Error("retry isn't backed off")
+	}
+
+	dispatchUntil(t, d, func() bool { return onlyDelivery(t, repo).Status != domain.DeliveryPending })
+
+	delivery = onlyDelivery(t, repo)
+	if delivery.Status != domain.DeliveryDelivered || delivery.Attempts != 3 || delivery.LastError != "" {
+		t.Errorf("delivery = %s after %d attempts (%q), want DELIVERED after 3",
+			delivery.Status, delivery.Attempts, delivery.LastError)
+	}
+}
+
+func TestDeliveryDeadLettered(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	d, repo, execution := newTestDispatcher(t, receiver.URL, 3)
+	notifyCompleted(t, d, execution)
+
+	dispatchUntil(t, d, func() bool { return onlyDelivery(t, repo).Status != domain.DeliveryPending })
+
+	delivery := onlyDelivery(t, repo)
+	if delivery.Status != domain.DeliveryDead || delivery.Attempts != 3 || delivery.ResponseCode != http.StatusInternalServerError {
+		t.Errorf("delivery = %s after %d attempts with %d, want DEAD after 3 with 500",
+			delivery.Status, delivery.Attempts, delivery.ResponseCode)
+	}
+
+	// Dead deliveries are never sent again
+	time.Sleep(20 * time.Millisecond)
+	d.dispatchDue(context.Background())
+	if got := requests.Load(); got != 3 {
+		t.Errorf("receiver got %d requests, want 3", got)
+	}
+}
+
+func TestDeliveryOfDeletedSubscriptionDeadLettered(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("deleted subscription received a request")
+	}))
+	defer receiver.Close()
+
+	d, repo, execution := newTestDispatcher(t, "", 3)
+	sub := domain.NewWebhookSubscription(receiver.URL, nil, "subscription-secret")
+	repo.CreateSubscription(context.Background(), sub)
+	notifyCompleted(t, d, execution)
+	repo.DeleteSubscription(context.Background(), sub.ID)
+
+	d.dispatchDue(context.Background())
+
+	if delivery := onlyDelivery(t, repo); delivery.Status != domain.DeliveryDead {
+		t.Errorf("delivery status = %s, want DEAD", delivery.Status)
+	}
+}
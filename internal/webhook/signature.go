@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers set on every webhook request
+const (
+	HeaderSignature = "X-Tempo-Signature"
+	HeaderTimestamp = "X-Tempo-Timestamp"
+	HeaderEvent     = "X-Tempo-Event"
+	HeaderDelivery  = "X-Tempo-Delivery"
+)
+
+// Sign computes the signature header value for a request body.
+// The timestamp is part of the signed message so receivers can reject replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches the body, for use by receivers
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
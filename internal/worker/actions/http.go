@@ -0,0 +1,288 @@
+// Package actions holds the actions built into the worker, configured by task input instead of code
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	workersdk "go-tempo/pkg/worker"
+)
+
+// HTTPAction is the name of the built-in HTTP request action
+const HTTPAction = "http"
+
+// HTTPOptions bounds the requests of the http action
+type HTTPOptions struct {
+	DefaultTimeout   time.Duration // Timeout of requests whose input sets none
+	MaxTimeout       time.Duration // Longest timeout an input may set
+	MaxResponseBytes int64         // Larger responses fail the task
+}
+
+// HTTPRequest is the input of the http action. URL and header values are Go templates executed
+// with .Vars and .Task, the ExecutionInfo of the task, e.g. "https://hr/users/{{.Vars.user_id}}".
+type HTTPRequest struct {
+	Method         string            `json:"method"` // Defaults to GET
+	URL            string            `json:"url" validate:"required"`
+	Vars           map[string]any    `json:"vars"`
+	Headers        map[string]string `json:"headers"`
+	Body           json.RawMessage   `json:"body"`            // Sent as JSON, or as is if it's a JSON string
+	ExpectedStatus []int             `json:"expected_status"` // Defaults to any 2xx
+	Extract        map[string]string `json:"extract"`         // Output field => dot path into the response, e.g. "data.items.0.id"
+	Timeout        string            `json:"timeout"`         // Go duration, e.g. "10s"
+}
+
+// HTTPResponse is the output of the http action. Body is left out when fields are extracted.
+type HTTPResponse struct {
+	StatusCode int                        `json:"status_code"`
+	Body       json.RawMessage            `json:"body,omitempty"` // The JSON response, or the response text as a JSON string
+	Extracted  map[string]json.RawMessage `json:"extracted,omitempty"`
+}
+
+func (r HTTPRequest) Validate() error {
+	switch strings.ToUpper(r.Method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return fmt.Errorf("unsupported method %q", r.Method)
+	}
+	for _, status := range r.ExpectedStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("expected_status %d is not an HTTP status", status)
+		}
+	}
+	if r.Timeout != "" {
+		if timeout, err := time.ParseDuration(r.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("timeout %q is not a positive duration", r.Timeout)
+		}
+	}
+	return nil
+}
+
+// RegisterHTTP registers the http action, which sends the request described by its input.
+// 5xx responses and connection errors are retried, as is 429 after its Retry-After; any other
+// unexpected status fails the task.
+func RegisterHTTP(r *workersdk.Registry, opts HTTPOptions) {
+	client := &http.Client{}
+	workersdk.Register(r, HTTPAction, func(ctx context.Context, in HTTPRequest) (HTTPResponse, error) {
+		return doHTTP(ctx, client, opts, in)
+	})
+}
+
+func doHTTP(ctx context.Context, client *http.Client, opts HTTPOptions, in HTTPRequest) (HTTPResponse, error) {
+	timeout := opts.DefaultTimeout
+	if in.Timeout != "" {
+		timeout, _ = time.ParseDuration(in.Timeout)
+	}
+	if timeout > opts.MaxTimeout {
+		return HTTPResponse{}, workersdk.NonRetryable(fmt.Errorf("timeout %s exceeds the maximum of %s", timeout, opts.MaxTimeout))
+	}
+
+	req, err := newHTTPRequest(ctx, in)
+	if err != nil {
+		return HTTPResponse{}, workersdk.NonRetryable(err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := client.Do(req.WithContext(reqCtx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return HTTPResponse{}, ctx.Err()
+		}
+		return HTTPResponse{}, workersdk.Retryable(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, opts.MaxResponseBytes+1))
+	if err != nil {
+		return HTTPResponse{}, workersdk.Retryable(fmt.Errorf("reading response: %w", err))
+	}
+	if int64(len(body)) > opts.MaxResponseBytes {
+		return HTTPResponse{}, workersdk.NonRetryable(fmt.Errorf("response exceeds %d bytes", opts.MaxResponseBytes))
+	}
+
+	if !expectedStatus(in.ExpectedStatus, resp.StatusCode) {
+		return HTTPResponse{}, statusError(resp, body)
+	}
+
+	out := HTTPResponse{StatusCode: resp.StatusCode}
+	if len(in.Extract) == 0 {
+		out.Body = jsonBody(body)
+		return out, nil
+	}
+
+	out.Extracted, err = extract(body, in.Extract)
+	if err != nil {
+		return HTTPResponse{}, workersdk.NonRetryable(err)
+	}
+	return out, nil
+}
+
+// newHTTPRequest builds the request from the input, executing its templates. Non-GET requests
+// carry the task's idempotency key unless the input sets the header.
+func newHTTPRequest(ctx context.Context, in HTTPRequest) (*http.Request, error) {
+	info, _ := workersdk.ExecutionInfoFromContext(ctx)
+	data := struct {
+		Vars map[string]any
+		Task workersdk.ExecutionInfo
+	}{in.Vars, info}
+
+	rawURL, err := render("url", in.URL, data)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url %q is not an absolute http(s) URL", rawURL)
+	}
+
+	var body io.Reader
+	contentType := ""
+	if len(in.Body) > 0 && string(in.Body) != "null" {
+		var text string
+		if json.Unmarshal(in.Body, &text) == nil {
+			body = strings.NewReader(text)
+		} else {
+			body = bytes.NewReader(in.Body)
+			contentType = "application/json"
+		}
+	}
+
+	method := strings.ToUpper(in.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if method != http.MethodGet && method != http.MethodHead && info.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", info.IdempotencyKey)
+	}
+	for name, value := range in.Headers {
+		value, err := render("header "+name, value, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+func render(name, text string, data any) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s template: %w", name, err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func expectedStatus(expected []int, status int) bool {
+	if len(expected) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, s := range expected {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError classifies an unexpected response: 5xx is retried, 429 after its Retry-After,
+// anything else fails the task
+func statusError(resp *http.Response, body []byte) error {
+	const maxSnippet = 512
+	snippet := strings.TrimSpace(string(body))
+	if len(snippet) > maxSnippet {
+		snippet = snippet[:maxSnippet] + "..."
+	}
+	err := fmt.Errorf("unexpected status %d: %s", resp.StatusCode, snippet)
+
+	switch {
+	case resp.StatusCode >= 500:
+		return workersdk.Retryable(err)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return workersdk.RetryAfter(err, time.Duration(seconds)*time.Second)
+	default:
+		return workersdk.NonRetryable(err)
+	}
+}
+
+// jsonBody returns a JSON response as is, and any other response as a JSON string
+func jsonBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	text, _ := json.Marshal(string(body))
+	return text
+}
+
+// extract picks the values at the given dot paths of a JSON response. Numeric path segments
+// index arrays.
+func extract(body []byte, paths map[string]string) (map[string]json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("extracting from a response that isn't JSON: %w", err)
+	}
+
+	extracted := make(map[string]json.RawMessage, len(paths))
+	for field, path := range paths {
+		value, err := lookup(doc, path)
+		if err != nil {
+			return nil, fmt.Errorf("extract %s: %w", field, err)
+		}
+		extracted[field], _ = json.Marshal(value)
+	}
+	return extracted, nil
+}
+
+var errNoValue = errors.New("no value at path")
+
+func lookup(doc any, path string) (any, error) {
+	value := doc
+	if path == "" || path == "." {
+		return value, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			v, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("%w %q", errNoValue, path)
+			}
+			value = v
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("%w %q", errNoValue, path)
+			}
+			value = node[i]
+		default:
+			return nil, fmt.Errorf("%w %q", errNoValue, path)
+		}
+	}
+	return value, nil
+}
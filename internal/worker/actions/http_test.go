@@ -0,0 +1,260 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	workersdk "go-tempo/pkg/worker"
+)
+
+var testHTTPOptions = HTTPOptions{
+	DefaultTimeout:   time.Second,
+	MaxTimeout:       5 * time.Second,
+	MaxResponseBytes: 1 << 10,
+}
+
+// runHTTP runs the registered http action with the input, as the worker would for a task
+func runHTTP(t *testing.T, ctx context.Context, in map[string]any) (HTTPResponse, error) {
+	t.Helper()
+	registry := workersdk.NewRegistry()
+	RegisterHTTP(registry, testHTTPOptions)
+
+	input, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("encoding input: %v", err)
+	}
+	output, err := registry.Handlers()[HTTPAction](ctx, input)
+	if err != nil {
+		return HTTPResponse{}, err
+	}
+	var out HTTPResponse
+	if err := json.Unmarshal(output, &out); err != nil {
+		t.Fatalf("decoding output %s: %v", output, err)
+	}
+	return out, nil
+}
+
+func TestHTTPRequest(t *testing.T) {
+	type received struct {
+		method, path, contentType, idempotencyKey, auth, body string
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{
+			method:         r.Method,
+			path:           r.URL.Path,
+			contentType:    r.Header.Get("Content-Type"),
+			idempotencyKey: r.Header.Get("Idempotency-Key"),
+			auth:           r.Header.Get("Authorization"),
+			body:           string(body),
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"emp-1"}`))
+	}))
+	defer server.Close()
+
+	ctx := workersdk.WithExecutionInfo(context.Background(), workersdk.ExecutionInfo{RefID: "create", IdempotencyKey: "exec:create"})
+	out, err := runHTTP(t, ctx, map[string]any{
+		"method":  "post",
+		"url":     server.URL + "/users/{{.Vars.user_id}}",
+		"vars":    map[string]any{"user_id": 42},
+		"headers": map[string]string{"Authorization": "Bearer {{.Task.RefID}}"},
+		"body":    map[string]any{"name": "Ada"},
+	})
+	if err != nil {
+		t.Fatalf("http action: %v", err)
+	}
+
+	got := <-requests
+	want := received{
+		method:         http.MethodPost,
+		path:           "/users/42",
+		contentType:    "application/json",
+		idempotencyKey: "exec:create",
+		auth:           "Bearer create",
+		body:           `{"name":"Ada"}`,
+	}
+	if got != want {
+		t.Errorf("request = %+v, want %+v", got, want)
+	}
+	if out.StatusCode != http.StatusCreated || string(out.Body) != `{"id":"emp-1"}` {
+		t.Errorf("output = %d %s, want 201 {\"id\":\"emp-1\"}", out.StatusCode, out.Body)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		header     map[string]string
+		expected   []int
+		wantKind   workersdk.ErrorKind // Empty for success
+		wantDelay  time.Duration
+		wantStatus int
+	}{
+		{name: "2xx succeeds", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "5xx is retried", status: http.StatusBadGateway, wantKind: workersdk.KindRetryable},
+		{name: "429 is retried after Retry-After", status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": "7"}, wantKind: workersdk.KindRetryable, wantDelay: 7 * time.Second},
+		{name: "4xx fails", status: http.StatusNotFound, wantKind: workersdk.KindNonRetryable},
+		{name: "expected status succeeds", status: http.StatusNotFound, expected: []int{200, 404}, wantStatus: http.StatusNotFound},
+		{name: "2xx not expected fails", status: http.StatusOK, expected: []int{201}, wantKind: workersdk.KindNonRetryable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for name, value := range tt.header {
+					w.Header().Set(name, value)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte("not json"))
+			}))
+			defer server.Close()
+
+			in := map[string]any{"url": server.URL}
+			if tt.expected != nil {
+				in["expected_status"] = tt.expected
+			}
+			out, err := runHTTP(t, context.Background(), in)
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatalf("http action: %v", err)
+				}
+				if out.StatusCode != tt.wantStatus {
+					t.Errorf("status_code = %d, want %d", out.StatusCode, tt.wantStatus)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("http action succeeded, want an error")
+			}
+			if kind := workersdk.KindOf(err); kind != tt.wantKind {
+				t.Errorf("error kind = %s, want %s (%v)", kind, tt.wantKind, err)
+			}
+			if delay := workersdk.RetryDelay(err); delay != tt.wantDelay {
+				t.Errorf("retry delay = %s, want %s", delay, tt.wantDelay)
+			}
+			if !strings.Contains(err.Error(), "not json") {
+				t.Errorf("error %q doesn't quote the response", err)
+			}
+		})
+	}
+}
+
+func TestHTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// A request outlasting its timeout is retried
+	_, err := runHTTP(t, context.Background(), map[string]any{"url": server.URL, "timeout": "50ms"})
+	if err == nil {
+		t.Fatal("http action succeeded, want a timeout")
+	}
+	if kind := workersdk.KindOf(err); kind != workersdk.KindRetryable {
+		t.Errorf("error kind = %s, want %s (%v)", kind, workersdk.KindRetryable, err)
+	}
+
+	// A timeout over the maximum fails without sending the request
+	_, err = runHTTP(t, context.Background(), map[string]any{"url": server.URL, "timeout": "1m"})
+	if kind := workersdk.KindOf(err); err == nil || kind != workersdk.KindNonRetryable {
+		t.Errorf("timeout over the maximum = %v (%s), want a non-retryable error", err, kind)
+	}
+
+	// A canceled task stops the request without counting as a failure of the endpoint
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = runHTTP(t, ctx, map[string]any{"url": server.URL})
+	if kind := workersdk.KindOf(err); err == nil || kind != workersdk.KindCancelled {
+		t.Errorf("canceled request = %v (%s), want a cancelled error", err, kind)
+	}
+}
+
+func TestHTTPExtract(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"items":[{"id":7,"tags":["a","b"]},{"id":8}],"total":12345678901234567890}}`))
+	}))
+	defer server.Close()
+
+	out, err := runHTTP(t, context.Background(), map[string]any{
+		"url": server.URL,
+		"extract": map[string]string{
+			"first_id": "data.items.0.id",
+			"tag":      "data.items.0.tags.1",
+			"second":   "data.items.1",
+			"total":    "data.total",
+			"all":      ".",
+		},
+	})
+	if err != nil {
+		t.Fatalf("http action: %v", err)
+	}
+	if out.Body != nil {
+		t.Errorf("body = %s, want it left out when extracting", out.Body)
+	}
+	for field, want := range map[string]string{
+		"first_id": `7`,
+		"tag":      `"b"`,
+		"second":   `{"id":8}`,
+		"total":    `12345678901234567890`, // Numbers keep their precision
+	} {
+		if got := string(out.Extracted[field]); got != want {
+			t.Errorf("extracted %s = %s, want %s", field, got, want)
+		}
+	}
+	if !strings.HasPrefix(string(out.Extracted["all"]), `{"data":`) {
+		t.Errorf("extracted all = %s, want the whole response", out.Extracted["all"])
+	}
+
+	for _, path := range []string{"data.missing", "data.items.2", "data.items.x", "data.total.x"} {
+		_, err := runHTTP(t, context.Background(), map[string]any{"url": server.URL, "extract": map[string]string{"v": path}})
+		if kind := workersdk.KindOf(err); err == nil || kind != workersdk.KindNonRetryable {
+			t.Errorf("extract %q = %v (%s), want a non-retryable error", path, err, kind)
+		}
+	}
+}
+
+func TestHTTPResponseLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", int(testHTTPOptions.MaxResponseBytes)+1)))
+	}))
+	defer server.Close()
+
+	_, err := runHTTP(t, context.Background(), map[string]any{"url": server.URL})
+	if kind := workersdk.KindOf(err); err == nil || kind != workersdk.KindNonRetryable {
+		t.Errorf("oversized response = %v (%s), want a non-retryable error", err, kind)
+	}
+}
+
+func TestHTTPInvalidInput(t *testing.T) {
+	for name, in := range map[string]map[string]any{
+		"missing url":        {},
+		"relative url":       {"url": "/users"},
+		"unsupported scheme": {"url": "ftp://example.com"},
+		"unsupported method": {"url": "http://example.com", "method": "TRACE"},
+		"bad status":         {"url": "http://example.com", "expected_status": []int{700}},
+		"bad timeout":        {"url": "http://example.com", "timeout": "soon"},
+		"missing var":        {"url": "http://example.com/{{.Vars.id}}"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := runHTTP(t, context.Background(), in)
+			if kind := workersdk.KindOf(err); err == nil || kind != workersdk.KindNonRetryable {
+				t.Errorf("http action = %v (%s), want a non-retryable error", err, kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	workersdk "go-tempo/pkg/worker"
+)
+
+// ShellAction is the name of the built-in subprocess action
+const ShellAction = "shell"
+
+// ShellOptions sandboxes the commands of the shell action
+type ShellOptions struct {
+	AllowedCommands []string      // Executable names looked up in PATH, or absolute paths
+	WorkDir         string        // Parent of the scratch directory each run gets, the system temp dir if empty
+	DefaultTimeout  time.Duration // Timeout of commands whose input sets none
+	MaxTimeout      time.Duration // Longest timeout an input may set
+	MaxOutputBytes  int           // Bytes of stdout and of stderr kept; the rest is dropped
+}
+
+// ShellCommand is the input of the shell action. The command runs directly, not through a shell,
+// so args aren't expanded.
+type ShellCommand struct {
+	Command string            `json:"command" validate:"required"` // One of shell_action.allowed_commands
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	Stdin   string            `json:"stdin"`
+	Timeout string            `json:"timeout"` // Go duration, e.g. "5m"
+}
+
+// ShellResult is the output of the shell action
+type ShellResult struct {
+	ExitCode        int    `json:"exit_code"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+}
+
+func (c ShellCommand) Validate() error {
+	for name := range c.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("invalid env name %q", name)
+		}
+		// The dynamic loader would run arbitrary code inside an allowed executable, and another
+		// PATH would swap the binaries an allowed script runs
+		if strings.HasPrefix(name, "LD_") || strings.HasPrefix(name, "DYLD_") || reservedEnv(name) {
+			return fmt.Errorf("env %s is not allowed", name)
+		}
+	}
+	if c.Timeout != "" {
+		if timeout, err := time.ParseDuration(c.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("timeout %q is not a positive duration", c.Timeout)
+		}
+	}
+	return nil
+}
+
+// RegisterShell registers the shell action, which runs an allowed command in a scratch directory
+// removed afterwards. A non-zero exit fails the attempt and is retried like any handler error,
+// keeping the result as the output; commands that time out are killed with their whole process
+// group.
+func RegisterShell(r *workersdk.Registry, opts ShellOptions) error {
+	allowed := make(map[string]string, len(opts.AllowedCommands))
+	for _, command := range opts.AllowedCommands {
+		path, err := exec.LookPath(command)
+		if err != nil {
+			return fmt.Errorf("allowed command %q: %w", command, err)
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			return fmt.Errorf("allowed command %q: %w", command, err)
+		}
+		allowed[command] = path
+	}
+	if opts.WorkDir != "" {
+		if err := os.MkdirAll(opts.WorkDir, 0o700); err != nil {
+			return fmt.Errorf("work dir: %w", err)
+		}
+	}
+
+	workersdk.Register(r, ShellAction, func(ctx context.Context, in ShellCommand) (ShellResult, error) {
+		path, ok := allowed[in.Command]
+		if !ok {
+			return ShellResult{}, workersdk.NonRetryable(fmt.Errorf("command %q is not allowed", in.Command))
+		}
+		return runShell(ctx, opts, path, in)
+	})
+	return nil
+}
+
+func runShell(ctx context.Context, opts ShellOptions, path string, in ShellCommand) (ShellResult, error) {
+	timeout := opts.DefaultTimeout
+	if in.Timeout != "" {
+		timeout, _ = time.ParseDuration(in.Timeout)
+	}
+	if timeout > opts.MaxTimeout {
+		return ShellResult{}, workersdk.NonRetryable(fmt.Errorf("timeout %s exceeds the maximum of %s", timeout, opts.MaxTimeout))
+	}
+
+	dir, err := os.MkdirTemp(opts.WorkDir, "tempo-shell-")
+	if err != nil {
+		return ShellResult{}, fmt.Errorf("creating work dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &cappedBuffer{max: opts.MaxOutputBytes}
+	stderr := &cappedBuffer{max: opts.MaxOutputBytes}
+	cmd := exec.CommandContext(runCtx, path, in.Args...)
+	cmd.Dir = dir
+	cmd.Env = shellEnv(ctx, dir, in.Env)
+	cmd.Stdin = strings.NewReader(in.Stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	killProcessGroup(cmd)
+
+	err = cmd.Run()
+	out := ShellResult{
+		ExitCode:        cmd.ProcessState.ExitCode(),
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return out, nil
+	case ctx.Err() != nil:
+		return ShellResult{}, ctx.Err()
+	case runCtx.Err() != nil:
+		return out, workersdk.Retryable(fmt.Errorf("%s timed out after %s%s", in.Command, timeout, tail(out.Stderr)))
+	case errors.As(err, &exitErr):
+		return out, fmt.Errorf("%s exited with code %d%s", in.Command, out.ExitCode, tail(out.Stderr))
+	default:
+		return ShellResult{}, workersdk.NonRetryable(fmt.Errorf("running %s: %w", in.Command, err))
+	}
+}
+
+// shellEnv is the environment of a command: the input env, PATH, the scratch directory as HOME and
+// TMPDIR and the task's execution info. The fixed variables come last so they win even if the
+// input env names them.
+func shellEnv(ctx context.Context, dir string, extra map[string]string) []string {
+	env := make([]string, 0, len(extra)+8)
+	for name, value := range extra {
+		env = append(env, name+"="+value)
+	}
+	env = append(env,
+		"PATH="+os.Getenv("PATH"),
+		"HOME="+dir,
+		"TMPDIR="+dir,
+	)
+	if info, ok := workersdk.ExecutionInfoFromContext(ctx); ok {
+		env = append(env,
+			"TEMPO_EXECUTION_ID="+info.ExecutionID.String(),
+			"TEMPO_TASK_ID="+info.TaskID.String(),
+			"TEMPO_REF_ID="+info.RefID,
+			"TEMPO_ATTEMPT="+strconv.Itoa(info.Attempt),
+			"TEMPO_IDEMPOTENCY_KEY="+info.IdempotencyKey,
+		)
+	}
+	return env
+}
+
+// reservedEnv reports whether shellEnv sets the variable itself
+func reservedEnv(name string) bool {
+	switch name {
+	case "PATH", "HOME", "TMPDIR":
+		return true
+	}
+	return strings.HasPrefix(name, "TEMPO_")
+}
+
+// tail returns the end of stderr for error messages
+func tail(stderr string) string {
+	const maxTail = 1024
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return ""
+	}
+	if len(stderr) > maxTail {
+		stderr = "..." + stderr[len(stderr)-maxTail:]
+	}
+	return ": " + stderr
+}
+
+// cappedBuffer keeps the first max bytes written to it and drops the rest. It has no ReadFrom,
+// so io.Copy can't bypass the cap.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
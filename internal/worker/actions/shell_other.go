@@ -0,0 +1,13 @@
+//go:build !unix
+
+package actions
+
+import (
+	"os/exec"
+	"time"
+)
+
+// killProcessGroup only kills the command itself where process groups aren't available
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.WaitDelay = 5 * time.Second
+}
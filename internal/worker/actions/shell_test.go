@@ -0,0 +1,198 @@
+//go:build unix
+
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	workersdk "go-tempo/pkg/worker"
+)
+
+// runShellAction runs the registered shell action with the input, as the worker would for a task.
+// The result is decoded from the output also when the action fails.
+func runShellAction(t *testing.T, opts ShellOptions, in map[string]any) (ShellResult, error) {
+	t.Helper()
+	if opts.AllowedCommands == nil {
+		opts.AllowedCommands = []string{"sh"}
+	}
+	if opts.DefaultTimeout == 0 {
+		opts.DefaultTimeout = 5 * time.Second
+		opts.MaxTimeout = 10 * time.Second
+	}
+	if opts.MaxOutputBytes == 0 {
+		opts.MaxOutputBytes = 1 << 10
+	}
+	registry := workersdk.NewRegistry()
+	if err := RegisterShell(registry, opts); err != nil {
+		t.Fatalf("RegisterShell: %v", err)
+	}
+
+	input, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("encoding input: %v", err)
+	}
+	output, runErr := registry.Handlers()[ShellAction](context.Background(), input)
+	var out ShellResult
+	if len(output) > 0 {
+		if err := json.Unmarshal(output, &out); err != nil {
+			t.Fatalf("decoding output %s: %v", output, err)
+		}
+	}
+	return out, runErr
+}
+
+// script runs the shell script through the allowed sh
+func script(source string) map[string]any {
+	return map[string]any{"command": "sh", "args": []string{"-c", source}}
+}
+
+func TestShellCommandNotAllowed(t *testing.T) {
+	_, err := runShellAction(t, ShellOptions{}, map[string]any{"command": "ls", "args": []string{"/"}})
+	if kind := workersdk.KindOf(err); err == nil || kind != workersdk.KindNonRetryable {
+		t.Fatalf("command outside the allow-list = %v (%s), want a non-retryable error", err, kind)
+	}
+	if !strings.Contains(err.Error(), `"ls" is not allowed`) {
+		t.Errorf("error %q doesn't name the command", err)
+	}
+}
+
+func TestShellEnv(t *testing.T) {
+	out, err := runShellAction(t, ShellOptions{}, map[string]any{
+		"command": "sh",
+		"args":    []string{"-c", `echo "$GREETING"`},
+		"env":     map[string]string{"GREETING": "hello"},
+	})
+	if err != nil {
+		t.Fatalf("shell action: %v", err)
+	}
+	if out.Stdout != "hello\n" {
+		t.Errorf("stdout = %q, want the input env's GREETING", out.Stdout)
+	}
+
+	// The input env can't replace the variables the action sets, nor the dynamic loader's
+	for _, name := range []string{"PATH", "HOME", "TMPDIR", "TEMPO_TASK_ID", "LD_PRELOAD", "DYLD_INSERT_LIBRARIES"} {
+		_, err := runShellAction(t, ShellOptions{}, map[string]any{
+			"command": "sh",
+			"args":    []string{"-c", "true"},
+			"env":     map[string]string{name: "/tmp/evil"},
+		})
+		if kind := workersdk.KindOf(err); err == nil || kind != workersdk.KindNonRetryable {
+			t.Errorf("env %s = %v (%s), want a non-retryable error", name, err, kind)
+		}
+	}
+}
+
+func TestShellNonZeroExit(t *testing.T) {
+	out, err := runShellAction(t, ShellOptions{}, script("echo out; echo oops >&2; exit 3"))
+	if err == nil {
+		t.Fatal("shell action succeeded, want the exit code to fail it")
+	}
+	if kind := workersdk.KindOf(err); kind != workersdk.KindRetryable {
+		t.Errorf("error kind = %s, want %s (%v)", kind, workersdk.KindRetryable, err)
+	}
+	if !strings.Contains(err.Error(), "exited with code 3: oops") {
+		t.Errorf("error %q, want the exit code and the end of stderr", err)
+	}
+
+	// The result is kept with the error, so a failed task records it
+	if out.ExitCode != 3 || out.Stdout != "out\n" || out.Stderr != "oops\n" {
+		t.Errorf("output = %+v, want exit code 3 with stdout and stderr", out)
+	}
+}
+
+func TestShellOutputTruncated(t *testing.T) {
+	out, err := runShellAction(t, ShellOptions{MaxOutputBytes: 8},
+		script("printf 0123456789; printf abc >&2"))
+	if err != nil {
+		t.Fatalf("shell action: %v", err)
+	}
+	if out.Stdout != "01234567" || !out.StdoutTruncated {
+		t.Errorf("stdout = %q (truncated %t), want the first 8 bytes marked truncated", out.Stdout, out.StdoutTruncated)
+	}
+	if out.Stderr != "abc" || out.StderrTruncated {
+		t.Errorf("stderr = %q (truncated %t), want all of it", out.Stderr, out.StderrTruncated)
+	}
+}
+
+func TestShellScratchDir(t *testing.T) {
+	workDir := t.TempDir()
+	out, err := runShellAction(t, ShellOptions{WorkDir: workDir},
+		script(`pwd; echo "$HOME"; echo "$TMPDIR"; echo data > file && cat file`))
+	if err != nil {
+		t.Fatalf("shell action: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.Stdout), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("stdout = %q, want 4 lines", out.Stdout)
+	}
+	dir := lines[0]
+	if filepath.Dir(dir) != workDir || lines[1] != dir || lines[2] != dir || lines[3] != "data" {
+		t.Errorf("stdout = %q, want a writable dir under %s as the working dir, HOME and TMPDIR", out.Stdout, workDir)
+	}
+
+	// The scratch dir is removed after the run
+	if entries, err := os.ReadDir(workDir); err != nil || len(entries) != 0 {
+		t.Errorf("work dir holds %v (%v), want the scratch dir removed", entries, err)
+	}
+}
+
+func TestShellTimeout(t *testing.T) {
+	// The command starts a background sleep in its process group and prints its pid
+	start := time.Now()
+	out, err := runShellAction(t, ShellOptions{}, map[string]any{
+		"command": "sh",
+		"args":    []string{"-c", "sleep 30 & echo $!; wait"},
+		"timeout": "200ms",
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Fatalf("shell action = %v, want a timeout", err)
+	}
+	var kindErr *workersdk.Error
+	if !errors.As(err, &kindErr) || kindErr.Kind != workersdk.KindRetryable {
+		t.Errorf("error %v, want it marked retryable", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("timed out command took %s, want it killed at once", elapsed)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(out.Stdout))
+	if err != nil {
+		t.Fatalf("stdout = %q, want the background pid", out.Stdout)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for running(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatal("background process outlived the timeout, want the whole process group killed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// running reports whether the process exists and isn't a zombie left for its new parent to reap
+func running(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return !os.IsNotExist(err) || !dirExists("/proc/self")
+	}
+	_, state, _ := strings.Cut(string(stat), ") ")
+	return !strings.HasPrefix(state, "Z")
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
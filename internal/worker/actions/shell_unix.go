@@ -0,0 +1,20 @@
+//go:build unix
+
+package actions
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killProcessGroup starts the command in its own process group and kills the whole group when
+// its context is done, so processes it spawned don't outlive it
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// Don't wait forever on output pipes held open by orphans outside the group
+	cmd.WaitDelay = 5 * time.Second
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"go-tempo/internal/domain"
+	workersdk "go-tempo/pkg/worker"
+
+	"github.com/google/uuid"
+)
+
+// ExecutionInfo describes the task attempt a handler is running for
+type ExecutionInfo = workersdk.ExecutionInfo
+
+// NewExecutionInfo builds the execution info for the current attempt of a task
+func NewExecutionInfo(task *domain.Task) ExecutionInfo {
+	return ExecutionInfo{
+		ExecutionID:    task.ExecutionID,
+		TaskID:         task.ID,
+		RefID:          task.RefID,
+		Attempt:        task.RetryCount + 1,
+		IdempotencyKey: IdempotencyKey(task.ExecutionID, task.RefID),
+	}
+}
+
+// IdempotencyKey derives a deterministic key from the execution and the task's RefID
+func IdempotencyKey(executionID uuid.UUID, refID string) string {
+	sum := sha256.Sum256([]byte(executionID.String() + ":" + refID))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithExecutionInfo returns a copy of ctx carrying info
+func WithExecutionInfo(ctx context.Context, info ExecutionInfo) context.Context {
+	return workersdk.WithExecutionInfo(ctx, info)
+}
+
+// ExecutionInfoFromContext returns the execution info stored in ctx, if any
+func ExecutionInfoFromContext(ctx context.Context) (ExecutionInfo, bool) {
+	return workersdk.ExecutionInfoFromContext(ctx)
+}
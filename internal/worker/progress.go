@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"go-tempo/internal/domain"
+	"go-tempo/internal/metrics"
+)
+
+// progressReporter writes the progress a handler reports to its task and the event stream. It
+// writes at most once per interval; reports in between are coalesced into the latest.
+type progressReporter struct {
+	worker   *Worker
+	ctx      context.Context
+	task     *domain.Task
+	interval time.Duration
+
+	mu        sync.Mutex
+	pending   *domain.TaskProgress
+	timer     *time.Timer // Scheduled write of pending, nil if none
+	lastWrite time.Time
+	closed    bool
+
+	writeMu sync.Mutex // Keeps writes in report order
+}
+
+func newProgressReporter(ctx context.Context, w *Worker, task *domain.Task) *progressReporter {
+	return &progressReporter{worker: w, ctx: ctx, task: task, interval: w.progressInterval}
+}
+
+func (p *progressReporter) Report(percent int, message string, details any) {
+	progress := domain.TaskProgress{
+		Percent:   min(max(percent, 0), 100),
+		Message:   message,
+		UpdatedAt: time.Now(),
+	}
+	if details != nil {
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			log.Printf("Worker dropped progress details of task %s: %v", p.task.RefID, err)
+		} else {
+			progress.Details = encoded
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	if p.pending != nil {
+		metrics.WorkerProgressReportsTotal.WithLabelValues(p.task.Action, "coalesced").Inc()
+	}
+	p.pending = &progress
+	if p.timer == nil {
+		p.timer = time.AfterFunc(max(p.interval-time.Since(p.lastWrite), 0), p.flush)
+	}
+}
+
+// flush writes the pending progress, if any
+func (p *progressReporter) flush() {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	p.mu.Lock()
+	progress := p.pending
+	p.pending, p.timer = nil, nil
+	if progress != nil {
+		p.lastWrite = time.Now()
+	}
+	p.mu.Unlock()
+	if progress == nil {
+		return
+	}
+
+	if err := p.worker.repo.UpdateProgress(p.ctx, p.task.ID, *progress); err != nil {
+		log.Printf("Worker failed to record progress of task %s: %v", p.task.RefID, err)
+		return
+	}
+	metrics.WorkerProgressReportsTotal.WithLabelValues(p.task.Action, "written").Inc()
+	p.worker.publishWorkflowEvent(p.ctx, domain.NewTaskProgressEvent(p.task, *progress))
+}
+
+// close writes the latest report once the handler has returned and ignores later ones
+func (p *progressReporter) close() {
+	p.mu.Lock()
+	p.closed = true
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.mu.Unlock()
+	p.flush()
+}
@@ -1,74 +1,45 @@
 package worker
 
 import (
-	"context"
-	"errors"
 	"fmt"
-	"time"
+	"sort"
+
+	workersdk "go-tempo/pkg/worker"
 )
 
 // TaskHandler is the blueprint for any function that does work
-type TaskHandler func(ctx context.Context, input []byte) ([]byte, error)
+type TaskHandler = workersdk.Handler
 
 // TaskRegistry holds all our executable actions
 type TaskRegistry map[string]TaskHandler
 
-// InitRegistry wires up the actual business logic
-func InitRegistry() TaskRegistry {
-	registry := make(TaskRegistry)
-
-	registry["create_employee_profile"] = func(ctx context.Context, input []byte) ([]byte, error) {
-		fmt.Printf("Creating employee profile with payload: %s\n", string(input))
-		time.Sleep(10 * time.Second)
-		return []byte(`{"status": "success", "employee_id": "EMP-12345", "profile_created": true}`), nil
-	}
-
-	registry["setup_email_account"] = func(ctx context.Context, input []byte) ([]byte, error) {
-		fmt.Printf("Setting up email account with payload: %s\n", string(input))
-		time.Sleep(10 * time.Second)
-		return []byte(`{"status": "success", "email": "john.doe@company.com", "mailbox_created": true}`), nil
-	}
-
-	registry["assign_equipment"] = func(ctx context.Context, input []byte) ([]byte, error) {
-		fmt.Printf("Assigning equipment with payload: %s\n", string(input))
-		time.Sleep(10 * time.Second)
-		return []byte(`{"status": "success", "laptop_id": "LT-789", "monitor_id": "MN-456", "assigned": true}`), nil
-	}
-
-	registry["enroll_benefits"] = func(ctx context.Context, input []byte) ([]byte, error) {
-		fmt.Printf("Enrolling in benefits with payload: %s\n", string(input))
-		time.Sleep(10 * time.Second)
-		return []byte(`{"status": "success", "health_plan": "Premium PPO", "401k_enrolled": true}`), nil
+// Subset returns the handlers of the given actions, or the whole registry when none are given
+func (r TaskRegistry) Subset(actions []string) (TaskRegistry, error) {
+	if len(actions) == 0 {
+		return r, nil
 	}
-
-	registry["schedule_orientation"] = func(ctx context.Context, input []byte) ([]byte, error) {
-		fmt.Printf("Scheduling orientation with payload: %s\n", string(input))
-		time.Sleep(10 * time.Second)
-		return []byte(`{"status": "success", "orientation_date": "2026-03-01", "calendar_invite_sent": true}`), nil
-	}
-
-	// Test function: Always fails to simulate task errors
-	registry["failing_task"] = func(ctx context.Context, input []byte) ([]byte, error) {
-		fmt.Printf("Failing task executed with payload: %s\n", string(input))
-		return nil, errors.New("simulated task failure")
-	}
-
-	// Test function: Long-running task that respects context cancellation (for timeout testing)
-	registry["timeout_task"] = func(ctx context.Context, input []byte) ([]byte, error) {
-		fmt.Printf("Long-running task started with payload: %s\n", string(input))
-		
-		// Run for 10 seconds but check for cancellation every 100ms
-		for i := 0; i < 100; i++ {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			default:
-				time.Sleep(100 * time.Millisecond)
-			}
+	subset := make(TaskRegistry, len(actions))
+	for _, action := range actions {
+		handler, ok := r[action]
+		if !ok {
+			return nil, fmt.Errorf("unknown action %q", action)
 		}
-		
-		return []byte(`{"status": "success", "completed": true}`), nil
+		subset[action] = handler
 	}
-
-	return registry
-}
\ No newline at end of file
+	return subset, nil
+}
+
+// Actions returns the registered action names, sorted
+func (r TaskRegistry) Actions() []string {
+	actions := make([]string, 0, len(r))
+	for action := range r {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// NewTaskRegistry builds the task registry from the handlers of an SDK registry, wrapped in its middleware
+func NewTaskRegistry(r *workersdk.Registry) TaskRegistry {
+	return TaskRegistry(r.Handlers())
+}
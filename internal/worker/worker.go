@@ -3,13 +3,18 @@ package worker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"runtime/debug"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go-tempo/internal/core/ports"
 	"go-tempo/internal/domain"
 	"go-tempo/internal/metrics"
+	workersdk "go-tempo/pkg/worker"
 
 	"github.com/google/uuid"
 )
@@ -22,40 +27,88 @@ type Worker struct {
 	workflowRepo ports.WorkflowRepository
 	eventBus     ports.EventBus
 	registry     TaskRegistry
+
+	resultCache   ports.ResultCache // Optional, nil disables result caching
+	cachedActions map[string]bool   // Actions whose outputs are cached by idempotency key
+
+	limiter       ports.ActionLimiter // Optional, nil disables action limits
+	leaseTTL      time.Duration       // Lease of a concurrency slot, renewed at a third of it
+	throttleDelay time.Duration       // Longest wait before requeueing a throttled task
+
+	progressInterval time.Duration // Least time between progress writes of a task
+
+	runningThreads atomic.Int32 // Pool goroutines currently in their loop
+
+	// Parent of in-flight handler contexts, canceled by CancelInFlight once draining times out
+	inFlightCtx    context.Context
+	cancelInFlight context.CancelFunc
 }
 
 func NewWorker(q ports.TaskQueue, retryQ ports.TaskQueue, r ports.TaskRepository, wfRepo ports.WorkflowRepository, bus ports.EventBus, reg TaskRegistry) *Worker {
+	inFlightCtx, cancelInFlight := context.WithCancel(context.Background())
 	return &Worker{
-		workerID:     uuid.New().String(),
-		queue:        q,
-		retryQueue:   retryQ,
-		repo:         r,
-		workflowRepo: wfRepo,
-		eventBus:     bus,
-		registry:     reg,
+		workerID:         uuid.New().String(),
+		queue:            q,
+		retryQueue:       retryQ,
+		repo:             r,
+		workflowRepo:     wfRepo,
+		eventBus:         bus,
+		registry:         reg,
+		progressInterval: time.Second,
+		inFlightCtx:      inFlightCtx,
+		cancelInFlight:   cancelInFlight,
+	}
+}
+
+// UseResultCache enables output caching for the given actions. When a cached output
+// exists for a task's idempotency key, the handler is not run again.
+func (w *Worker) UseResultCache(cache ports.ResultCache, actions ...string) {
+	w.resultCache = cache
+	w.cachedActions = make(map[string]bool, len(actions))
+	for _, action := range actions {
+		w.cachedActions[action] = true
 	}
 }
 
-// ProcessNextTask handles exactly ONE task lifecycle (orchestrates the workflow)
+// UseLimiter enforces per-action concurrency and rate limits. Tasks that would exceed one are
+// requeued after at most throttleDelay instead of running.
+func (w *Worker) UseLimiter(limiter ports.ActionLimiter, leaseTTL, throttleDelay time.Duration) {
+	w.limiter = limiter
+	w.leaseTTL = leaseTTL
+	w.throttleDelay = throttleDelay
+}
+
+// ThrottleProgress sets the least time between writes of the progress a handler reports.
+// Reports in between are coalesced into the latest.
+func (w *Worker) ThrottleProgress(interval time.Duration) {
+	w.progressInterval = interval
+}
+
+// ProcessNextTask handles exactly ONE task lifecycle (orchestrates the workflow).
+// Canceling ctx stops it waiting for a task; once a task is popped it runs to completion
+// unless CancelInFlight is called.
 func (w *Worker) ProcessNextTask(ctx context.Context) {
-	// 1. Pop and fetch task from queue
-	task, err := w.popAndFetchTask(ctx)
+	// 1. Pop and fetch task from queue. A claiming queue hands over the task already claimed
+	task, claimed, err := w.popAndFetchTask(ctx)
 	if err != nil {
 		return // Error already logged in popAndFetchTask
 	}
 
+	// The popped task is owned by this worker now, so shutdown must not abandon its bookkeeping
+	ctx = context.WithoutCancel(ctx)
+
 	// Track queue wait time
 	queueWaitTime := time.Since(task.CreatedAt).Seconds()
 	metrics.WorkerQueueWaitTime.Observe(queueWaitTime)
 
 	// 2. Check if task should be skipped
 	if task.SkipHint {
-		w.handleSkippedTask(ctx, task)
+		w.handleSkippedTask(ctx, task, w.skipReason(ctx, task))
 		return
 	}
 
 	// 3. Claim the task
-	if !w.claimTask(ctx, task) {
+	if !claimed && !w.claimTask(ctx, task) {
 		return // Failed to claim (already claimed by another worker)
 	}
 
@@ -63,45 +116,128 @@ func (w *Worker) ProcessNextTask(ctx context.Context) {
 	metrics.WorkerActiveTasks.WithLabelValues(w.workerID).Inc()
 	defer metrics.WorkerActiveTasks.WithLabelValues(w.workerID).Dec()
 
-	// 4. Execute the task
+	// 4. Reuse the output of a previous run of this task, if cached. A redelivery that never
+	// runs the action needs no concurrency slot or rate limit token
+	if output, ok := w.lookupCachedResult(ctx, task); ok {
+		w.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskStarted, task, ""))
+		w.handleTaskSuccess(ctx, task, output)
+		return
+	}
+
+	// 5. Respect the action limits; a throttled task goes back to its queue
+	releaseLimits, ok := w.acquireLimits(ctx, task)
+	if !ok {
+		return
+	}
+	defer releaseLimits()
+	w.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskStarted, task, ""))
+
+	// 6. Execute the task
 	output, err := w.executeTaskAction(ctx, task)
 	if err != nil {
-		w.handleTaskFailure(ctx, task, err)
+		switch {
+		case w.inFlightCtx.Err() != nil:
+			// Interrupted by shutdown; a handler canceling on its own counts as a failed attempt,
+			// or a task canceling every run would be released forever
+			w.releaseTask(ctx, task)
+		case workersdk.KindOf(err) == workersdk.KindSkip:
+			w.handleTaskSkippedByHandler(ctx, task, err)
+		default:
+			w.handleTaskFailure(ctx, task, output, err)
+		}
 		return
 	}
 
-	// 5. Handle successful completion
+	// 7. Handle successful completion
+	w.storeCachedResult(ctx, task, output)
 	w.handleTaskSuccess(ctx, task, output)
 }
 
-// popAndFetchTask pops task ID from queue and fetches full task data from DB
-func (w *Worker) popAndFetchTask(ctx context.Context) (*domain.Task, error) {
+// lookupCachedResult returns the cached output for the task's idempotency key
+func (w *Worker) lookupCachedResult(ctx context.Context, task *domain.Task) ([]byte, bool) {
+	if w.resultCache == nil || !w.cachedActions[task.Action] {
+		return nil, false
+	}
+
+	output, found, err := w.resultCache.Get(ctx, IdempotencyKey(task.ExecutionID, task.RefID))
+	if err != nil {
+		log.Printf("Worker failed to read result cache for task %s: %v", task.RefID, err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+
+	log.Printf("Worker %s found cached result for task %s, skipping execution", w.workerID, task.RefID)
+	metrics.WorkerResultCacheHitsTotal.WithLabelValues(task.Action).Inc()
+	return output, true
+}
+
+// storeCachedResult saves the output so redeliveries of the task don't re-execute it
+func (w *Worker) storeCachedResult(ctx context.Context, task *domain.Task, output []byte) {
+	if w.resultCache == nil || !w.cachedActions[task.Action] {
+		return
+	}
+
+	if err := w.resultCache.Put(ctx, IdempotencyKey(task.ExecutionID, task.RefID), output); err != nil {
+		log.Printf("Worker failed to cache result for task %s: %v", task.RefID, err)
+	}
+}
+
+// popAndFetchTask pops task ID from queue and fetches full task data from DB. A claiming queue
+// returns the task claimed for this worker, reported by claimed.
+func (w *Worker) popAndFetchTask(ctx context.Context) (task *domain.Task, claimed bool, err error) {
+	if q, ok := w.queue.(ports.ClaimingTaskQueue); ok {
+		task, err := q.PopClaimed(ctx, w.workerID)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Worker error popping from queue: %v", err)
+			}
+			return nil, false, err
+		}
+		log.Printf("Worker %s claimed task %s", w.workerID, task.RefID)
+		return task, true, nil
+	}
+
 	taskIDStr, err := w.queue.Pop(ctx)
 	if err != nil {
-		log.Printf("Worker error popping from queue: %v", err)
-		return nil, err
+		if ctx.Err() == nil {
+			log.Printf("Worker error popping from queue: %v", err)
+		}
+		return nil, false, err
 	}
 
 	taskID, err := uuid.Parse(taskIDStr)
 	if err != nil {
 		log.Printf("Worker failed to parse task ID %s: %v", taskIDStr, err)
-		return nil, err
+		return nil, false, err
 	}
 
-	task, err := w.repo.FindTaskByID(ctx, taskID)
+	// Shutdown may begin right after the pop; the task must still be fetched and handled
+	task, err = w.repo.FindTaskByID(context.WithoutCancel(ctx), taskID)
 	if err != nil {
 		log.Printf("Worker failed to find task %s: %v", taskIDStr, err)
-		return nil, err
+		return nil, false, err
 	}
 
-	return task, nil
+	return task, false, nil
+}
+
+// skipReason tells a task skipped because its workflow was cancelled from one whose parent failed
+func (w *Worker) skipReason(ctx context.Context, task *domain.Task) string {
+	execution, err := w.workflowRepo.GetByID(ctx, task.ExecutionID)
+	if err != nil {
+		log.Printf("Worker failed to find workflow %s of skipped task %s: %v", task.ExecutionID, task.RefID, err)
+		return domain.SkipReasonParentFailed
+	}
+	return domain.SkipReason(execution.Status)
 }
 
-// handleSkippedTask marks task as skipped and publishes termination event
-func (w *Worker) handleSkippedTask(ctx context.Context, task *domain.Task) {
-	log.Printf("Worker %s skipping task %s (parent task failed)", w.workerID, task.RefID)
+// handleSkippedTask marks task as skipped for the reason and publishes termination event
+func (w *Worker) handleSkippedTask(ctx context.Context, task *domain.Task, reason string) {
+	log.Printf("Worker %s skipping task %s (%s)", w.workerID, task.RefID, reason)
 
-	err := w.repo.MarkSkipped(ctx, task.ID)
+	err := w.repo.MarkSkipped(ctx, task.ID, reason)
 	if err != nil {
 		log.Printf("Worker failed to mark task %s as skipped: %v", task.RefID, err)
 		return
@@ -113,9 +249,10 @@ func (w *Worker) handleSkippedTask(ctx context.Context, task *domain.Task) {
 		task.ID,
 		task.RefID,
 		domain.TaskTerminationSkipped,
-		"skipped due to parent task failure",
+		"skipped: "+reason,
 	)
 	w.eventBus.PublishTaskTerminated(ctx, terminationEvent)
+	w.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskSkipped, task, reason))
 
 	metrics.WorkerTasksProcessedTotal.WithLabelValues(task.Action, "skipped").Inc()
 	log.Printf("Worker successfully skipped task %s", task.RefID)
@@ -136,78 +273,213 @@ func (w *Worker) claimTask(ctx context.Context, task *domain.Task) bool {
 	return true
 }
 
+// acquireLimits takes a concurrency slot and a rate token for the task's action and renews the
+// slot's lease until the returned release func is called. If a limit is reached, or the limiter
+// can't be reached, the task is requeued and false is returned.
+func (w *Worker) acquireLimits(ctx context.Context, task *domain.Task) (func(), bool) {
+	if w.limiter == nil {
+		return func() {}, true
+	}
+
+	taskID := task.ID.String()
+	throttledBy, retryAfter, err := w.limiter.Acquire(ctx, task.Action, taskID)
+	if err != nil {
+		log.Printf("Worker failed to check limits of task %s, requeueing it: %v", task.RefID, err)
+		w.requeueThrottled(ctx, task, 0)
+		return nil, false
+	}
+	if throttledBy != "" {
+		log.Printf("Worker %s throttled task %s by the %s limit of %s", w.workerID, task.RefID, throttledBy, task.Action)
+		metrics.WorkerTasksThrottledTotal.WithLabelValues(task.Action, throttledBy).Inc()
+		w.requeueThrottled(ctx, task, retryAfter)
+		return nil, false
+	}
+
+	renewCtx, stopRenew := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(w.leaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.limiter.Renew(renewCtx, task.Action, taskID); err != nil {
+					log.Printf("Worker failed to renew limit lease of task %s: %v", task.RefID, err)
+				}
+			case <-renewCtx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		stopRenew()
+		if err := w.limiter.Release(ctx, task.Action, taskID); err != nil {
+			log.Printf("Worker failed to release limit slot of task %s: %v", task.RefID, err)
+		}
+	}, true
+}
+
+// requeueThrottled hands a throttled task back to its queue. The worker waits first, for
+// retryAfter when known but at most throttleDelay, so it doesn't spin on throttled tasks.
+func (w *Worker) requeueThrottled(ctx context.Context, task *domain.Task, retryAfter time.Duration) {
+	delay := w.throttleDelay
+	if retryAfter > 0 && retryAfter < delay {
+		delay = retryAfter
+	}
+	timer := time.NewTimer(delay)
+	select {
+	case <-timer.C:
+	case <-w.inFlightCtx.Done():
+	}
+	timer.Stop()
+
+	if err := w.requeueTask(ctx, task); err != nil {
+		log.Printf("Worker failed to requeue throttled task %s: %v", task.RefID, err)
+	}
+}
+
 // executeTaskAction looks up and executes the task handler
 func (w *Worker) executeTaskAction(ctx context.Context, task *domain.Task) ([]byte, error) {
 	handler, exists := w.registry[task.Action]
 	if !exists {
 		log.Printf("Worker unknown action: %s", task.Action)
-		w.repo.MarkFailed(ctx, task.ID, "unknown action")
-
 		metrics.WorkerRegistryErrorsTotal.WithLabelValues(task.Action).Inc()
-		metrics.WorkerTasksProcessedTotal.WithLabelValues(task.Action, "failed").Inc()
 
-		// Publish termination event
-		terminationEvent := domain.NewTaskTerminatedEvent(
-			task.ExecutionID,
-			task.ID,
-			task.RefID,
-			domain.TaskTerminationFailed,
-			"unknown action",
-		)
-		w.eventBus.PublishTaskTerminated(ctx, terminationEvent)
-		w.workflowRepo.UpdateStatus(ctx, task.ExecutionID, string(domain.WorkflowFailed))
-
-		return nil, errors.New("unknown action")
+		// Another run won't find the action either, so the task fails without retries
+		return nil, workersdk.NonRetryable(errors.New("unknown action"))
 	}
 
-	// Execute handler and track execution time
+	// Execute handler and track execution time. The handler is canceled if draining times out
+	handlerCtx, cancel := context.WithCancel(WithExecutionInfo(ctx, NewExecutionInfo(task)))
+	defer cancel()
+	stop := context.AfterFunc(w.inFlightCtx, cancel)
+	defer stop()
+
+	// Progress is written against ctx, so the last report lands even if the handler was canceled
+	progress := newProgressReporter(ctx, w, task)
+	handlerCtx = workersdk.WithProgressReporter(handlerCtx, progress)
+
 	execStart := time.Now()
-	output, err := handler(ctx, []byte(task.Input))
+	output, err := runHandler(handlerCtx, task, handler)
+	progress.close()
 	execDuration := time.Since(execStart).Seconds()
 	metrics.WorkerTaskDuration.WithLabelValues(task.Action).Observe(execDuration)
 
 	return output, err
 }
 
-// handleTaskFailure handles task failure with retry logic
-func (w *Worker) handleTaskFailure(ctx context.Context, task *domain.Task, execErr error) {
+// runHandler runs the handler, turning a panic into a non-retryable error carrying the stack
+// trace so the task fails instead of the worker crashing
+func runHandler(ctx context.Context, task *domain.Task, handler TaskHandler) (output []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Worker recovered panic in handler of task %s: %v", task.RefID, r)
+			output, err = nil, workersdk.NonRetryable(fmt.Errorf("handler panicked: %v\n%s", r, debug.Stack()))
+		}
+	}()
+	return handler(ctx, []byte(task.Input))
+}
+
+// releaseTask hands a task interrupted by shutdown back to its queue without counting the attempt
+func (w *Worker) releaseTask(ctx context.Context, task *domain.Task) {
+	log.Printf("Worker %s interrupted task %s, returning it to the queue", w.workerID, task.RefID)
+
+	if err := w.requeueTask(ctx, task); err != nil {
+		log.Printf("Worker failed to release task %s: %v", task.RefID, err)
+		return
+	}
+
+	metrics.WorkerTasksProcessedTotal.WithLabelValues(task.Action, "released").Inc()
+}
+
+// requeueTask moves a claimed task back to QUEUED and pushes it to the queue it came from
+func (w *Worker) requeueTask(ctx context.Context, task *domain.Task) error {
+	if err := w.repo.ReleaseTask(ctx, task.ID, task.Version); err != nil {
+		return err
+	}
+	return w.queue.Push(ctx, task)
+}
+
+// handleTaskFailure handles task failure with retry logic. output is what the handler returned
+// alongside execErr, recorded if the task fails for good.
+func (w *Worker) handleTaskFailure(ctx context.Context, task *domain.Task, output []byte, execErr error) {
 	log.Printf("Worker task %s failed: %v", task.RefID, execErr)
+	w.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskAttemptFailed, task, execErr.Error()))
+
+	// Non-retryable errors fail the task at once
+	if workersdk.KindOf(execErr) == workersdk.KindNonRetryable {
+		log.Printf("Worker task %s failed with a non-retryable error, marking as failed", task.RefID)
+		w.markTaskFailedPermanently(ctx, task, output, execErr)
+		return
+	}
 
 	// Check if task can be retried
 	if task.CanRetry(task.MaxRetries) {
-		w.retryTask(ctx, task)
+		w.retryTask(ctx, task, execErr)
 		return
 	}
 
 	// Retries exhausted - mark as failed permanently
-	w.markTaskFailedPermanently(ctx, task, execErr)
+	log.Printf("Worker task %s exhausted all retries, marking as failed", task.RefID)
+	metrics.TaskRetryExhaustionTotal.WithLabelValues(task.Action).Inc()
+	w.markTaskFailedPermanently(ctx, task, output, execErr)
 }
 
 // retryTask increments retry count and pushes task back to retry queue
-func (w *Worker) retryTask(ctx context.Context, task *domain.Task) {
+func (w *Worker) retryTask(ctx context.Context, task *domain.Task, execErr error) {
 	log.Printf("Worker retrying task %s (retry %d/%d)", task.RefID, task.RetryCount+1, task.MaxRetries)
 
 	metrics.WorkerRetriesTotal.WithLabelValues(task.Action, strconv.Itoa(task.RetryCount+1)).Inc()
 
-	err := w.repo.IncrementRetryCount(ctx, task.ID, task.Version)
+	// A handler's retry-after delay is stored with the task, and the retry queue holds the task
+	// until then, so the delay outlasts this process
+	var nextAttemptAt *time.Time
+	if delay := workersdk.RetryDelay(execErr); delay > 0 {
+		log.Printf("Worker delaying retry of task %s by %s", task.RefID, delay)
+		at := time.Now().Add(delay)
+		nextAttemptAt = &at
+	}
+	err := w.repo.IncrementRetryCount(ctx, task.ID, task.Version, nextAttemptAt)
 	if err != nil {
 		log.Printf("Worker failed to increment retry count for task %s: %v", task.RefID, err)
 		return
 	}
+	task.NextAttemptAt = nextAttemptAt
 
-	pushErr := w.retryQueue.Push(ctx, task.ID.String())
-	if pushErr != nil {
+	if pushErr := w.retryQueue.Push(ctx, task); pushErr != nil {
 		log.Printf("Worker failed to push task %s to retry queue: %v", task.RefID, pushErr)
+		return
+	}
+
+	w.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskRetried, task, execErr.Error()))
+}
+
+// handleTaskSkippedByHandler marks a task whose handler chose to skip it as skipped. Unlike a
+// task skipped because its parent failed, it unblocks its children like a completed task.
+func (w *Worker) handleTaskSkippedByHandler(ctx context.Context, task *domain.Task, reason error) {
+	log.Printf("Worker task %s skipped by its handler: %v", task.RefID, reason)
+
+	if err := w.repo.MarkSkipped(ctx, task.ID, reason.Error()); err != nil {
+		log.Printf("Worker failed to mark task %s as skipped: %v", task.RefID, err)
+		return
+	}
+
+	metrics.WorkerTasksProcessedTotal.WithLabelValues(task.Action, "skipped").Inc()
+
+	event := domain.TaskCompletedEvent{
+		ExecutionID: task.ExecutionID,
+		TaskID:      task.ID,
+		RefID:       task.RefID,
+		Skipped:     true,
 	}
+	w.eventBus.PublishTaskCompleted(ctx, event)
+	w.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskSkipped, task, reason.Error()))
 }
 
 // markTaskFailedPermanently marks task as failed and publishes termination event
-func (w *Worker) markTaskFailedPermanently(ctx context.Context, task *domain.Task, execErr error) {
-	log.Printf("Worker task %s exhausted all retries, marking as failed", task.RefID)
-
-	w.repo.MarkFailed(ctx, task.ID, execErr.Error())
+func (w *Worker) markTaskFailedPermanently(ctx context.Context, task *domain.Task, output []byte, execErr error) {
+	w.repo.MarkFailed(ctx, task.ID, execErr.Error(), output)
 
-	metrics.TaskRetryExhaustionTotal.WithLabelValues(task.Action).Inc()
 	metrics.WorkerTasksProcessedTotal.WithLabelValues(task.Action, "failed").Inc()
 
 	w.workflowRepo.UpdateStatus(ctx, task.ExecutionID, string(domain.WorkflowFailed))
@@ -221,6 +493,7 @@ func (w *Worker) markTaskFailedPermanently(ctx context.Context, task *domain.Tas
 		execErr.Error(),
 	)
 	w.eventBus.PublishTaskTerminated(ctx, terminationEvent)
+	w.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskFailed, task, execErr.Error()))
 }
 
 // handleTaskSuccess marks task as completed and publishes completion event
@@ -235,16 +508,43 @@ func (w *Worker) handleTaskSuccess(ctx context.Context, task *domain.Task, outpu
 		RefID:       task.RefID,
 	}
 	w.eventBus.PublishTaskCompleted(ctx, event)
+	w.publishWorkflowEvent(ctx, domain.NewTaskEvent(domain.EventTaskCompleted, task, ""))
 
 	log.Printf("Worker successfully finished %s", task.RefID)
 }
 
-// StartPool launches multiple concurrent worker loops
-func (w *Worker) StartPool(ctx context.Context, concurrency int) {
+// publishWorkflowEvent records a lifecycle event for event stream subscribers.
+// Failures are logged only; the event stream is not part of task state.
+func (w *Worker) publishWorkflowEvent(ctx context.Context, event domain.WorkflowEvent) {
+	event.WorkerID = w.workerID
+	if _, err := w.eventBus.PublishWorkflowEvent(ctx, event); err != nil {
+		log.Printf("Worker failed to publish %s event for task %s: %v", event.Type, event.RefID, err)
+	}
+}
+
+// RunningThreads returns how many pool goroutines are running (used by health checks)
+func (w *Worker) RunningThreads() int {
+	return int(w.runningThreads.Load())
+}
+
+// CancelInFlight cancels the handlers still running after the drain timeout.
+// Their tasks are released back to the queue for another worker.
+func (w *Worker) CancelInFlight() {
+	w.cancelInFlight()
+}
+
+// StartPool launches multiple concurrent worker loops. Canceling ctx stops them popping
+// new tasks; wg is done once every loop has finished its current task.
+func (w *Worker) StartPool(ctx context.Context, concurrency int, wg *sync.WaitGroup) {
 	log.Printf("Starting worker pool with %d concurrent workers...", concurrency)
 
 	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		w.runningThreads.Add(1)
 		go func(threadID int) {
+			defer wg.Done()
+			defer w.runningThreads.Add(-1)
+
 			log.Printf("Worker thread %d (ID: %s) started", threadID, w.workerID)
 			for {
 				select {
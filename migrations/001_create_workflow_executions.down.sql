@@ -0,0 +1 @@
+DROP TABLE IF EXISTS workflow_executions;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS workflow_executions (
+    id              UUID PRIMARY KEY,
+    user_id         UUID        NOT NULL,
+    workflow_type   VARCHAR(50) NOT NULL,
+    status          VARCHAR(20) DEFAULT 'RUNNING',
+    callback_url    TEXT,
+    callback_events JSONB,
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ
+);
+
+-- Workflow search (GET /api/v1/workflows) is ordered by (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_user_id ON workflow_executions (user_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_created ON workflow_executions (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_user_created ON workflow_executions (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_type_created ON workflow_executions (workflow_type, created_at);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_status_created ON workflow_executions (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_updated_at ON workflow_executions (updated_at);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS tasks (
+    id           UUID PRIMARY KEY,
+    execution_id UUID         NOT NULL REFERENCES workflow_executions (id) ON DELETE CASCADE,
+    ref_id       VARCHAR(100) NOT NULL,
+    action       VARCHAR(100) NOT NULL,
+    status       VARCHAR(20)  DEFAULT 'PENDING',
+    retry_count  BIGINT       DEFAULT 0,
+    max_retries  BIGINT       DEFAULT 3,
+    last_error   TEXT,
+    dependencies JSONB,
+    in_degree    BIGINT       DEFAULT 0,
+    skip_hint    BOOLEAN      DEFAULT FALSE,
+    worker_id    VARCHAR(100),
+    version      BIGINT       DEFAULT 1,
+    input        JSONB,
+    output       JSONB,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_execution_id ON tasks (execution_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks (status);
+CREATE INDEX IF NOT EXISTS idx_tasks_worker_id ON tasks (worker_id);
+
+-- failing_action filter of the workflow search
+CREATE INDEX IF NOT EXISTS idx_tasks_action_status ON tasks (action, status);
+
+-- DecrementAndGetReadyTasks / DecrementAndSetSkipHint / FindChildren: dependencies @> '["ref"]'
+CREATE INDEX IF NOT EXISTS idx_tasks_dependencies ON tasks USING GIN (dependencies jsonb_path_ops);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id         UUID PRIMARY KEY,
+    url        TEXT         NOT NULL,
+    events     JSONB,
+    secret     VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              UUID PRIMARY KEY,
+    execution_id    UUID        NOT NULL REFERENCES workflow_executions (id) ON DELETE CASCADE,
+    subscription_id UUID,
+    url             TEXT        NOT NULL,
+    event_type      VARCHAR(50) NOT NULL,
+    payload         JSONB,
+    status          VARCHAR(20) DEFAULT 'PENDING',
+    attempts        BIGINT      DEFAULT 0,
+    last_error      TEXT,
+    response_code   BIGINT      DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    delivered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_execution_id ON webhook_deliveries (execution_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+
+-- ClaimDueDeliveries only scans deliveries that are still pending
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
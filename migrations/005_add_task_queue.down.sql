@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_queue_ready;
+ALTER TABLE tasks DROP COLUMN IF EXISTS queued_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS queue;
@@ -0,0 +1,6 @@
+-- Postgres queue backend (queues.backend: postgres): the tasks table is the queue
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS queue VARCHAR(100);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS queued_at TIMESTAMPTZ;
+
+-- Pop takes the oldest QUEUED task of a queue with FOR UPDATE SKIP LOCKED
+CREATE INDEX IF NOT EXISTS idx_tasks_queue_ready ON tasks (queue, queued_at) WHERE status = 'QUEUED';
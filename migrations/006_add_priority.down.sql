@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority;
+ALTER TABLE workflow_executions DROP COLUMN IF EXISTS priority;
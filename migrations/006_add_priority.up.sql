@@ -0,0 +1,3 @@
+-- Scheduling priority, 0 (lowest) to 9 (highest); existing rows get the default of 5
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 5;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 5;
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS task_queue_clocks;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_tenant;
+DROP INDEX IF EXISTS idx_webhook_subscriptions_tenant;
+DROP INDEX IF EXISTS idx_tasks_tenant;
+DROP INDEX IF EXISTS idx_workflow_executions_tenant_created;
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS tenant;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS tenant;
+ALTER TABLE tasks DROP COLUMN IF EXISTS tenant;
+ALTER TABLE workflow_executions DROP COLUMN IF EXISTS tenant;
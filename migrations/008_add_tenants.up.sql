@@ -0,0 +1,19 @@
+-- Tenant (namespace) of every workflow; API requests only see their tenant's rows
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS tenant VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS tenant VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS tenant VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS tenant VARCHAR(63) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_tenant_created ON workflow_executions (tenant, created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_tenant ON tasks (tenant);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant ON webhook_subscriptions (tenant);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant ON webhook_deliveries (tenant);
+
+-- Postgres queue backend: virtual clock per queue and tenant for weighted fair dispatch.
+-- The row with an empty tenant holds the clock of the queue itself.
+CREATE TABLE IF NOT EXISTS task_queue_clocks (
+    queue        VARCHAR(100)     NOT NULL,
+    tenant       VARCHAR(63)      NOT NULL,
+    virtual_time DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (queue, tenant)
+);
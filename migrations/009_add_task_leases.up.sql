@@ -0,0 +1,5 @@
+-- Leases of tasks claimed by external workers over the HTTP worker protocol
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS lease_token_hash VARCHAR(64);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_lease_expires_at ON tasks (lease_expires_at) WHERE lease_expires_at IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS progress_updated_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS progress_details;
+ALTER TABLE tasks DROP COLUMN IF EXISTS progress_message;
+ALTER TABLE tasks DROP COLUMN IF EXISTS progress_percent;
@@ -0,0 +1,5 @@
+-- Latest progress reported by the handler of a running task
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_percent INTEGER;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_message TEXT;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_details JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_updated_at TIMESTAMPTZ;
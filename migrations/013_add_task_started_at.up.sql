@@ -0,0 +1,2 @@
+-- When the worker running a task claimed it
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
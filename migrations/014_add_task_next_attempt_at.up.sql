@@ -0,0 +1,2 @@
+-- When a retry delayed by its handler may run; the Postgres queue skips the task until then
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
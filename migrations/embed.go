@@ -0,0 +1,26 @@
+// Package migrations embeds the versioned SQL schema migrations into the binary.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS holds every NNN_name.up.sql and NNN_name.down.sql file in this directory
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLiteFS holds the same schema for the embedded SQLite store (--store=sqlite:path)
+var SQLiteFS = mustSub(sqliteFiles, "sqlite")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
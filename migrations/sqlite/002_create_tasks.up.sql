@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS tasks (
+    id           TEXT PRIMARY KEY,
+    execution_id TEXT         NOT NULL REFERENCES workflow_executions (id) ON DELETE CASCADE,
+    ref_id       VARCHAR(100) NOT NULL,
+    action       VARCHAR(100) NOT NULL,
+    status       VARCHAR(20)  DEFAULT 'PENDING',
+    retry_count  INTEGER      DEFAULT 0,
+    max_retries  INTEGER      DEFAULT 3,
+    last_error   TEXT,
+    dependencies TEXT,
+    in_degree    INTEGER      DEFAULT 0,
+    skip_hint    BOOLEAN      DEFAULT FALSE,
+    worker_id    VARCHAR(100),
+    version      INTEGER      DEFAULT 1,
+    input        TEXT,
+    output       TEXT,
+    created_at   DATETIME,
+    updated_at   DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_execution_id ON tasks (execution_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks (status);
+CREATE INDEX IF NOT EXISTS idx_tasks_worker_id ON tasks (worker_id);
+
+-- failing_action filter of the workflow search
+CREATE INDEX IF NOT EXISTS idx_tasks_action_status ON tasks (action, status);
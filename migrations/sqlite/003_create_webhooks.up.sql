@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id         TEXT PRIMARY KEY,
+    url        TEXT         NOT NULL,
+    events     TEXT,
+    secret     VARCHAR(100) NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              TEXT PRIMARY KEY,
+    execution_id    TEXT        NOT NULL REFERENCES workflow_executions (id) ON DELETE CASCADE,
+    subscription_id TEXT,
+    url             TEXT        NOT NULL,
+    event_type      VARCHAR(50) NOT NULL,
+    payload         TEXT,
+    status          VARCHAR(20) DEFAULT 'PENDING',
+    attempts        INTEGER     DEFAULT 0,
+    last_error      TEXT,
+    response_code   INTEGER     DEFAULT 0,
+    next_attempt_at DATETIME,
+    delivered_at    DATETIME,
+    created_at      DATETIME,
+    updated_at      DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_execution_id ON webhook_deliveries (execution_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+
+-- ClaimDueDeliveries only scans deliveries that are still pending
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
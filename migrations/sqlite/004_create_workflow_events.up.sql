@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS workflow_events (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    execution_id TEXT        NOT NULL REFERENCES workflow_executions (id) ON DELETE CASCADE,
+    type         VARCHAR(50) NOT NULL,
+    task_id      TEXT,
+    ref_id       VARCHAR(100),
+    action       VARCHAR(100),
+    attempt      INTEGER,
+    worker_id    VARCHAR(100),
+    error        TEXT,
+    status       VARCHAR(20),
+    occurred_at  DATETIME    NOT NULL
+);
+
+-- ListByExecution reads one execution's history in insertion order
+CREATE INDEX IF NOT EXISTS idx_workflow_events_execution_id ON workflow_events (execution_id, id);
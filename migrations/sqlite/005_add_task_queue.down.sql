@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN queued_at;
+ALTER TABLE tasks DROP COLUMN queue;
@@ -0,0 +1,3 @@
+-- Only used by the Postgres queue backend; kept so the tasks table matches the model
+ALTER TABLE tasks ADD COLUMN queue VARCHAR(100);
+ALTER TABLE tasks ADD COLUMN queued_at DATETIME;
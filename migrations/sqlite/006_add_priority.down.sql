@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN priority;
+ALTER TABLE workflow_executions DROP COLUMN priority;
@@ -0,0 +1,2 @@
+-- Named task queue that routes a task to the workers registering its action
+ALTER TABLE tasks ADD COLUMN task_queue VARCHAR(100) NOT NULL DEFAULT 'default';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_tenant;
+DROP INDEX IF EXISTS idx_webhook_subscriptions_tenant;
+DROP INDEX IF EXISTS idx_tasks_tenant;
+DROP INDEX IF EXISTS idx_workflow_executions_tenant_created;
+
+ALTER TABLE webhook_deliveries DROP COLUMN tenant;
+ALTER TABLE webhook_subscriptions DROP COLUMN tenant;
+ALTER TABLE tasks DROP COLUMN tenant;
+ALTER TABLE workflow_executions DROP COLUMN tenant;
@@ -0,0 +1,10 @@
+-- Tenant (namespace) of every workflow; API requests only see their tenant's rows
+ALTER TABLE workflow_executions ADD COLUMN tenant VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE tasks ADD COLUMN tenant VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_subscriptions ADD COLUMN tenant VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_deliveries ADD COLUMN tenant VARCHAR(63) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_tenant_created ON workflow_executions (tenant, created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_tenant ON tasks (tenant);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant ON webhook_subscriptions (tenant);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant ON webhook_deliveries (tenant);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workflow_executions_tenant_idempotency_key;
+
+ALTER TABLE workflow_executions DROP COLUMN idempotency_key;
@@ -0,0 +1,5 @@
+-- Idempotency-Key of a submission; resubmitting with the same key returns the first execution
+ALTER TABLE workflow_executions ADD COLUMN idempotency_key VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workflow_executions_tenant_idempotency_key
+    ON workflow_executions (tenant, idempotency_key) WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE tasks DROP COLUMN progress_updated_at;
+ALTER TABLE tasks DROP COLUMN progress_details;
+ALTER TABLE tasks DROP COLUMN progress_message;
+ALTER TABLE tasks DROP COLUMN progress_percent;
@@ -0,0 +1,5 @@
+-- Latest progress reported by the handler of a running task
+ALTER TABLE tasks ADD COLUMN progress_percent INTEGER;
+ALTER TABLE tasks ADD COLUMN progress_message TEXT;
+ALTER TABLE tasks ADD COLUMN progress_details TEXT;
+ALTER TABLE tasks ADD COLUMN progress_updated_at DATETIME;
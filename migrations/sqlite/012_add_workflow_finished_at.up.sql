@@ -0,0 +1,2 @@
+-- When the last task of a workflow finished and its final event was announced
+ALTER TABLE workflow_executions ADD COLUMN finished_at DATETIME;
@@ -0,0 +1,2 @@
+-- When the worker running a task claimed it
+ALTER TABLE tasks ADD COLUMN started_at DATETIME;
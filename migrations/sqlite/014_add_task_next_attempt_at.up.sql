@@ -0,0 +1,2 @@
+-- When a retry delayed by its handler may run
+ALTER TABLE tasks ADD COLUMN next_attempt_at DATETIME;
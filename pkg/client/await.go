@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Result is the final state of a workflow returned by Await
+type Result struct {
+	Workflow *Workflow
+	Outputs  map[string]json.RawMessage // Output of each COMPLETED task by ref_id
+}
+
+// Await waits for the workflow to finish and returns its final state. A workflow ending FAILED or
+// CANCELLED is returned with a *WorkflowError. Await follows the workflow's event stream,
+// reconnecting where it left off when the connection drops, until ctx is done.
+func (c *Client) Await(ctx context.Context, id uuid.UUID) (*Result, error) {
+	lastEventID := ""
+	for attempt := 0; ; attempt++ {
+		finished, err := c.watch(ctx, id, &lastEventID)
+		if finished {
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil && !transient(err) {
+			return nil, err
+		}
+		if err := c.sleep(ctx, c.backoff(attempt, retryAfter(err))); err != nil {
+			return nil, err
+		}
+	}
+
+	wf, err := c.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return result(wf)
+}
+
+// watch reads the workflow's event stream until its final event, which reports finished, or
+// until the stream ends. lastEventID tracks the position to resume from.
+func (c *Client) watch(ctx context.Context, id uuid.UUID, lastEventID *string) (finished bool, err error) {
+	header := http.Header{"Accept": {"text/event-stream"}}
+	if *lastEventID != "" {
+		header.Set("Last-Event-ID", *lastEventID)
+	}
+	resp, err := c.send(ctx, http.MethodGet, "/workflows/"+id.String()+"/events", header, nil)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode >= 300 {
+		return false, readError(resp)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var eventID, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line ends the event
+			if eventID != "" {
+				*lastEventID = eventID
+			}
+			if isFinal(data) {
+				return true, nil
+			}
+			eventID, data = "", ""
+		case strings.HasPrefix(line, "id:"):
+			eventID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	return false, scanner.Err()
+}
+
+func isFinal(data string) bool {
+	if data == "" {
+		return false
+	}
+	var event struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal([]byte(data), &event) != nil {
+		return false
+	}
+	switch event.Type {
+	case "workflow.completed", "workflow.failed", "workflow.cancelled":
+		return true
+	}
+	return false
+}
+
+func result(wf *Workflow) (*Result, error) {
+	res := &Result{Workflow: wf, Outputs: make(map[string]json.RawMessage)}
+	failed := make(map[string]string)
+	for _, task := range wf.Tasks {
+		switch task.Status {
+		case "COMPLETED":
+			res.Outputs[task.RefID] = task.Output
+		case "FAILED":
+			failed[task.RefID] = task.LastError
+		}
+	}
+
+	if wf.Status != "COMPLETED" {
+		return res, &WorkflowError{ID: wf.ID, Status: wf.Status, Errors: failed}
+	}
+	return res, nil
+}
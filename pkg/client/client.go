@@ -0,0 +1,281 @@
+// Package client is the Go SDK of the Tempo REST API: it builds and submits workflow DAGs,
+// waits for their outputs and retries transient failures.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config configures a Client; only BaseURL is required
+type Config struct {
+	BaseURL     string // e.g. "http://localhost:8080"
+	APIKey      string // Sent in X-API-Key
+	BearerToken string // JWT sent in Authorization
+	Tenant      string // Sent in X-Tenant-ID; empty for the default tenant or the credentials' tenant
+
+	HTTPClient *http.Client // Default http.DefaultClient; its Timeout would also cut Await streams
+
+	// Transient failures (network errors, 429, 500, 502, 503 and 504) are retried with
+	// exponential backoff and jitter, honoring Retry-After
+	MaxRetries int           // Default 4; negative disables retries
+	MinBackoff time.Duration // Default 200ms
+	MaxBackoff time.Duration // Default 10s
+}
+
+// Client calls the Tempo REST API; it is safe for concurrent use
+type Client struct {
+	cfg     Config
+	baseURL string
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("client: BaseURL is required")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 4
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 200 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Second
+	}
+	return &Client{cfg: cfg, baseURL: strings.TrimRight(cfg.BaseURL, "/") + "/api/v1"}, nil
+}
+
+// Workflow is a workflow execution with the state of its tasks
+type Workflow struct {
+	ID        uuid.UUID `json:"execution_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Tenant    string    `json:"tenant"`
+	Type      string    `json:"type"`
+	Status    string    `json:"status"` // RUNNING, COMPLETED, FAILED or CANCELLED
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Tasks     []Task    `json:"tasks"`
+}
+
+// Task is the state of one task of a Workflow
+type Task struct {
+	ID           uuid.UUID       `json:"task_id"`
+	RefID        string          `json:"ref_id"`
+	Action       string          `json:"action"`
+	Dependencies []string        `json:"dependencies"`
+	TaskQueue    string          `json:"task_queue"`
+	Priority     int             `json:"priority"`
+	Status       string          `json:"status"` // PENDING, QUEUED, RUNNING, COMPLETED, FAILED or SKIPPED
+	Attempts     int             `json:"attempts"`
+	MaxRetries   int             `json:"max_retries"`
+	WorkerID     string          `json:"worker_id"`
+	LastError    string          `json:"last_error"`
+	Output       json.RawMessage `json:"output"`
+	Progress     *TaskProgress   `json:"progress"` // Nil until the running attempt reports progress
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// TaskProgress is the latest progress a task's handler reported
+type TaskProgress struct {
+	Percent   int             `json:"percent"`
+	Message   string          `json:"message"`
+	Details   json.RawMessage `json:"details"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Submit validates and submits the workflow, returning its execution ID. Retries reuse the
+// workflow's idempotency key, so a retried submission never starts a second workflow.
+func (c *Client) Submit(ctx context.Context, wf *WorkflowBuilder) (uuid.UUID, error) {
+	if err := wf.Validate(); err != nil {
+		return uuid.Nil, err
+	}
+	body, err := json.Marshal(wf.request())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	key := wf.idempotencyKey
+	if key == "" {
+		key = uuid.NewString()
+	}
+
+	var resp struct {
+		ID uuid.UUID `json:"execution_id"`
+	}
+	header := http.Header{"Idempotency-Key": {key}}
+	if err := c.do(ctx, http.MethodPost, "/workflows", header, body, &resp); err != nil {
+		return uuid.Nil, err
+	}
+	return resp.ID, nil
+}
+
+// Get returns the workflow with the state of its tasks
+func (c *Client) Get(ctx context.Context, id uuid.UUID) (*Workflow, error) {
+	var wf Workflow
+	if err := c.do(ctx, http.MethodGet, "/workflows/"+id.String(), nil, nil, &wf); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// Cancel stops a running workflow; an *APIError with status 409 means it already finished
+func (c *Client) Cancel(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodPost, "/workflows/"+id.String()+"/cancel", nil, nil, nil)
+}
+
+// do sends the request, retrying transient failures, and decodes a JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, body []byte, out any) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, path, header, body)
+		if err != nil {
+			if ctx.Err() != nil || attempt >= c.cfg.MaxRetries {
+				return err
+			}
+			if err := c.sleep(ctx, c.backoff(attempt, 0)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if resp.StatusCode < 300 {
+			defer resp.Body.Close()
+			if out == nil {
+				return nil
+			}
+			return json.NewDecoder(resp.Body).Decode(out)
+		}
+
+		apiErr := readError(resp)
+		if !transient(apiErr) || attempt >= c.cfg.MaxRetries {
+			return apiErr
+		}
+		if err := c.sleep(ctx, c.backoff(attempt, retryAfter(apiErr))); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, header http.Header, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+	return c.cfg.HTTPClient.Do(req)
+}
+
+// authorize adds the credentials and tenant to the request
+func (c *Client) authorize(req *http.Request) {
+	if c.cfg.APIKey != "" {
+		req.Header.Set("X-API-Key", c.cfg.APIKey)
+	}
+	if c.cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.BearerToken)
+	}
+	if c.cfg.Tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.cfg.Tenant)
+	}
+}
+
+// readError converts a failed response into a *ValidationError, *APIError or *ServerError
+func readError(resp *http.Response) error {
+	defer resp.Body.Close()
+	var body struct {
+		Error string `json:"error"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(raw, &body) != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(raw))
+	}
+	if body.Error == "" {
+		body.Error = http.StatusText(resp.StatusCode)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest:
+		return &ValidationError{Problems: []string{body.Error}}
+	case resp.StatusCode >= 500:
+		return &ServerError{StatusCode: resp.StatusCode, Message: body.Error}
+	}
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: body.Error}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+// transient reports whether a failed request may succeed when retried: network errors, 429 and
+// 5xx responses other than 501
+func transient(err error) bool {
+	var validationErr *ValidationError
+	var apiErr *APIError
+	var serverErr *ServerError
+	switch {
+	case errors.As(err, &validationErr):
+		return false
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode == http.StatusTooManyRequests
+	case errors.As(err, &serverErr):
+		return serverErr.StatusCode != http.StatusNotImplemented
+	}
+	return true
+}
+
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// backoff returns the wait before the retry following attempt: the server's Retry-After if it
+// sent one, else an exponential delay with jitter between MinBackoff and MaxBackoff
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	delay := c.cfg.MinBackoff << min(attempt, 20)
+	if delay <= 0 || delay > c.cfg.MaxBackoff {
+		delay = c.cfg.MaxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
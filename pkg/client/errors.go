@@ -0,0 +1,59 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ValidationError reports a workflow the server refuses: found by the builder before it is sent,
+// or returned by the server with 400
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid workflow: " + strings.Join(e.Problems, "; ")
+}
+
+// APIError is a request the server refused for a reason other than validation, e.g. 401, 403,
+// 404, 409 or a 429 still returned after the retries
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // From the Retry-After header of 429 responses
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("tempo: %d %s", e.StatusCode, e.Message)
+}
+
+// ServerError is a request the server failed (5xx) on every attempt
+type ServerError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("tempo: server error %d: %s", e.StatusCode, e.Message)
+}
+
+// WorkflowError is returned by Await for a workflow that ended FAILED or CANCELLED
+type WorkflowError struct {
+	ID     uuid.UUID
+	Status string
+	Errors map[string]string // Last error of each FAILED task by ref_id
+}
+
+func (e *WorkflowError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("workflow %s %s", e.ID, strings.ToLower(e.Status))
+	}
+	failed := make([]string, 0, len(e.Errors))
+	for refID, msg := range e.Errors {
+		failed = append(failed, refID+": "+msg)
+	}
+	return fmt.Sprintf("workflow %s %s (%s)", e.ID, strings.ToLower(e.Status), strings.Join(failed, ", "))
+}
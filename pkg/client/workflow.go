@@ -0,0 +1,233 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// WorkflowBuilder describes a workflow DAG to submit:
+//
+//	wf := client.NewWorkflow("onboarding", userID)
+//	wf.Task("welcome", "send_email").Input(email)
+//	wf.Task("account", "create_account").DependsOn("welcome").Priority(8)
+type WorkflowBuilder struct {
+	workflowType   string
+	userID         uuid.UUID
+	priority       *int
+	callbackURL    string
+	callbackEvents []string
+	idempotencyKey string
+	tasks          []*TaskBuilder
+}
+
+// NewWorkflow starts a workflow of the type submitted on behalf of userID
+func NewWorkflow(workflowType string, userID uuid.UUID) *WorkflowBuilder {
+	return &WorkflowBuilder{workflowType: workflowType, userID: userID}
+}
+
+// Task adds a task running action; refID names it in the DependsOn of other tasks
+func (w *WorkflowBuilder) Task(refID, action string) *TaskBuilder {
+	task := &TaskBuilder{refID: refID, action: action}
+	w.tasks = append(w.tasks, task)
+	return task
+}
+
+// Priority sets the priority of the workflow, from 0 (lowest) to 9 (highest); default 5
+func (w *WorkflowBuilder) Priority(priority int) *WorkflowBuilder {
+	w.priority = &priority
+	return w
+}
+
+// Callback has the server post the events (default: the final workflow events) to url
+func (w *WorkflowBuilder) Callback(url string, events ...string) *WorkflowBuilder {
+	w.callbackURL = url
+	w.callbackEvents = events
+	return w
+}
+
+// IdempotencyKey makes submitting the workflow again return the first execution instead of
+// starting another. Without one, Submit generates a key covering its own retries only.
+func (w *WorkflowBuilder) IdempotencyKey(key string) *WorkflowBuilder {
+	w.idempotencyKey = key
+	return w
+}
+
+// TaskBuilder describes one task of a WorkflowBuilder
+type TaskBuilder struct {
+	refID        string
+	action       string
+	dependencies []string
+	input        json.RawMessage
+	inputErr     error
+	priority     *int
+	taskQueue    string
+}
+
+// DependsOn makes the task wait for the tasks with the ref IDs to complete
+func (t *TaskBuilder) DependsOn(refIDs ...string) *TaskBuilder {
+	t.dependencies = append(t.dependencies, refIDs...)
+	return t
+}
+
+// Input sets the input handed to the action, any value encoding to a JSON object
+func (t *TaskBuilder) Input(input any) *TaskBuilder {
+	t.input, t.inputErr = json.Marshal(input)
+	return t
+}
+
+// Priority overrides the workflow priority for this task
+func (t *TaskBuilder) Priority(priority int) *TaskBuilder {
+	t.priority = &priority
+	return t
+}
+
+// TaskQueue overrides the task queue the server routes the action to
+func (t *TaskBuilder) TaskQueue(taskQueue string) *TaskBuilder {
+	t.taskQueue = taskQueue
+	return t
+}
+
+// Validate reports the problems the server would refuse the workflow for, including unknown
+// dependencies and cycles, as a *ValidationError
+func (w *WorkflowBuilder) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if w.workflowType == "" {
+		add("type is required")
+	}
+	if w.userID == uuid.Nil {
+		add("user_id is required")
+	}
+	if w.priority != nil && (*w.priority < 0 || *w.priority > 9) {
+		add("priority must be between 0 and 9")
+	}
+	if len(w.tasks) == 0 {
+		add("at least one task is required")
+	}
+
+	refs := make(map[string]bool, len(w.tasks))
+	for _, task := range w.tasks {
+		switch {
+		case task.refID == "":
+			add("task ref_id is required")
+		case refs[task.refID]:
+			add("duplicate task %q", task.refID)
+		}
+		refs[task.refID] = true
+
+		if task.action == "" {
+			add("task %q: action is required", task.refID)
+		}
+		if task.priority != nil && (*task.priority < 0 || *task.priority > 9) {
+			add("task %q: priority must be between 0 and 9", task.refID)
+		}
+		if task.inputErr != nil {
+			add("task %q: input: %v", task.refID, task.inputErr)
+		} else if len(task.input) > 0 && string(task.input) != "null" && task.input[0] != '{' {
+			add("task %q: input must be a JSON object", task.refID)
+		}
+	}
+	for _, task := range w.tasks {
+		for _, dep := range task.dependencies {
+			if !refs[dep] {
+				add("task %q depends on unknown task %q", task.refID, dep)
+			}
+		}
+	}
+	if len(problems) == 0 {
+		if cycle := w.findCycle(); cycle != "" {
+			add("tasks form a cycle through %q", cycle)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// findCycle runs Kahn's algorithm and returns a task left over on a cycle, or "" if none is
+func (w *WorkflowBuilder) findCycle() string {
+	inDegree := make(map[string]int, len(w.tasks))
+	children := make(map[string][]string)
+	for _, task := range w.tasks {
+		inDegree[task.refID] += 0
+		for _, dep := range task.dependencies {
+			inDegree[task.refID]++
+			children[dep] = append(children[dep], task.refID)
+		}
+	}
+
+	var ready []string
+	for refID, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, refID)
+		}
+	}
+	for len(ready) > 0 {
+		refID := ready[0]
+		ready = ready[1:]
+		delete(inDegree, refID)
+		for _, child := range children[refID] {
+			inDegree[child]--
+			if inDegree[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+
+	for refID := range inDegree {
+		return refID
+	}
+	return ""
+}
+
+// submitRequest is the body of POST /api/v1/workflows
+type submitRequest struct {
+	Type           string        `json:"type"`
+	UserID         uuid.UUID     `json:"user_id"`
+	Tasks          []taskRequest `json:"tasks"`
+	Priority       *int          `json:"priority,omitempty"`
+	CallbackURL    string        `json:"callback_url,omitempty"`
+	CallbackEvents []string      `json:"callback_events,omitempty"`
+}
+
+type taskRequest struct {
+	RefID        string          `json:"ref_id"`
+	Action       string          `json:"action"`
+	Dependencies []string        `json:"dependencies"`
+	Input        json.RawMessage `json:"input"`
+	Priority     *int            `json:"priority,omitempty"`
+	TaskQueue    string          `json:"task_queue,omitempty"`
+}
+
+func (w *WorkflowBuilder) request() submitRequest {
+	req := submitRequest{
+		Type:           w.workflowType,
+		UserID:         w.userID,
+		Tasks:          make([]taskRequest, 0, len(w.tasks)),
+		Priority:       w.priority,
+		CallbackURL:    w.callbackURL,
+		CallbackEvents: w.callbackEvents,
+	}
+	for _, task := range w.tasks {
+		input := task.input
+		if len(input) == 0 || string(input) == "null" {
+			input = json.RawMessage("{}")
+		}
+		req.Tasks = append(req.Tasks, taskRequest{
+			RefID:        task.refID,
+			Action:       task.action,
+			Dependencies: task.dependencies,
+			Input:        input,
+			Priority:     task.priority,
+			TaskQueue:    task.taskQueue,
+		})
+	}
+	return req
+}
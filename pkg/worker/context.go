@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ExecutionInfo describes the task attempt a handler is running for
+type ExecutionInfo struct {
+	ExecutionID uuid.UUID
+	TaskID      uuid.UUID
+	RefID       string
+	Attempt     int // 1 for the first run, incremented on every retry
+
+	// IdempotencyKey is identical across retries and redeliveries of the same task,
+	// so handlers can pass it to downstream APIs to deduplicate side effects.
+	IdempotencyKey string
+}
+
+type executionInfoKey struct{}
+
+// WithExecutionInfo returns a copy of ctx carrying info
+func WithExecutionInfo(ctx context.Context, info ExecutionInfo) context.Context {
+	return context.WithValue(ctx, executionInfoKey{}, info)
+}
+
+// ExecutionInfoFromContext returns the execution info of the task a handler is running for
+func ExecutionInfoFromContext(ctx context.Context) (ExecutionInfo, bool) {
+	info, ok := ctx.Value(executionInfoKey{}).(ExecutionInfo)
+	return info, ok
+}
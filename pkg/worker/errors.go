@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrorKind tells the worker what to do with a task whose handler returned an error
+type ErrorKind string
+
+const (
+	// KindRetryable errors are retried until the task's max_retries are used up. Errors
+	// without a kind are retryable.
+	KindRetryable ErrorKind = "retryable"
+
+	// KindNonRetryable errors fail the task at once, skipping its dependent tasks
+	KindNonRetryable ErrorKind = "non_retryable"
+
+	// KindSkip errors end the task as SKIPPED, e.g. when there's nothing to do. Unlike a task
+	// skipped because a dependency failed, its dependent tasks still run.
+	KindSkip ErrorKind = "skip"
+
+	// KindCancelled errors mean the handler stopped because its context was canceled. On
+	// shutdown the attempt isn't counted and the task goes back to its queue; otherwise the
+	// attempt failed and is retried like a retryable error.
+	KindCancelled ErrorKind = "cancelled"
+)
+
+// Error is a handler error of a given kind
+type Error struct {
+	Kind  ErrorKind
+	Err   error
+	Delay time.Duration // Wait before the retry of a KindRetryable error, 0 for none
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable marks err as worth retrying
+func Retryable(err error) error {
+	return &Error{Kind: KindRetryable, Err: err}
+}
+
+// RetryAfter marks err as worth retrying once delay has passed, e.g. when a downstream API
+// answers 429 with a Retry-After header
+func RetryAfter(err error, delay time.Duration) error {
+	return &Error{Kind: KindRetryable, Err: err, Delay: delay}
+}
+
+// NonRetryable marks err as permanent, e.g. for invalid input or a 4xx response
+func NonRetryable(err error) error {
+	return &Error{Kind: KindNonRetryable, Err: err}
+}
+
+// Skip ends the task as SKIPPED with reason recorded as its output
+func Skip(reason error) error {
+	return &Error{Kind: KindSkip, Err: reason}
+}
+
+// Cancelled marks err as caused by the handler's context being canceled
+func Cancelled(err error) error {
+	return &Error{Kind: KindCancelled, Err: err}
+}
+
+// KindOf returns the kind of the first *Error in err's chain, or KindRetryable if there is none
+func KindOf(err error) ErrorKind {
+	var kindErr *Error
+	if errors.As(err, &kindErr) {
+		return kindErr.Kind
+	}
+	return KindRetryable
+}
+
+// RetryDelay returns how long to wait before retrying err, 0 if it can be retried at once
+func RetryDelay(err error) time.Duration {
+	var kindErr *Error
+	if errors.As(err, &kindErr) && kindErr.Kind == KindRetryable {
+		return kindErr.Delay
+	}
+	return 0
+}
+
+func isClassified(err error) bool {
+	var kindErr *Error
+	return errors.As(err, &kindErr)
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Recover returns a middleware turning a panicking handler into a non-retryable error carrying
+// the panic value and stack trace. The worker recovers panics too; Recover lets the middleware
+// outside it see the failure.
+func Recover() Middleware {
+	return func(action string, next Handler) Handler {
+		return func(ctx context.Context, input []byte) (output []byte, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					output, err = nil, NonRetryable(fmt.Errorf("handler of %s panicked: %v\n%s", action, r, debug.Stack()))
+				}
+			}()
+			return next(ctx, input)
+		}
+	}
+}
+
+// Logging returns a middleware logging the outcome and duration of every handler run
+func Logging(logger *log.Logger) Middleware {
+	return func(action string, next Handler) Handler {
+		return func(ctx context.Context, input []byte) ([]byte, error) {
+			task := action
+			if info, ok := ExecutionInfoFromContext(ctx); ok {
+				task = fmt.Sprintf("%s (task %s, attempt %d)", action, info.RefID, info.Attempt)
+			}
+
+			start := time.Now()
+			output, err := next(ctx, input)
+			if err != nil {
+				logger.Printf("Handler %s failed after %s (%s): %v", task, time.Since(start).Round(time.Millisecond), KindOf(err), err)
+			} else {
+				logger.Printf("Handler %s succeeded in %s", task, time.Since(start).Round(time.Millisecond))
+			}
+			return output, err
+		}
+	}
+}
+
+// Metrics returns a middleware counting handler runs in outcomes, a counter labelled by action
+// and outcome: "success" or the ErrorKind of the error
+func Metrics(outcomes *prometheus.CounterVec) Middleware {
+	return func(action string, next Handler) Handler {
+		return func(ctx context.Context, input []byte) ([]byte, error) {
+			output, err := next(ctx, input)
+			outcome := "success"
+			if err != nil {
+				outcome = string(KindOf(err))
+			}
+			outcomes.WithLabelValues(action, outcome).Inc()
+			return output, err
+		}
+	}
+}
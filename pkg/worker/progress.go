@@ -0,0 +1,30 @@
+package worker
+
+import "context"
+
+// ProgressReporter records the progress of the task a handler is running for
+type ProgressReporter interface {
+	// Report records percent (0 to 100), a message and optional details encoded as JSON.
+	// Reports may be coalesced, so only the latest is guaranteed to be stored.
+	Report(percent int, message string, details any)
+}
+
+type progressReporterKey struct{}
+
+// WithProgressReporter returns a copy of ctx carrying reporter
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, reporter)
+}
+
+// ReportProgress reports the progress of the task a handler is running for. It does nothing when
+// ctx carries no reporter, so handlers can call it unconditionally.
+//
+//	for i, row := range rows {
+//		...
+//		worker.ReportProgress(ctx, 100*(i+1)/len(rows), "importing", map[string]int{"rows": i + 1})
+//	}
+func ReportProgress(ctx context.Context, percent int, message string, details any) {
+	if reporter, ok := ctx.Value(progressReporterKey{}).(ProgressReporter); ok {
+		reporter.Report(percent, message, details)
+	}
+}
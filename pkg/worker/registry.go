@@ -0,0 +1,142 @@
+// Package worker is the SDK for writing task handlers: typed registration with JSON decoding and
+// input validation, error kinds that tell the worker whether to retry, and handler middleware.
+//
+//	registry := worker.NewRegistry()
+//	registry.Use(worker.Logging(log.Default()), worker.Recover())
+//	worker.Register(registry, "send_email", func(ctx context.Context, in Email) (Receipt, error) {
+//		...
+//	})
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Handler runs an action on the JSON input of a task and returns its JSON output. Output returned
+// with an error is recorded if the task fails for good.
+type Handler func(ctx context.Context, input []byte) ([]byte, error)
+
+// Middleware wraps the handler of an action, e.g. to log, measure or recover it
+type Middleware func(action string, next Handler) Handler
+
+// Registry collects the handlers of the actions a worker runs
+type Registry struct {
+	handlers   map[string]Handler
+	middleware []Middleware
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler of an action. Registering an action twice panics.
+func (r *Registry) Handle(action string, handler Handler) {
+	if action == "" || handler == nil {
+		panic("worker: action and handler are required")
+	}
+	if _, exists := r.handlers[action]; exists {
+		panic(fmt.Sprintf("worker: action %q registered twice", action))
+	}
+	r.handlers[action] = handler
+}
+
+// Use adds middleware wrapping every handler, including those registered earlier.
+// The first middleware is the outermost.
+func (r *Registry) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Actions returns the registered action names, sorted
+func (r *Registry) Actions() []string {
+	actions := make([]string, 0, len(r.handlers))
+	for action := range r.handlers {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// Handlers returns the handler of every action wrapped in the middleware
+func (r *Registry) Handlers() map[string]Handler {
+	handlers := make(map[string]Handler, len(r.handlers))
+	for action, handler := range r.handlers {
+		for i := len(r.middleware) - 1; i >= 0; i-- {
+			handler = r.middleware[i](action, handler)
+		}
+		handlers[action] = handler
+	}
+	return handlers
+}
+
+var validate = validator.New(validator.WithRequiredStructEnabled())
+
+// Validator is implemented by inputs that check themselves after decoding
+type Validator interface {
+	Validate() error
+}
+
+// Register registers a typed handler of an action. The task input is decoded into In and
+// validated by its `validate` struct tags and Validate method, if any; an invalid input fails the
+// task without retries. The handler's Out is encoded as the task output, and is kept with the
+// error if fn fails with a non-zero Out.
+//
+// A plain error returned by fn is retried; wrap it with RetryAfter, NonRetryable, Skip or
+// Cancelled to say otherwise. An error returned after ctx is done counts as Cancelled.
+func Register[In, Out any](r *Registry, action string, fn func(ctx context.Context, in In) (Out, error)) {
+	r.Handle(action, func(ctx context.Context, input []byte) ([]byte, error) {
+		var in In
+		if err := decodeInput(input, &in); err != nil {
+			return nil, NonRetryable(fmt.Errorf("invalid input: %w", err))
+		}
+
+		out, err := fn(ctx, in)
+		if err != nil {
+			if ctx.Err() != nil && !isClassified(err) {
+				err = Cancelled(err)
+			}
+			if reflect.ValueOf(&out).Elem().IsZero() {
+				return nil, err
+			}
+			output, _ := json.Marshal(out)
+			return output, err
+		}
+
+		output, err := json.Marshal(out)
+		if err != nil {
+			return nil, NonRetryable(fmt.Errorf("encoding output: %w", err))
+		}
+		return output, nil
+	})
+}
+
+func decodeInput(input []byte, in any) error {
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, in); err != nil {
+			return err
+		}
+	}
+
+	value := reflect.ValueOf(in).Elem()
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Struct {
+		if err := validate.Struct(value.Interface()); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := in.(Validator); ok {
+		return v.Validate()
+	}
+	if v, ok := value.Interface().(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}